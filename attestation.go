@@ -0,0 +1,85 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/flightctl/fips-validator/internal/executor"
+	"github.com/flightctl/fips-validator/internal/report"
+	"github.com/flightctl/fips-validator/internal/validation"
+)
+
+// wantSubjects returns whether the subjects of targets need to be determined
+// for an attestation.
+func wantSubjects() bool {
+	return outputFormat == "attestation"
+}
+
+// fileSubject returns the subject for the file at path.
+func fileSubject(path string) (report.Subject, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return report.Subject{}, fmt.Errorf("failed to hash %s: %v", path, err)
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return report.Subject{}, fmt.Errorf("failed to hash %s: %v", path, err)
+	}
+	return report.Subject{Name: path, Digest: map[string]string{"sha256": hex.EncodeToString(h.Sum(nil))}}, nil
+}
+
+// binarySubjects returns the subjects for the validated binaries of a root
+// filesystem at rootPath, which has no digest of its own. Skipped binaries and
+// binaries inside nested archives are left out.
+func binarySubjects(rootPath string, results []*validation.BinaryResult) ([]report.Subject, error) {
+	var subjects []report.Subject
+	for _, r := range results {
+		if r.Status == validation.StatusSkipped || strings.Contains(r.Path, "!") {
+			continue
+		}
+		s, err := fileSubject(filepath.Join(rootPath, r.Path))
+		if err != nil {
+			return nil, err
+		}
+		s.Name = r.Path
+		subjects = append(subjects, s)
+	}
+	return subjects, nil
+}
+
+// imageSubject returns the subject for a local image, identified by its
+// manifest digest.
+func imageSubject(imageRef string) (report.Subject, error) {
+	stdout, stderr, rc, err := executor.Execute(context.TODO(), "", "podman", "image", "inspect", "--format", "{{.Digest}}", imageRef)
+	if err != nil {
+		return report.Subject{}, commandError("failed to inspect image", err)
+	}
+	if rc != 0 {
+		return report.Subject{}, fmt.Errorf("failed to inspect image, exit code %d: %s", rc, string(stderr))
+	}
+	alg, digest, ok := strings.Cut(strings.TrimSpace(string(stdout)), ":")
+	if !ok {
+		return report.Subject{}, fmt.Errorf("unexpected image digest %q", strings.TrimSpace(string(stdout)))
+	}
+	return report.Subject{Name: imageRef, Digest: map[string]string{alg: digest}}, nil
+}
+
+// ostreeSubject returns the subject for an ostree commit, identified by its
+// SHA-256 checksum.
+func ostreeSubject(repo, ref string) (report.Subject, error) {
+	stdout, stderr, rc, err := executor.Execute(context.TODO(), "", "ostree", "rev-parse", "--repo="+repo, ref)
+	if err != nil {
+		return report.Subject{}, commandError("failed to resolve ostree ref", err)
+	}
+	if rc != 0 {
+		return report.Subject{}, fmt.Errorf("failed to resolve ostree ref, exit code %d: %s", rc, string(stderr))
+	}
+	return report.Subject{Name: repo + ":" + ref, Digest: map[string]string{"sha256": strings.TrimSpace(string(stdout))}}, nil
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/flightctl/fips-validator/internal/report"
+	"github.com/flightctl/fips-validator/internal/validation"
+)
+
+// parseBinaryFlags parses the flags given to binary mode instead of a binary,
+// and returns the file listing the binaries to validate.
+func parseBinaryFlags(args []string) (string, error) {
+	fs := flag.NewFlagSet("binary", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	file := fs.String("from-file", "", "Validate the binaries listed in a file")
+	if err := fs.Parse(args); err != nil {
+		return "", fmt.Errorf("binary: %v", err)
+	}
+	if *file == "" {
+		return "", fmt.Errorf("binary: --from-file requires a path")
+	}
+	if fs.NArg() > 0 {
+		return "", fmt.Errorf("binary: no binary may be given with --from-file")
+	}
+	return *file, nil
+}
+
+// validateBinaryList validates the binaries listed in the file at listPath,
+// e.g. the output of "go list -f '{{.Target}}' ./...", as a single target.
+// Relative paths are relative to the current directory. Listed binaries that
+// don't exist, e.g. because their packages haven't been built yet, are
+// skipped as not built rather than failing the target.
+func validateBinaryList(listPath string) (*report.Target, error) {
+	paths, err := readList(listPath, "binaries")
+	if err != nil {
+		return nil, err
+	}
+	name := listPath
+	if listPath == "-" {
+		name = "stdin"
+	}
+	info("Validating %d binaries listed in %s:\n", len(paths), name)
+
+	results := []*validation.BinaryResult{}
+	var subjects []report.Subject
+	seen := map[string]bool{}
+	for _, p := range paths {
+		path, err := filepath.Abs(p)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get absolute path: %v", err)
+		}
+		if seen[path] {
+			continue
+		}
+		seen[path] = true
+
+		if _, err := os.Stat(path); errors.Is(err, fs.ErrNotExist) {
+			result := &validation.BinaryResult{Path: path, Status: validation.StatusSkipped, Reason: validation.SkipNotBuilt}
+			printBinaryResult(out, result)
+			results = append(results, result)
+			continue
+		}
+		result, err := checkBinary(path)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, result)
+		if wantSubjects() {
+			subject, err := fileSubject(path)
+			if err != nil {
+				return nil, err
+			}
+			subjects = append(subjects, subject)
+		}
+	}
+
+	t := newTarget("binary", name, nil, results)
+	t.Subjects = subjects
+	return t, nil
+}
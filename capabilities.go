@@ -0,0 +1,104 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	rtdebug "runtime/debug"
+	"strings"
+
+	"github.com/flightctl/fips-validator/internal/report"
+	"github.com/flightctl/fips-validator/internal/validation"
+	"github.com/flightctl/fips-validator/pkg/elfinfo"
+)
+
+// modes are the modes a target can be validated in, and outputFormats the
+// values of --output.
+var (
+	modes         = []string{"binary", "rpm", "image", "dir", "ostree", "tar", "zip", "squashfs", "auto"}
+	outputFormats = []string{"text", "json", "manifest", "attestation"}
+	subcommands   = []string{"explain", "verify", "diff", "capabilities", "selftest"}
+)
+
+// capabilities describes what this build of fips-validator supports, for
+// tools that wrap it.
+type capabilities struct {
+	Version        string    `json:"version"`
+	Modes          []string  `json:"modes"`
+	Subcommands    []string  `json:"subcommands"`
+	OutputFormats  []string  `json:"outputFormats"`
+	FormatVersions []int     `json:"formatVersions"`
+	Architectures  []string  `json:"architectures"`
+	Checks         []string  `json:"checks"`
+	Rules          rulesInfo `json:"rules"`
+}
+
+// rulesInfo describes the built-in Go version rules.
+type rulesInfo struct {
+	Version      string   `json:"version"`
+	MinGoVersion string   `json:"minGoVersion"`
+	GoVersions   []string `json:"goVersions"`
+}
+
+// printCapabilities prints the capabilities as text or, with "--output json"
+// in args or given before the subcommand, as JSON.
+func printCapabilities(args []string) error {
+	fs := flag.NewFlagSet("capabilities", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	format := fs.String("output", outputFormat, "Output format (text or json)")
+	if err := fs.Parse(args); err != nil {
+		return fmt.Errorf("capabilities: %v", err)
+	}
+	if fs.NArg() > 0 {
+		return fmt.Errorf("capabilities: incorrect number of arguments")
+	}
+
+	c := &capabilities{
+		Version:        toolVersionString(),
+		Modes:          modes,
+		Subcommands:    subcommands,
+		OutputFormats:  outputFormats,
+		FormatVersions: report.FormatVersions(),
+		Architectures:  elfinfo.Archs,
+	}
+	for _, ci := range validation.Checks() {
+		c.Checks = append(c.Checks, ci.ID)
+	}
+	rules := validation.DefaultRules()
+	c.Rules = rulesInfo{Version: rules.Version, MinGoVersion: rules.MinGoVersion}
+	for _, gr := range rules.GoVersions {
+		c.Rules.GoVersions = append(c.Rules.GoVersions, gr.Versions)
+	}
+
+	switch *format {
+	case "json":
+		enc := json.NewEncoder(os.Stdout)
+		if !jsonCompact {
+			enc.SetIndent("", "  ")
+		}
+		return enc.Encode(c)
+	case "text":
+		fmt.Printf("version:        %s\n", c.Version)
+		fmt.Printf("modes:          %s\n", strings.Join(c.Modes, ", "))
+		fmt.Printf("subcommands:    %s\n", strings.Join(c.Subcommands, ", "))
+		fmt.Printf("output formats: %s\n", strings.Join(c.OutputFormats, ", "))
+		fmt.Printf("report formats: %s\n", strings.Trim(fmt.Sprint(c.FormatVersions), "[]"))
+		fmt.Printf("architectures:  %s\n", strings.Join(c.Architectures, ", "))
+		fmt.Printf("checks:         %s\n", strings.Join(c.Checks, ", "))
+		fmt.Printf("rules:          %s (Go %s)\n", c.Rules.Version, strings.Join(c.Rules.GoVersions, ", "))
+		return nil
+	}
+	return fmt.Errorf("capabilities: unsupported output format %q", *format)
+}
+
+// toolVersionString returns the module version this build of fips-validator
+// was built from, e.g. "v0.3.0" if installed with "go install", or "(devel)".
+func toolVersionString() string {
+	bi, ok := rtdebug.ReadBuildInfo()
+	if !ok || bi.Main.Version == "" {
+		return "(devel)"
+	}
+	return bi.Main.Version
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"sync"
+
+	"github.com/flightctl/fips-validator/internal/report"
+)
+
+// checkpoint records the images of a batch that have been validated, with
+// their results, so that a restarted batch only validates the remaining
+// images. The checkpoint file holds one target of the JSON report per line.
+type checkpoint struct {
+	mu sync.Mutex
+	f  *os.File
+}
+
+// readCheckpoint returns the targets recorded in the checkpoint file at path by
+// name, or none if the file doesn't exist yet. An incomplete last line, e.g.
+// because the run was killed while writing it, is ignored.
+func readCheckpoint(path string) (map[string]*report.Target, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return map[string]*report.Target{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read checkpoint: %v", err)
+	}
+	targets := map[string]*report.Target{}
+	lines := bytes.Split(data, []byte("\n"))
+	for i, line := range lines {
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+		var t report.Target
+		if err := json.Unmarshal(line, &t); err != nil {
+			if i == len(lines)-1 {
+				debug("ignoring incomplete last line of checkpoint %s", path)
+				break
+			}
+			return nil, fmt.Errorf("failed to parse checkpoint %s, line %d: %v", path, i+1, err)
+		}
+		targets[t.Name] = &t
+	}
+	return targets, nil
+}
+
+// openCheckpoint opens the checkpoint file at path for recording further
+// targets, creating it if it doesn't exist.
+func openCheckpoint(path string) (*checkpoint, error) {
+	data, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("failed to read checkpoint: %v", err)
+	}
+	// Drop an incomplete last line, which readCheckpoint ignored, so that
+	// the next target starts on a line of its own.
+	if len(data) > 0 && data[len(data)-1] != '\n' {
+		if err := os.Truncate(path, int64(bytes.LastIndexByte(data, '\n')+1)); err != nil {
+			return nil, fmt.Errorf("failed to repair checkpoint: %v", err)
+		}
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open checkpoint: %v", err)
+	}
+	return &checkpoint{f: f}, nil
+}
+
+// record appends t to the checkpoint and syncs it to disk, so that t is
+// skipped if the batch is restarted, even after a crash.
+func (c *checkpoint) record(t *report.Target) error {
+	line, err := json.Marshal(t)
+	if err != nil {
+		return fmt.Errorf("failed to encode checkpoint: %v", err)
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, err := c.f.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("failed to write checkpoint: %v", err)
+	}
+	if err := c.f.Sync(); err != nil {
+		return fmt.Errorf("failed to write checkpoint: %v", err)
+	}
+	return nil
+}
+
+func (c *checkpoint) Close() error {
+	return c.f.Close()
+}
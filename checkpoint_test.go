@@ -0,0 +1,102 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/flightctl/fips-validator/internal/report"
+	"github.com/flightctl/fips-validator/internal/validation"
+)
+
+// fakePodman puts a podman script first in PATH that mounts every image at an
+// empty directory, and returns the file it logs its arguments to, one command
+// per line.
+func fakePodman(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	log := filepath.Join(dir, "podman.log")
+	script := fmt.Sprintf("#!/bin/sh\necho \"$*\" >> %s\nif [ \"$1 $2\" = \"image mount\" ]; then echo %s; fi\n", log, t.TempDir())
+	if err := os.WriteFile(filepath.Join(dir, "podman"), []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+	return log
+}
+
+func TestValidateAllImagesCheckpoint(t *testing.T) {
+	oldOut, oldPolicy, oldJobs := out, policy, targetJobs
+	t.Cleanup(func() { out, policy, targetJobs = oldOut, oldPolicy, oldJobs })
+	out = io.Discard
+	policy = validation.DefaultPolicy()
+
+	refs := []string{"img-a", "img-b", "img-c", "img-d"}
+	for _, jobs := range []int{1, 2} {
+		t.Run(fmt.Sprintf("target jobs %d", jobs), func(t *testing.T) {
+			targetJobs = jobs
+			log := fakePodman(t)
+			dir := t.TempDir()
+			list := filepath.Join(dir, "images.txt")
+			if err := os.WriteFile(list, []byte(strings.Join(refs, "\n")+"\n"), 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			// A checkpoint of a run that was killed while recording img-d.
+			var lines []string
+			for _, ref := range []string{"img-a", "img-c"} {
+				line, err := json.Marshal(&report.Target{Mode: "image", Name: ref, Binaries: []*validation.BinaryResult{{Path: "/recorded", Status: validation.StatusPassed}}})
+				if err != nil {
+					t.Fatal(err)
+				}
+				lines = append(lines, string(line))
+			}
+			checkpointPath := filepath.Join(dir, "checkpoint.jsonl")
+			partial := strings.Join(lines, "\n") + "\n" + `{"mode":"image","name":"img-d","bin`
+			if err := os.WriteFile(checkpointPath, []byte(partial), 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			targets, err := validateAllImages(&imageBatch{file: list, checkpoint: checkpointPath})
+			if err != nil {
+				t.Fatalf("validateAllImages: %v", err)
+			}
+			if len(targets) != len(refs) {
+				t.Fatalf("validateAllImages returned %d targets, want %d", len(targets), len(refs))
+			}
+			for i, target := range targets {
+				if target.Name != refs[i] || len(target.Errors) > 0 {
+					t.Errorf("target %d = %s with errors %q, want %s without errors", i, target.Name, target.Errors, refs[i])
+				}
+				recorded := len(target.Binaries) == 1 && target.Binaries[0].Path == "/recorded"
+				if want := refs[i] == "img-a" || refs[i] == "img-c"; recorded != want {
+					t.Errorf("%s: result from the checkpoint = %v, want %v", refs[i], recorded, want)
+				}
+			}
+
+			data, err := os.ReadFile(log)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, ref := range refs {
+				mounted := strings.Contains(string(data), "image mount "+ref+"\n")
+				if want := ref == "img-b" || ref == "img-d"; mounted != want {
+					t.Errorf("%s: mounted = %v, want %v", ref, mounted, want)
+				}
+			}
+
+			// The images validated now are recorded after the repaired
+			// checkpoint, so that a further restart skips all of them.
+			recorded, err := readCheckpoint(checkpointPath)
+			if err != nil {
+				t.Fatalf("readCheckpoint: %v", err)
+			}
+			if len(recorded) != len(refs) {
+				t.Errorf("checkpoint records %d images, want %d", len(recorded), len(refs))
+			}
+		})
+	}
+}
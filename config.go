@@ -0,0 +1,97 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+
+	"gopkg.in/yaml.v3"
+)
+
+// defaultConfigFile is the config file that is picked up from the current
+// working directory if no --config flag is given.
+const defaultConfigFile = "fips-validator.yaml"
+
+// mappingValue is implemented by flag values that can also be set from a
+// mapping in the config file, e.g. an inline policy.
+type mappingValue interface {
+	SetMapping(data []byte) error
+}
+
+// loadConfig reads the YAML config file at path and applies its values to all
+// flags of fs that have not been set explicitly on the command line. Keys of
+// the config file are flag names, values are either scalars or, for flags that
+// can be repeated, lists of scalars. Flags whose values implement mappingValue
+// also accept a mapping. If path is empty, defaultConfigFile is used if it
+// exists.
+func loadConfig(fs *flag.FlagSet, path string) error {
+	explicit := path != ""
+	if !explicit {
+		path = defaultConfigFile
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if !explicit && errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return fmt.Errorf("failed to read config file: %v", err)
+	}
+
+	var settings map[string]interface{}
+	if err := yaml.Unmarshal(data, &settings); err != nil {
+		return fmt.Errorf("failed to parse config file %s: %v", path, err)
+	}
+
+	setOnCommandLine := map[string]bool{}
+	fs.Visit(func(f *flag.Flag) {
+		setOnCommandLine[f.Name] = true
+	})
+
+	// Apply settings in a stable order so errors are reported deterministically.
+	keys := make([]string, 0, len(settings))
+	for k := range settings {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		if key == "config" || fs.Lookup(key) == nil {
+			return fmt.Errorf("config file %s: unknown setting %q", path, key)
+		}
+		if setOnCommandLine[key] {
+			continue
+		}
+
+		if m, isMap := settings[key].(map[string]interface{}); isMap {
+			mv, ok := fs.Lookup(key).Value.(mappingValue)
+			if !ok {
+				return fmt.Errorf("config file %s: setting %q must be a scalar or a list of scalars", path, key)
+			}
+			data, err := yaml.Marshal(m)
+			if err != nil {
+				return fmt.Errorf("config file %s: invalid value for setting %q: %v", path, key, err)
+			}
+			if err := mv.SetMapping(data); err != nil {
+				return fmt.Errorf("config file %s: invalid value for setting %q: %v", path, key, err)
+			}
+			continue
+		}
+
+		values, ok := settings[key].([]interface{})
+		if !ok {
+			values = []interface{}{settings[key]}
+		}
+		for _, v := range values {
+			if _, isMap := v.(map[string]interface{}); isMap {
+				return fmt.Errorf("config file %s: setting %q must be a scalar or a list of scalars", path, key)
+			}
+			if err := fs.Set(key, fmt.Sprint(v)); err != nil {
+				return fmt.Errorf("config file %s: invalid value for setting %q: %v", path, key, err)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/flightctl/fips-validator/internal/report"
+)
+
+// parseDiffArgs parses the arguments of "diff": the mode and the old and new
+// targets, which share the repository for ostree commits. It returns the
+// arguments selecting the new target, which is validated like any target,
+// and the arguments after the mode that select the old one.
+func parseDiffArgs(args []string) ([]string, []string, error) {
+	if len(args) == 0 {
+		return nil, nil, fmt.Errorf("diff: incorrect number of arguments")
+	}
+	want := 3
+	if args[0] == "ostree" {
+		want = 4
+	}
+	if len(args) != want {
+		return nil, nil, fmt.Errorf("diff: incorrect number of arguments")
+	}
+	for _, a := range args[1:] {
+		if strings.HasPrefix(a, "-") {
+			return nil, nil, fmt.Errorf("diff: flags must be given before the subcommand")
+		}
+	}
+	if args[0] == "ostree" {
+		return []string{"ostree", args[1], args[3]}, []string{args[1], args[2]}, nil
+	}
+	return []string{args[0], args[2]}, []string{args[1]}, nil
+}
+
+// printPostureDiff prints the change in FIPS posture, as text or, with
+// --output json, as JSON, and returns the exit code: 1 if the posture
+// regressed, 0 otherwise, whatever the verdicts of the targets themselves.
+func printPostureDiff(d *report.PostureDiff) int {
+	rc := 0
+	if d.Regressions > 0 {
+		rc = 1
+	}
+	if outputFormat == "json" {
+		write := func(w io.Writer) error { return report.WritePostureDiff(w, d, jsonCompact) }
+		var err error
+		if outputFile != "" {
+			err = writeFileAtomic(outputFile, write)
+		} else {
+			err = write(os.Stdout)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: failed to write report: %v\n", err)
+			return 1
+		}
+		return rc
+	}
+
+	if len(d.Changes) == 0 {
+		success("\nFIPS posture unchanged\n")
+		return rc
+	}
+	report.PrintPostureDiff(out, d)
+	fmt.Fprintln(out)
+	if rc != 0 {
+		failure("FIPS posture regressed (regressions: %d, improvements: %d)\n", d.Regressions, d.Improvements)
+	} else {
+		success("FIPS posture didn't regress (improvements: %d)\n", d.Improvements)
+	}
+	return rc
+}
@@ -0,0 +1,174 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/flightctl/fips-validator/internal/report"
+	"github.com/flightctl/fips-validator/internal/validation"
+)
+
+func TestParseDiffArgs(t *testing.T) {
+	tests := []struct {
+		args    []string
+		newArgs []string
+		oldArgs []string
+		wantErr bool
+	}{
+		{args: []string{"image", "app:1", "app:2"}, newArgs: []string{"image", "app:2"}, oldArgs: []string{"app:1"}},
+		{args: []string{"ostree", "/repo", "old", "new"}, newArgs: []string{"ostree", "/repo", "new"}, oldArgs: []string{"/repo", "old"}},
+		{args: nil, wantErr: true},
+		{args: []string{"image", "app:1"}, wantErr: true},
+		{args: []string{"ostree", "/repo", "old"}, wantErr: true},
+		{args: []string{"image", "app:1", "--debug"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		newArgs, oldArgs, err := parseDiffArgs(tt.args)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("parseDiffArgs(%q) succeeded, want error", tt.args)
+			}
+			continue
+		}
+		if err != nil || !slices.Equal(newArgs, tt.newArgs) || !slices.Equal(oldArgs, tt.oldArgs) {
+			t.Errorf("parseDiffArgs(%q) = %q, %q, %v, want %q, %q", tt.args, newArgs, oldArgs, err, tt.newArgs, tt.oldArgs)
+		}
+	}
+}
+
+// postureTarget returns a target with binaries of the given statuses, by
+// path.
+func postureTarget(statuses map[string]validation.Status) *report.Target {
+	t := &report.Target{Mode: "image", Name: "app"}
+	for path, status := range statuses {
+		t.Binaries = append(t.Binaries, &validation.BinaryResult{Path: path, Status: status})
+	}
+	return t
+}
+
+func TestPrintPostureDiff(t *testing.T) {
+	oldOut, oldFormat, oldFile := out, outputFormat, outputFile
+	t.Cleanup(func() { out, outputFormat, outputFile = oldOut, oldFormat, oldFile })
+
+	const (
+		passed  = validation.StatusPassed
+		failed  = validation.StatusFailed
+		skipped = validation.StatusSkipped
+	)
+	old := map[string]validation.Status{
+		"/usr/bin/same":         passed,
+		"/usr/bin/regressed":    passed,
+		"/usr/bin/fixed":        failed,
+		"/usr/bin/removed-fail": failed,
+		"/usr/bin/removed-pass": passed,
+		"/usr/bin/now-skipped":  passed,
+	}
+	improved := map[string]validation.Status{
+		"/usr/bin/same":         passed,
+		"/usr/bin/regressed":    passed,
+		"/usr/bin/fixed":        passed,
+		"/usr/bin/removed-pass": passed,
+		"/usr/bin/now-skipped":  skipped,
+		"/usr/bin/added-pass":   passed,
+	}
+	regressed := map[string]validation.Status{
+		"/usr/bin/same":        passed,
+		"/usr/bin/regressed":   failed,
+		"/usr/bin/fixed":       passed,
+		"/usr/bin/now-skipped": passed,
+		"/usr/bin/added-fail":  failed,
+	}
+
+	tests := []struct {
+		name         string
+		new          map[string]validation.Status
+		changes      map[string]report.DriftKind
+		regressions  int
+		improvements int
+		rc           int
+	}{
+		{
+			name: "unchanged",
+			new:  old,
+			rc:   0,
+		},
+		{
+			name: "improved",
+			new:  improved,
+			changes: map[string]report.DriftKind{
+				"/usr/bin/fixed":        report.DriftVerdict,
+				"/usr/bin/removed-fail": report.DriftRemoved,
+				"/usr/bin/now-skipped":  report.DriftVerdict,
+				"/usr/bin/added-pass":   report.DriftAdded,
+			},
+			improvements: 2,
+			rc:           0,
+		},
+		{
+			name: "regressed",
+			new:  regressed,
+			changes: map[string]report.DriftKind{
+				"/usr/bin/regressed":    report.DriftVerdict,
+				"/usr/bin/fixed":        report.DriftVerdict,
+				"/usr/bin/removed-fail": report.DriftRemoved,
+				"/usr/bin/removed-pass": report.DriftRemoved,
+				"/usr/bin/added-fail":   report.DriftAdded,
+			},
+			regressions:  2,
+			improvements: 2,
+			rc:           1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := report.ComparePosture(postureTarget(old), postureTarget(tt.new))
+			changes := map[string]report.DriftKind{}
+			for _, c := range d.Changes {
+				changes[c.Path] = c.Kind
+			}
+			if len(changes) != len(tt.changes) {
+				t.Errorf("changes = %+v, want %v", d.Changes, tt.changes)
+			}
+			for path, kind := range tt.changes {
+				if changes[path] != kind {
+					t.Errorf("change of %s = %q, want %q", path, changes[path], kind)
+				}
+			}
+			if d.Regressions != tt.regressions || d.Improvements != tt.improvements {
+				t.Errorf("regressions, improvements = %d, %d, want %d, %d", d.Regressions, d.Improvements, tt.regressions, tt.improvements)
+			}
+
+			var buf bytes.Buffer
+			out, outputFormat, outputFile = &buf, "text", ""
+			if rc := printPostureDiff(d); rc != tt.rc {
+				t.Errorf("printPostureDiff() = %d, want %d", rc, tt.rc)
+			}
+			for path := range tt.changes {
+				if !strings.Contains(buf.String(), path) {
+					t.Errorf("printPostureDiff() printed %q, want %s", buf.String(), path)
+				}
+			}
+
+			outputFormat, outputFile = "json", filepath.Join(t.TempDir(), "diff.json")
+			if rc := printPostureDiff(d); rc != tt.rc {
+				t.Errorf("printPostureDiff() with --output json = %d, want %d", rc, tt.rc)
+			}
+			data, err := os.ReadFile(outputFile)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var written report.PostureDiff
+			if err := json.Unmarshal(data, &written); err != nil {
+				t.Fatalf("failed to parse the JSON diff: %v", err)
+			}
+			if len(written.Changes) != len(d.Changes) || written.Regressions != d.Regressions || written.Improvements != d.Improvements {
+				t.Errorf("JSON diff = %+v, want %+v", written, d)
+			}
+		})
+	}
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/flightctl/fips-validator/internal/validation"
+)
+
+// byteSize is a flag value holding a number of bytes, which can be given with
+// a binary unit suffix, e.g. "512M" or "10GiB".
+type byteSize int64
+
+var byteSizeUnits = []struct {
+	suffix string
+	factor int64
+}{
+	{"T", 1 << 40},
+	{"G", 1 << 30},
+	{"M", 1 << 20},
+	{"K", 1 << 10},
+}
+
+func (b *byteSize) String() string {
+	for _, u := range byteSizeUnits {
+		if *b != 0 && int64(*b)%u.factor == 0 {
+			return fmt.Sprintf("%d%s", int64(*b)/u.factor, u.suffix)
+		}
+	}
+	return strconv.FormatInt(int64(*b), 10)
+}
+
+func (b *byteSize) Set(s string) error {
+	num := strings.TrimSuffix(strings.TrimSuffix(strings.ToUpper(strings.TrimSpace(s)), "B"), "I")
+	factor := int64(1)
+	for _, u := range byteSizeUnits {
+		if strings.HasSuffix(num, u.suffix) {
+			num, factor = strings.TrimSuffix(num, u.suffix), u.factor
+			break
+		}
+	}
+	n, err := strconv.ParseInt(num, 10, 64)
+	if err != nil || n < 0 {
+		return fmt.Errorf("invalid size %q", s)
+	}
+	*b = byteSize(n * factor)
+	return nil
+}
+
+// archList is the value of a repeatable flag listing architectures, given as
+// "linux/<arch>" or just "<arch>" with Go's architecture names, e.g.
+// "linux/amd64". Several architectures can be separated by commas.
+type archList []string
+
+func (a *archList) String() string {
+	return strings.Join(*a, ",")
+}
+
+func (a *archList) Set(s string) error {
+	for _, platform := range strings.Split(s, ",") {
+		platform = strings.TrimSpace(platform)
+		arch := platform
+		if os, rest, ok := strings.Cut(platform, "/"); ok {
+			if os != "linux" {
+				return fmt.Errorf("unsupported platform %q (only linux binaries are validated)", platform)
+			}
+			arch = rest
+		}
+		if arch == "" || strings.Contains(arch, "/") {
+			return fmt.Errorf("invalid platform %q", platform)
+		}
+		*a = append(*a, arch)
+	}
+	return nil
+}
+
+// policyValue is the value of the --policy flag. It holds either the path of a
+// policy file or, if set from a mapping in the config file, an inline policy.
+type policyValue struct {
+	path   string
+	inline []byte
+}
+
+func (p *policyValue) String() string {
+	return p.path
+}
+
+func (p *policyValue) Set(path string) error {
+	p.path, p.inline = path, nil
+	return nil
+}
+
+func (p *policyValue) SetMapping(data []byte) error {
+	p.path, p.inline = "", data
+	return nil
+}
+
+// load returns the configured policy, or the default policy if none is set.
+func (p *policyValue) load() (*validation.Policy, error) {
+	switch {
+	case p.path != "":
+		return validation.LoadPolicy(p.path)
+	case p.inline != nil:
+		policy, err := validation.ParsePolicy(p.inline)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse policy in config file: %v", err)
+		}
+		return policy, nil
+	}
+	return validation.DefaultPolicy(), nil
+}
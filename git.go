@@ -0,0 +1,47 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/flightctl/fips-validator/internal/executor"
+)
+
+// changedFiles returns the files in the directory at dir that changed since
+// the git ref, relative to dir: the tracked files that differ from the ref,
+// whether the changes are committed or not, and the untracked files that
+// aren't ignored. Deleted files are included, but aren't found by a scan.
+// git is pointed at dir with -C rather than run in it, like all commands, so
+// that dir may be read-only.
+func changedFiles(dir, ref string) (map[string]bool, error) {
+	ctx := context.TODO()
+	_, stderr, rc, err := executor.Execute(ctx, "", "git", "-C", dir, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return nil, commandError("failed to run git", err)
+	}
+	if rc != 0 {
+		return nil, fmt.Errorf("--since-git: %s is not in a git repository: %s", dir, strings.TrimSpace(string(stderr)))
+	}
+
+	files := map[string]bool{}
+	for _, args := range [][]string{
+		{"diff", "--name-only", "--relative", "-z", ref, "--"},
+		{"ls-files", "--others", "--exclude-standard", "-z"},
+	} {
+		stdout, stderr, rc, err := executor.Execute(ctx, "", "git", append([]string{"-C", dir}, args...)...)
+		if err != nil {
+			return nil, commandError("failed to run git", err)
+		}
+		if rc != 0 {
+			return nil, fmt.Errorf("--since-git: git %s failed, exit code %d: %s", args[0], rc, strings.TrimSpace(string(stderr)))
+		}
+		for _, name := range bytes.Split(stdout, []byte{0}) {
+			if len(name) > 0 {
+				files[string(name)] = true
+			}
+		}
+	}
+	return files, nil
+}
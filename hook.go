@@ -0,0 +1,40 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/flightctl/fips-validator/internal/executor"
+	"github.com/flightctl/fips-validator/internal/report"
+)
+
+var onComplete string
+
+// runCompletionHook runs the --on-complete command, if any, through the shell
+// with the JSON report, in the schema version of --format-version, on its
+// stdin. The command's output is printed to
+// stderr so that it doesn't mix with a report printed to stdout. Failures are
+// printed, but don't change the exit code.
+func runCompletionHook(r *report.Report) {
+	if onComplete == "" {
+		return
+	}
+	var buf bytes.Buffer
+	if err := report.WriteJSONVersion(&buf, r, formatVersion, true); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: --on-complete: failed to write report: %v\n", err)
+		return
+	}
+	debug("running completion hook %q", onComplete)
+	stderr, rc, err := executor.ExecuteWithIO(context.TODO(), "", &buf, os.Stderr, "sh", "-c", onComplete)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: --on-complete: failed to run command: %v\n", err)
+		return
+	}
+	if rc != 0 {
+		fmt.Fprintf(os.Stderr, "Error: --on-complete: command failed, exit code %d: %s\n", rc, string(stderr))
+		return
+	}
+	os.Stderr.Write(stderr)
+}
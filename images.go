@@ -0,0 +1,260 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"sort"
+	"strings"
+
+	"golang.org/x/sync/errgroup"
+
+	"github.com/flightctl/fips-validator/internal/executor"
+	"github.com/flightctl/fips-validator/internal/report"
+)
+
+// imageBatch selects the images validated by "image --all" or
+// "image --from-file".
+type imageBatch struct {
+	// filter, if not empty, is a path.Match pattern that the repository or
+	// full name of an image must match, e.g. "quay.io/myorg/*".
+	filter string
+	// file, if not empty, is a file listing image references, one per line,
+	// which are validated instead of the local images.
+	file string
+	// checkpoint, if not empty, is the checkpoint file that records the
+	// images validated so far, which are skipped if the batch is restarted.
+	checkpoint string
+}
+
+// parseImageFlags parses the flags given to image mode instead of an image
+// reference.
+func parseImageFlags(args []string) (*imageBatch, error) {
+	fs := flag.NewFlagSet("image", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	all := fs.Bool("all", false, "Validate all local images")
+	b := &imageBatch{}
+	fs.StringVar(&b.filter, "filter", "", "Only validate images whose repository matches the pattern")
+	fs.StringVar(&b.file, "from-file", "", "Validate the images listed in a file")
+	fs.StringVar(&b.checkpoint, "checkpoint", "", "Record the validated images in a checkpoint file and skip them when restarted")
+	if err := fs.Parse(args); err != nil {
+		return nil, fmt.Errorf("image: %v", err)
+	}
+	if *all && b.file != "" {
+		return nil, fmt.Errorf("image: --all and --from-file are mutually exclusive")
+	}
+	if !*all && (b.file == "" || b.filter != "") {
+		return nil, fmt.Errorf("image: --filter requires --all")
+	}
+	if fs.NArg() > 0 {
+		return nil, fmt.Errorf("image: no image reference may be given with --all or --from-file")
+	}
+	if _, err := path.Match(b.filter, ""); err != nil {
+		return nil, fmt.Errorf("image: invalid --filter pattern %q: %v", b.filter, err)
+	}
+	return b, nil
+}
+
+// readList returns the image references or binary paths listed in the file
+// at path, or on stdin if path is "-", one per line, in the order they are
+// listed. Blank lines and lines starting with "#" are ignored. what names the
+// entries in errors.
+func readList(path, what string) ([]string, error) {
+	var data []byte
+	var err error
+	if path == "-" {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read list of %s: %v", what, err)
+	}
+	var entries []string
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		entries = append(entries, line)
+	}
+	return entries, nil
+}
+
+// localImage is an entry of the output of "podman images --format json".
+type localImage struct {
+	ID       string   `json:"Id"`
+	Names    []string `json:"Names"`
+	Dangling bool     `json:"Dangling"`
+}
+
+// listLocalImages returns a reference for each local image selected by b,
+// ordered by name. Dangling images, which have no name, are left out, as are
+// intermediate images, which podman doesn't list by default.
+func listLocalImages(b *imageBatch) ([]string, error) {
+	stdout, stderr, rc, err := executor.Execute(context.TODO(), "", "podman", "images", "--format", "json")
+	if err != nil {
+		return nil, commandError("failed to list images", err)
+	}
+	if rc != 0 {
+		return nil, fmt.Errorf("failed to list images, exit code %d: %s", rc, string(stderr))
+	}
+	var images []localImage
+	if err := json.Unmarshal(stdout, &images); err != nil {
+		return nil, fmt.Errorf("failed to parse list of images: %v", err)
+	}
+
+	var refs []string
+	for _, img := range images {
+		if img.Dangling {
+			debug("skipping dangling image %s", img.ID)
+			continue
+		}
+		for _, name := range img.Names {
+			if b.matches(name) {
+				refs = append(refs, name)
+				break
+			}
+		}
+	}
+	sort.Strings(refs)
+	return refs, nil
+}
+
+// matches returns whether the image name, e.g. "quay.io/myorg/app:v1", is
+// selected by the filter, either by its repository or by its full name.
+func (b *imageBatch) matches(name string) bool {
+	if b.filter == "" {
+		return true
+	}
+	repo := name
+	if i := strings.LastIndex(repo, "@"); i >= 0 {
+		repo = repo[:i]
+	}
+	if i := strings.LastIndex(repo, ":"); i > strings.LastIndex(repo, "/") {
+		repo = repo[:i]
+	}
+	for _, s := range []string{repo, name} {
+		if ok, _ := path.Match(b.filter, s); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// validateAllImages validates each image selected by b. An image that can't be
+// validated, e.g. because it fails to mount, is reported as an invalid target
+// and the batch continues with the next image.
+//
+// With --checkpoint, each validated image is recorded in the checkpoint file,
+// and the images recorded by a previous, interrupted run aren't validated
+// again, but their recorded results are reported along with the others.
+// Images that couldn't be validated aren't recorded, so they are retried.
+//
+// With --target-jobs, up to that many images are validated concurrently. The
+// output of each image is held back until it's done and then printed in the
+// order of the images, so that the output and the targets of the report are
+// in the same order regardless of the number of jobs.
+func validateAllImages(b *imageBatch) ([]*report.Target, error) {
+	var refs []string
+	var err error
+	if b.file != "" {
+		if refs, err = readList(b.file, "images"); err != nil {
+			return nil, err
+		}
+		info("Validating %d images listed in %s:\n", len(refs), b.file)
+	} else {
+		if refs, err = listLocalImages(b); err != nil {
+			return nil, err
+		}
+		info("Validating %d local images:\n", len(refs))
+	}
+
+	targets := make([]*report.Target, len(refs))
+	errs := make([]error, len(refs))
+	// skipped is set for the images recorded in the checkpoint.
+	skipped := make([]bool, len(refs))
+	var cp *checkpoint
+	if b.checkpoint != "" {
+		recorded, err := readCheckpoint(b.checkpoint)
+		if err != nil {
+			return nil, err
+		}
+		n := 0
+		for i, ref := range refs {
+			if t, ok := recorded[ref]; ok {
+				targets[i], skipped[i] = t, true
+				n++
+			}
+		}
+		if n > 0 {
+			info("• %d of %d images already validated according to checkpoint %s, skipping them\n", n, len(refs), b.checkpoint)
+		}
+		if cp, err = openCheckpoint(b.checkpoint); err != nil {
+			return nil, err
+		}
+		defer cp.Close()
+	}
+	// validate validates the i-th image and records it in the checkpoint.
+	validate := func(w io.Writer, i int) {
+		targets[i], errs[i] = validateOciImage(w, refs[i])
+		if errs[i] == nil && cp != nil {
+			if err := cp.record(targets[i]); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: image %s: %v\n", refs[i], err)
+			}
+		}
+	}
+	finish := func(i int) {
+		if errs[i] != nil {
+			fmt.Fprintf(os.Stderr, "Error: image %s: %v\n", refs[i], errs[i])
+			targets[i] = &report.Target{Mode: "image", Name: refs[i], Errors: []string{errs[i].Error()}}
+		}
+	}
+	if targetJobs == 1 {
+		for i := range refs {
+			if skipped[i] {
+				continue
+			}
+			fmt.Fprintln(out)
+			validate(out, i)
+			finish(i)
+		}
+		return targets, nil
+	}
+
+	outputs := make([]bytes.Buffer, len(refs))
+	done := make([]chan struct{}, len(refs))
+	for i := range done {
+		done[i] = make(chan struct{})
+	}
+	go func() {
+		g := &errgroup.Group{}
+		g.SetLimit(targetJobs)
+		for i := range refs {
+			if skipped[i] {
+				close(done[i])
+				continue
+			}
+			g.Go(func() error {
+				defer close(done[i])
+				validate(&outputs[i], i)
+				return nil
+			})
+		}
+	}()
+	for i := range refs {
+		<-done[i]
+		if skipped[i] {
+			continue
+		}
+		fmt.Fprintln(out)
+		_, _ = outputs[i].WriteTo(out)
+		finish(i)
+	}
+	return targets, nil
+}
@@ -0,0 +1,201 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/fatih/color"
+	"golang.org/x/term"
+
+	"github.com/flightctl/fips-validator/internal/report"
+	"github.com/flightctl/fips-validator/internal/validation"
+)
+
+// interactiveTerminal returns whether --interactive can take effect: the
+// results are printed as text and both stdin and stdout are terminals.
+func interactiveTerminal() bool {
+	return outputFormat == "text" && term.IsTerminal(int(os.Stdin.Fd())) && term.IsTerminal(int(os.Stdout.Fd()))
+}
+
+// browserItem is a failed binary or OpenSSL installation in the interactive
+// summary, which can be expanded to show its findings.
+type browserItem struct {
+	title    string
+	findings []validation.Finding
+	expanded bool
+}
+
+// browser is the interactive summary of a report. It lists the failures
+// below a tally of the binaries by status, one line each, with the item at
+// cursor highlighted. offset is the first line shown if the list doesn't fit
+// into the terminal.
+type browser struct {
+	tally  string
+	items  []*browserItem
+	cursor int
+	offset int
+}
+
+// browserHelp lists the keys the interactive summary responds to.
+const browserHelp = "↑/↓ move · enter expand · a expand all · q quit"
+
+func newBrowser(r *report.Report) *browser {
+	b := &browser{tally: fmt.Sprintf("%d failed, %d passed, %d skipped", r.Summary.Failed, r.Summary.Passed, r.Summary.Skipped)}
+	for _, t := range r.Targets {
+		prefix := ""
+		if len(r.Targets) > 1 {
+			prefix = t.Name + ": "
+		}
+		if t.OpenSSL != nil && !t.OpenSSL.Valid {
+			b.items = append(b.items, &browserItem{title: prefix + "OpenSSL installation", findings: t.OpenSSL.Findings})
+		}
+		var failed []*validation.BinaryResult
+		for _, r := range t.Binaries {
+			if r.Status == validation.StatusFailed {
+				failed = append(failed, r)
+			}
+		}
+		sort.SliceStable(failed, func(i, j int) bool { return failed[i].Path < failed[j].Path })
+		for _, r := range failed {
+			b.items = append(b.items, &browserItem{title: prefix + r.Path, findings: r.Findings})
+		}
+	}
+	return b
+}
+
+// browseFailures shows the failures of r in an interactive summary on the
+// terminal's alternate screen until the user quits it.
+func browseFailures(r *report.Report) error {
+	b := newBrowser(r)
+	if len(b.items) == 0 {
+		return nil
+	}
+	state, err := term.MakeRaw(int(os.Stdin.Fd()))
+	if err != nil {
+		return fmt.Errorf("failed to set up terminal: %v", err)
+	}
+	defer term.Restore(int(os.Stdin.Fd()), state)
+	// Switch to the alternate screen and hide the cursor, so that the
+	// summary leaves the scan's output as it was.
+	fmt.Fprint(os.Stdout, "\x1b[?1049h\x1b[?25l")
+	defer fmt.Fprint(os.Stdout, "\x1b[?25h\x1b[?1049l")
+
+	buf := make([]byte, 16)
+	for {
+		width, height, err := term.GetSize(int(os.Stdout.Fd()))
+		if err != nil || width == 0 || height == 0 {
+			width, height = 80, 24
+		}
+		b.render(os.Stdout, width, height)
+		n, err := os.Stdin.Read(buf)
+		if err != nil {
+			return fmt.Errorf("failed to read from terminal: %v", err)
+		}
+		switch string(buf[:n]) {
+		case "q", "\x1b", "\x03":
+			return nil
+		case "k", "\x1b[A", "\x1bOA":
+			b.move(-1)
+		case "j", "\x1b[B", "\x1bOB":
+			b.move(1)
+		case "\x1b[5~":
+			b.move(-(height - 3))
+		case "\x1b[6~":
+			b.move(height - 3)
+		case "g", "\x1b[H", "\x1bOH":
+			b.move(-len(b.items))
+		case "G", "\x1b[F", "\x1bOF":
+			b.move(len(b.items))
+		case "\r", " ":
+			b.items[b.cursor].expanded = !b.items[b.cursor].expanded
+		case "a":
+			b.expandAll()
+		}
+	}
+}
+
+func (b *browser) move(n int) {
+	b.cursor = min(max(b.cursor+n, 0), len(b.items)-1)
+}
+
+// expandAll expands all items or, if all of them are expanded already,
+// collapses them.
+func (b *browser) expandAll() {
+	expand := false
+	for _, it := range b.items {
+		if !it.expanded {
+			expand = true
+		}
+	}
+	for _, it := range b.items {
+		it.expanded = expand
+	}
+}
+
+// lines returns the lines of the list of items, truncated to width, and the
+// index of the line of the item at the cursor.
+func (b *browser) lines(width int) ([]string, int) {
+	var lines []string
+	cursorLine := 0
+	for i, it := range b.items {
+		mark := "▸"
+		if it.expanded {
+			mark = "▾"
+		}
+		title := fmt.Sprintf("%s %s (%d findings)", mark, it.title, len(it.findings))
+		if len(it.findings) == 1 {
+			title = fmt.Sprintf("%s %s (1 finding)", mark, it.title)
+		}
+		title = truncate(title, width)
+		if i == b.cursor {
+			cursorLine = len(lines)
+			title = color.New(color.ReverseVideo).Sprint(title)
+		}
+		lines = append(lines, title)
+		if !it.expanded {
+			continue
+		}
+		for _, f := range it.findings {
+			lines = append(lines, "  "+report.FindingMark(f)+" "+truncate(f.Message, width-4))
+			if f.Hint != "" {
+				lines = append(lines, "    "+truncate("hint: "+f.Hint, width-4))
+			}
+		}
+	}
+	return lines, cursorLine
+}
+
+// render draws the summary on a terminal of the given size, scrolling the
+// list so that the item at the cursor is visible.
+func (b *browser) render(w io.Writer, width, height int) {
+	lines, cursorLine := b.lines(width)
+	view := max(height-3, 1)
+	if cursorLine < b.offset {
+		b.offset = cursorLine
+	} else if cursorLine >= b.offset+view {
+		b.offset = cursorLine - view + 1
+	}
+	b.offset = min(b.offset, max(len(lines)-view, 0))
+
+	var sb strings.Builder
+	sb.WriteString("\x1b[H\x1b[2J")
+	sb.WriteString(color.New(color.Bold).Sprint(truncate("Binaries: "+b.tally, width)) + "\r\n")
+	sb.WriteString(color.New(color.Faint).Sprint(truncate(browserHelp, width)) + "\r\n\r\n")
+	for _, l := range lines[b.offset:min(b.offset+view, len(lines))] {
+		sb.WriteString(l + "\r\n")
+	}
+	fmt.Fprint(w, strings.TrimSuffix(sb.String(), "\r\n"))
+}
+
+// truncate shortens s to at most n runes, so that lines don't wrap.
+func truncate(s string, n int) string {
+	if n < 1 || utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	runes := []rune(s)
+	return string(runes[:n-1]) + "…"
+}
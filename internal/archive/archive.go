@@ -0,0 +1,227 @@
+package archive
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+type format int
+
+const (
+	formatNone format = iota
+	formatTar
+	formatTarGz
+	formatZip
+)
+
+func detectFormat(name string) format {
+	name = strings.ToLower(name)
+	switch {
+	case strings.HasSuffix(name, ".tar"):
+		return formatTar
+	case strings.HasSuffix(name, ".tar.gz"), strings.HasSuffix(name, ".tgz"):
+		return formatTarGz
+	case IsZip(name), IsLanguagePackage(name):
+		return formatZip
+	}
+	return formatNone
+}
+
+// languagePackageExts are the extensions of language packages that are zip
+// archives: Python wheels and eggs, and Java archives.
+var languagePackageExts = []string{".whl", ".egg", ".jar"}
+
+// IsArchive returns whether name has the extension of an archive format that
+// Extract supports. Language packages, which Extract supports, too, aren't
+// considered archives, see IsLanguagePackage.
+func IsArchive(name string) bool {
+	return detectFormat(name) != formatNone && !IsLanguagePackage(name)
+}
+
+// IsZip returns whether name has the extension of a zip archive.
+func IsZip(name string) bool {
+	return strings.HasSuffix(strings.ToLower(name), ".zip")
+}
+
+// IsLanguagePackage returns whether name has the extension of a language
+// package, e.g. a Python wheel, which may bundle compiled extension modules.
+func IsLanguagePackage(name string) bool {
+	name = strings.ToLower(name)
+	for _, ext := range languagePackageExts {
+		if strings.HasSuffix(name, ext) {
+			return true
+		}
+	}
+	return false
+}
+
+// Extract extracts the archive at path into destDir, accounting all written
+// bytes against limit. Only directories and regular files are extracted;
+// links, devices, and entries that would be written outside of destDir are
+// skipped.
+func Extract(path, destDir string, limit *Limit) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return extract(path, f, destDir, limit)
+}
+
+// ExtractFS is like Extract, but reads the archive with the given name from
+// fsys.
+func ExtractFS(fsys fs.FS, name, destDir string, limit *Limit) error {
+	f, err := fsys.Open(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return extract(name, f, destDir, limit)
+}
+
+// ExtractZip extracts the zip archive at path into destDir like Extract,
+// whatever its name. It returns whether the archive records the Unix
+// permission bits of its files: archives created on other systems, e.g. by
+// Windows tools, don't, so that none of the extracted files is executable.
+func ExtractZip(path, destDir string, limit *Limit) (bool, error) {
+	zr, err := zip.OpenReader(path)
+	if err != nil {
+		return false, err
+	}
+	defer zr.Close()
+	if err := extractZip(&zr.Reader, destDir, limit); err != nil {
+		return false, err
+	}
+	return hasUnixModes(&zr.Reader), nil
+}
+
+// hasUnixModes returns whether any regular file in the zip archive has Unix
+// permission bits in its external attributes. Go's zip package, like Info-ZIP,
+// only reads them from entries created on Unix or macOS.
+func hasUnixModes(zr *zip.Reader) bool {
+	const creatorUnix, creatorMacOSX = 3, 19
+	for _, zf := range zr.File {
+		creator := zf.CreatorVersion >> 8
+		if (creator == creatorUnix || creator == creatorMacOSX) && zf.ExternalAttrs>>16 != 0 && zf.Mode().IsRegular() {
+			return true
+		}
+	}
+	return false
+}
+
+func extract(name string, f fs.File, destDir string, limit *Limit) error {
+	switch detectFormat(name) {
+	case formatTar:
+		return extractTar(f, destDir, limit)
+	case formatTarGz:
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			return err
+		}
+		defer gz.Close()
+		return extractTar(gz, destDir, limit)
+	case formatZip:
+		ra, ok := f.(io.ReaderAt)
+		if !ok {
+			return fmt.Errorf("%s: zip archives require random access", name)
+		}
+		fi, err := f.Stat()
+		if err != nil {
+			return err
+		}
+		zr, err := zip.NewReader(ra, fi.Size())
+		if err != nil {
+			return err
+		}
+		return extractZip(zr, destDir, limit)
+	}
+	return fmt.Errorf("unsupported archive format: %s", name)
+}
+
+func extractTar(r io.Reader, destDir string, limit *Limit) error {
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		target, ok := entryPath(destDir, hdr.Name)
+		if !ok {
+			continue
+		}
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, 0o755); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if err := writeFile(target, tr, hdr.FileInfo().Mode(), limit); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func extractZip(zr *zip.Reader, destDir string, limit *Limit) error {
+	for _, zf := range zr.File {
+		target, ok := entryPath(destDir, zf.Name)
+		if !ok {
+			continue
+		}
+		mode := zf.Mode()
+		switch {
+		case mode.IsDir():
+			if err := os.MkdirAll(target, 0o755); err != nil {
+				return err
+			}
+		case mode.IsRegular():
+			rc, err := zf.Open()
+			if err != nil {
+				return err
+			}
+			err = writeFile(target, rc, mode, limit)
+			rc.Close()
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// entryPath returns the path an archive entry is extracted to, or false if the
+// entry would end up outside of destDir.
+func entryPath(destDir, name string) (string, bool) {
+	name = strings.TrimPrefix(filepath.Clean("/"+name), "/")
+	if name == "" || !filepath.IsLocal(name) {
+		return "", false
+	}
+	return filepath.Join(destDir, name), true
+}
+
+func writeFile(target string, r io.Reader, mode os.FileMode, limit *Limit) error {
+	if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, mode.Perm()|0o600)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(limit.Writer(f), r); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
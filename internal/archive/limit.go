@@ -0,0 +1,62 @@
+package archive
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sync/atomic"
+)
+
+// ErrSizeLimitExceeded is returned when an extraction would write more bytes
+// than allowed by its Limit.
+var ErrSizeLimitExceeded = errors.New("extraction size limit exceeded")
+
+// Limit caps the cumulative number of bytes written by one or more
+// extractions. A nil *Limit imposes no limit. It is safe for concurrent use.
+type Limit struct {
+	max     int64
+	written atomic.Int64
+}
+
+// NewLimit returns a Limit allowing up to max bytes to be written. A max of
+// zero or less disables the limit.
+func NewLimit(max int64) *Limit {
+	if max <= 0 {
+		return nil
+	}
+	return &Limit{max: max}
+}
+
+// Writer returns a writer that accounts all bytes written to w against the
+// limit and fails with ErrSizeLimitExceeded once it is exceeded.
+func (l *Limit) Writer(w io.Writer) io.Writer {
+	if l == nil {
+		return w
+	}
+	return &limitedWriter{w: w, l: l}
+}
+
+// Exceeded returns whether any writer has exceeded the limit.
+func (l *Limit) Exceeded() bool {
+	return l != nil && l.written.Load() > l.max
+}
+
+// Err returns a descriptive error if the limit has been exceeded.
+func (l *Limit) Err() error {
+	if !l.Exceeded() {
+		return nil
+	}
+	return fmt.Errorf("%w: more than %d bytes extracted", ErrSizeLimitExceeded, l.max)
+}
+
+type limitedWriter struct {
+	w io.Writer
+	l *Limit
+}
+
+func (lw *limitedWriter) Write(p []byte) (int, error) {
+	if lw.l.written.Add(int64(len(p))) > lw.l.max {
+		return 0, lw.l.Err()
+	}
+	return lw.w.Write(p)
+}
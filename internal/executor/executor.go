@@ -4,22 +4,147 @@ import (
 	"bytes"
 	"context"
 	"errors"
+	"fmt"
+	"io"
 	"os/exec"
+	"slices"
+	"strings"
+	"time"
 )
 
+// NotInstalledError is returned if a command can't be run because it isn't
+// installed, i.e. can't be found in the PATH. It wraps exec.ErrNotFound.
+type NotInstalledError struct {
+	Tool string
+}
+
+func (e *NotInstalledError) Error() string {
+	return fmt.Sprintf("tool not installed: %s", e.Tool)
+}
+
+func (e *NotInstalledError) Unwrap() error {
+	return exec.ErrNotFound
+}
+
+// Command is a command run by Execute or ExecuteWithIO, as passed to Trace.
+type Command struct {
+	// Args are the command and its arguments, with credentials redacted.
+	Args []string
+	// Dir is the working directory, or "" for the current one.
+	Dir string
+	// ExitCode is -1 if the command couldn't be run or was killed.
+	ExitCode int
+	Duration time.Duration
+}
+
+// String returns the command line, quoted so that it can be pasted into a
+// shell to run the command by hand.
+func (c Command) String() string {
+	quoted := make([]string, len(c.Args))
+	for i, arg := range c.Args {
+		quoted[i] = shellQuote(arg)
+	}
+	return strings.Join(quoted, " ")
+}
+
+// Trace, if set, is called after every command has run, e.g. to log the
+// commands for debugging. It may be called concurrently.
+var Trace func(Command)
+
+// sensitiveFlags are flags of podman and skopeo whose values are credentials.
+var sensitiveFlags = []string{"--creds", "--password", "--passphrase", "--registry-token", "--src-creds", "--dest-creds"}
+
+// Redacted replaces the credentials in redacted command lines.
+const Redacted = "REDACTED"
+
+// Redact returns a copy of the command line args with the values of flags
+// that take credentials replaced by Redacted, whether given as "--flag value"
+// or "--flag=value". Paths to files with credentials, e.g. of --authfile,
+// are kept, as the files' contents never appear in the command line.
+func Redact(args []string) []string {
+	redacted := slices.Clone(args)
+	for i := 0; i < len(redacted); i++ {
+		flag, _, hasValue := strings.Cut(redacted[i], "=")
+		if !slices.Contains(sensitiveFlags, flag) {
+			continue
+		}
+		if hasValue {
+			redacted[i] = flag + "=" + Redacted
+		} else if i+1 < len(redacted) {
+			redacted[i+1] = Redacted
+			i++
+		}
+	}
+	return redacted
+}
+
+// shellQuote quotes s for a POSIX shell, unless it only consists of
+// characters that don't need quoting.
+func shellQuote(s string) string {
+	if s != "" && strings.IndexFunc(s, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || strings.ContainsRune("-_./:=@%+,", r))
+	}) < 0 {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// Execute runs command and returns its stdout, stderr, and exit code. The
+// command runs in workingDir or, if workingDir is empty, in the current
+// working directory of the process. Callers must not use a validation target
+// as working directory, as targets may be mounted read-only and commands
+// tend to assume a writable working directory.
 func Execute(ctx context.Context, workingDir string, command string, args ...string) (stdout []byte, stderr []byte, rc int, err error) {
+	var stdoutBytes bytes.Buffer
+	stderr, rc, err = ExecuteWithIO(ctx, workingDir, nil, &stdoutBytes, command, args...)
+	return stdoutBytes.Bytes(), stderr, rc, err
+}
+
+// ExecuteWithIO runs a command like Execute, but reads the command's stdin
+// from stdin and streams its stdout to stdout instead of buffering it. Either
+// may be nil. If the command isn't installed, a *NotInstalledError is
+// returned.
+func ExecuteWithIO(ctx context.Context, workingDir string, stdin io.Reader, stdout io.Writer, command string, args ...string) (stderr []byte, rc int, err error) {
+	start := time.Now()
+	stderr, rc, err = run(ctx, workingDir, stdin, stdout, command, args...)
+	if Trace != nil {
+		Trace(Command{
+			Args:     Redact(append([]string{command}, args...)),
+			Dir:      workingDir,
+			ExitCode: rc,
+			Duration: time.Since(start),
+		})
+	}
+	return stderr, rc, err
+}
+
+func run(ctx context.Context, workingDir string, stdin io.Reader, stdout io.Writer, command string, args ...string) (stderr []byte, rc int, err error) {
 	cmd := exec.CommandContext(ctx, command, args...)
 	cmd.Dir = workingDir
+	if stdin != nil {
+		cmd.Stdin = stdin
+	}
+	if stdout != nil {
+		cmd.Stdout = stdout
+	}
 
-	var stdoutBytes, stderrBytes bytes.Buffer
-	cmd.Stdout = &stdoutBytes
+	var stderrBytes bytes.Buffer
 	cmd.Stderr = &stderrBytes
 	if err := cmd.Run(); err != nil {
 		var exitErr *exec.ExitError
 		if errors.As(err, &exitErr) {
-			return stdoutBytes.Bytes(), stderrBytes.Bytes(), exitErr.ExitCode(), nil
+			return stderrBytes.Bytes(), exitErr.ExitCode(), nil
+		}
+		if errors.Is(err, exec.ErrNotFound) {
+			return stderrBytes.Bytes(), -1, &NotInstalledError{Tool: command}
 		}
-		return stdoutBytes.Bytes(), stderrBytes.Bytes(), -1, err
+		return stderrBytes.Bytes(), -1, err
 	}
-	return stdoutBytes.Bytes(), stderrBytes.Bytes(), 0, nil
+	return stderrBytes.Bytes(), 0, nil
+}
+
+// Available returns whether command can be found in the PATH.
+func Available(command string) bool {
+	_, err := exec.LookPath(command)
+	return err == nil
 }
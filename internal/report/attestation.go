@@ -0,0 +1,50 @@
+package report
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+)
+
+// StatementType and PredicateType identify in-toto statements whose predicate
+// is a fips-validator report.
+const (
+	StatementType = "https://in-toto.io/Statement/v1"
+	PredicateType = "https://github.com/flightctl/fips-validator/fips-validation/v1"
+)
+
+// Subject is an artifact an attestation is about, identified by its digests,
+// e.g. {"sha256": "<hex>"}.
+type Subject struct {
+	Name   string            `json:"name"`
+	Digest map[string]string `json:"digest"`
+}
+
+// Statement is an in-toto statement attesting the validation result of its
+// subjects. It is meant to be signed out of band, e.g. with "cosign attest".
+type Statement struct {
+	Type          string    `json:"_type"`
+	Subject       []Subject `json:"subject"`
+	PredicateType string    `json:"predicateType"`
+	Predicate     *Report   `json:"predicate"`
+}
+
+// WriteAttestation writes the report as an in-toto statement to w, either
+// pretty-printed or on a single line if compact is set. The subjects are
+// collected from the targets of the report, which must have at least one.
+func WriteAttestation(w io.Writer, r *Report, compact bool) error {
+	st := &Statement{Type: StatementType, Subject: []Subject{}, PredicateType: PredicateType, Predicate: r}
+	for _, t := range r.Targets {
+		normalize(t)
+		st.Subject = append(st.Subject, t.Subjects...)
+	}
+	if len(st.Subject) == 0 {
+		return errors.New("no subjects to attest")
+	}
+
+	enc := json.NewEncoder(w)
+	if !compact {
+		enc.SetIndent("", "  ")
+	}
+	return enc.Encode(st)
+}
@@ -0,0 +1,160 @@
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/fatih/color"
+
+	"github.com/flightctl/fips-validator/internal/validation"
+)
+
+// DriftKind is the kind of a discrepancy between a manifest and a new report.
+type DriftKind string
+
+const (
+	DriftAdded   DriftKind = "added"
+	DriftRemoved DriftKind = "removed"
+	DriftChanged DriftKind = "changed"
+	DriftVerdict DriftKind = "verdict"
+)
+
+// Discrepancy is a difference between a manifest and a new report. Path is
+// empty for targets as a whole, and "openssl" for their OpenSSL installation.
+// Old and New describe the manifest's and the new report's side, e.g. the
+// status or the SHA-256 digest of a binary.
+type Discrepancy struct {
+	Target string    `json:"target"`
+	Path   string    `json:"path,omitempty"`
+	Kind   DriftKind `json:"kind"`
+	Old    string    `json:"old,omitempty"`
+	New    string    `json:"new,omitempty"`
+}
+
+// ReadManifest reads a report written with WriteJSON, e.g. by
+// "--output manifest", from the file at path.
+func ReadManifest(path string) (*Report, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read manifest: %v", err)
+	}
+	if err := checkFormatVersion(data); err != nil {
+		return nil, fmt.Errorf("failed to parse manifest %s: %v", path, err)
+	}
+	var r Report
+	if err := json.Unmarshal(data, &r); err != nil {
+		return nil, fmt.Errorf("failed to parse manifest %s: %v", path, err)
+	}
+	return &r, nil
+}
+
+// Compare returns the discrepancies between the manifest and the report r:
+// binaries that were added or removed, whose SHA-256 digest changed, or whose
+// status changed. Targets are matched by mode and name, except that the
+// targets of two single-target reports are always compared, so that an image
+// can be verified under another reference than the one in the manifest.
+// Digests are only compared if both sides have one.
+func Compare(manifest, r *Report) []Discrepancy {
+	if len(manifest.Targets) == 1 && len(r.Targets) == 1 {
+		return compareTargets(manifest.Targets[0], r.Targets[0])
+	}
+
+	var ds []Discrepancy
+	seen := map[string]bool{}
+	for _, t := range r.Targets {
+		seen[t.Mode+" "+t.Name] = true
+		old := findTarget(manifest, t.Mode, t.Name)
+		if old == nil {
+			ds = append(ds, Discrepancy{Target: t.Name, Kind: DriftAdded})
+			continue
+		}
+		ds = append(ds, compareTargets(old, t)...)
+	}
+	for _, t := range manifest.Targets {
+		if !seen[t.Mode+" "+t.Name] {
+			ds = append(ds, Discrepancy{Target: t.Name, Kind: DriftRemoved})
+		}
+	}
+	return ds
+}
+
+func findTarget(r *Report, mode, name string) *Target {
+	for _, t := range r.Targets {
+		if t.Mode == mode && t.Name == name {
+			return t
+		}
+	}
+	return nil
+}
+
+func compareTargets(old, t *Target) []Discrepancy {
+	normalize(old)
+	normalize(t)
+
+	var ds []Discrepancy
+	if o, n := validText(old.OpenSSLValid), validText(t.OpenSSLValid); o != n {
+		ds = append(ds, Discrepancy{Target: t.Name, Path: "openssl", Kind: DriftVerdict, Old: o, New: n})
+	}
+
+	oldBinaries := map[string]*validation.BinaryResult{}
+	for _, b := range old.Binaries {
+		oldBinaries[b.Path] = b
+	}
+	for _, b := range t.Binaries {
+		o, ok := oldBinaries[b.Path]
+		if !ok {
+			ds = append(ds, Discrepancy{Target: t.Name, Path: b.Path, Kind: DriftAdded, New: b.SHA256})
+			continue
+		}
+		delete(oldBinaries, b.Path)
+		if o.SHA256 != "" && b.SHA256 != "" && o.SHA256 != b.SHA256 {
+			ds = append(ds, Discrepancy{Target: t.Name, Path: b.Path, Kind: DriftChanged, Old: o.SHA256, New: b.SHA256})
+		}
+		if o.Status != b.Status {
+			ds = append(ds, Discrepancy{Target: t.Name, Path: b.Path, Kind: DriftVerdict, Old: string(o.Status), New: string(b.Status)})
+		}
+	}
+	// Binaries of the manifest are still ordered by path.
+	for _, o := range old.Binaries {
+		if _, ok := oldBinaries[o.Path]; ok {
+			ds = append(ds, Discrepancy{Target: t.Name, Path: o.Path, Kind: DriftRemoved, Old: o.SHA256})
+		}
+	}
+	return ds
+}
+
+// validText describes an OpenSSL verdict, which is nil if the OpenSSL
+// installation wasn't validated.
+func validText(valid *bool) string {
+	switch {
+	case valid == nil:
+		return "not validated"
+	case *valid:
+		return "passed"
+	default:
+		return "failed"
+	}
+}
+
+// PrintDiscrepancies prints the discrepancies to w in human-readable form.
+func PrintDiscrepancies(w io.Writer, ds []Discrepancy) {
+	yellow := color.New(color.FgYellow).SprintFunc()
+	for _, d := range ds {
+		what := d.Path
+		if what == "" {
+			what = "target " + d.Target
+		}
+		switch d.Kind {
+		case DriftAdded:
+			fmt.Fprintf(w, "%s %s was added\n", yellow("+"), what)
+		case DriftRemoved:
+			fmt.Fprintf(w, "%s %s was removed\n", yellow("-"), what)
+		case DriftChanged:
+			fmt.Fprintf(w, "%s %s changed: sha256 %s, was %s\n", yellow("~"), what, d.New, d.Old)
+		case DriftVerdict:
+			fmt.Fprintf(w, "%s %s %s, was %s\n", yellow("~"), what, d.New, d.Old)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+)
+
+// FormatVersion is the latest version of the JSON report's schema, which is
+// written unless an older one is requested. Changes that restructure or
+// remove fields require a new version; the encoders of older versions keep
+// writing their schema for consumers that depend on it.
+const FormatVersion = 1
+
+// encoders map the supported versions of the JSON report's schema to the
+// values written for a report in that version. Reports are normalized before
+// they are encoded.
+var encoders = map[int]func(r *Report) any{
+	1: func(r *Report) any {
+		return &struct {
+			FormatVersion int `json:"formatVersion"`
+			*Report
+		}{1, r}
+	},
+}
+
+// FormatVersions returns the supported versions of the JSON report's schema,
+// in ascending order.
+func FormatVersions() []int {
+	versions := make([]int, 0, len(encoders))
+	for v := range encoders {
+		versions = append(versions, v)
+	}
+	sort.Ints(versions)
+	return versions
+}
+
+// ValidFormatVersion returns an error if version isn't a supported version of
+// the JSON report's schema.
+func ValidFormatVersion(version int) error {
+	if _, ok := encoders[version]; !ok {
+		return fmt.Errorf("unsupported format version %d (supported: %v)", version, FormatVersions())
+	}
+	return nil
+}
+
+// WriteJSONVersion is like WriteJSON, but writes the report in the given
+// version of the schema, see FormatVersions.
+func WriteJSONVersion(w io.Writer, r *Report, version int, compact bool) error {
+	encode, ok := encoders[version]
+	if !ok {
+		return ValidFormatVersion(version)
+	}
+	for _, t := range r.Targets {
+		normalize(t)
+	}
+
+	enc := json.NewEncoder(w)
+	if !compact {
+		enc.SetIndent("", "  ")
+	}
+	return enc.Encode(encode(r))
+}
+
+// checkFormatVersion returns an error if a report read from JSON was written
+// in a newer version of the schema than this build supports. Reports without
+// a version predate versioning and are in version 1.
+func checkFormatVersion(data []byte) error {
+	var v struct {
+		FormatVersion int `json:"formatVersion"`
+	}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	if _, ok := encoders[v.FormatVersion]; v.FormatVersion != 0 && !ok {
+		return fmt.Errorf("unsupported format version %d, written by a newer fips-validator", v.FormatVersion)
+	}
+	return nil
+}
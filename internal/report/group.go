@@ -0,0 +1,161 @@
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/flightctl/fips-validator/internal/validation"
+)
+
+// GroupedReport is a report organized by failed check rather than by binary,
+// for triaging large scans: Checks maps the ID of each failed check to the
+// binaries and OpenSSL installations that failed it, while the binaries that
+// passed or were skipped are only counted in Summary.
+type GroupedReport struct {
+	Valid   bool                   `json:"valid"`
+	Summary Summary                `json:"summary"`
+	Checks  map[string]*CheckGroup `json:"checks"`
+}
+
+// CheckGroup lists the failures of a single check, in the order of the
+// targets and by binary path.
+type CheckGroup struct {
+	Title       string    `json:"title,omitempty"`
+	Remediation string    `json:"remediation,omitempty"`
+	Failures    []Failure `json:"failures"`
+}
+
+// Failure is a finding with error severity of a binary or, if Path is empty,
+// of the OpenSSL installation of the target named Target.
+type Failure struct {
+	Target  string `json:"target"`
+	Path    string `json:"path,omitempty"`
+	Message string `json:"message"`
+}
+
+// GroupByCheck returns the failures of the report grouped by check. Like
+// Summary.FailuresByCheck, it only considers the findings with error
+// severity of failed binaries that belong to a check.
+func GroupByCheck(r *Report) *GroupedReport {
+	g := &GroupedReport{Valid: r.Valid, Summary: r.Summary, Checks: map[string]*CheckGroup{}}
+	add := func(check string, f Failure) {
+		cg := g.Checks[check]
+		if cg == nil {
+			cg = &CheckGroup{}
+			if ci, ok := validation.LookupCheck(check); ok {
+				cg.Title, cg.Remediation = ci.Title, ci.Remediation
+			}
+			g.Checks[check] = cg
+		}
+		cg.Failures = append(cg.Failures, f)
+	}
+
+	for _, t := range r.Targets {
+		normalize(t)
+		if t.OpenSSL != nil {
+			for _, f := range t.OpenSSL.Findings {
+				if f.Severity == validation.SeverityError && f.Check != "" {
+					add(f.Check, Failure{Target: t.Name, Message: f.Message})
+				}
+			}
+		}
+		for _, b := range t.Binaries {
+			if b.Status != validation.StatusFailed {
+				continue
+			}
+			for _, f := range b.Findings {
+				if f.Severity == validation.SeverityError && f.Check != "" {
+					add(f.Check, Failure{Target: t.Name, Path: b.Path, Message: f.Message})
+				}
+			}
+		}
+	}
+	return g
+}
+
+// WriteGroupedJSON writes the report grouped by check as JSON to w, like
+// WriteJSON.
+func WriteGroupedJSON(w io.Writer, r *Report, compact bool) error {
+	enc := json.NewEncoder(w)
+	if !compact {
+		enc.SetIndent("", "  ")
+	}
+	return enc.Encode(GroupByCheck(r))
+}
+
+// PrintGroupedByCheck prints the report grouped by check to w in
+// human-readable form: each failed check, starting with the one failed most
+// often, with the binaries that failed it, followed by the number of binaries
+// that passed and were skipped. Binaries are prefixed with their target's name
+// if the report covers several targets.
+func PrintGroupedByCheck(w io.Writer, r *Report) {
+	g := GroupByCheck(r)
+	checks := make([]string, 0, len(g.Checks))
+	for check := range g.Checks {
+		checks = append(checks, check)
+	}
+	sort.Slice(checks, func(i, j int) bool {
+		ni, nj := len(g.Checks[checks[i]].Failures), len(g.Checks[checks[j]].Failures)
+		if ni != nj {
+			return ni > nj
+		}
+		return checks[i] < checks[j]
+	})
+
+	if len(checks) > 0 {
+		fmt.Fprintf(w, "\nFailures by check:\n")
+	}
+	for _, check := range checks {
+		cg := g.Checks[check]
+		if cg.Title != "" {
+			fmt.Fprintf(w, "• %s: %s (%d)\n", check, cg.Title, len(cg.Failures))
+		} else {
+			fmt.Fprintf(w, "• %s (%d)\n", check, len(cg.Failures))
+		}
+		for _, f := range cg.Failures {
+			subject := f.Path
+			if subject == "" {
+				subject = "OpenSSL installation"
+			}
+			if len(r.Targets) > 1 {
+				subject = f.Target + ": " + subject
+			}
+			fmt.Fprintf(w, "  %s %s: %s\n", FindingMark(validation.Finding{Severity: validation.SeverityError}), subject, f.Message)
+		}
+	}
+
+	fmt.Fprintln(w)
+	printCounts(w, g.Summary)
+}
+
+// printCounts prints the number of binaries of s by status to w, with the
+// reasons binaries were skipped for, most frequent first.
+func printCounts(w io.Writer, s Summary) {
+	fmt.Fprintf(w, "Binaries: %d failed, %d passed, %d skipped", s.Failed, s.Passed, s.Skipped)
+	if len(s.SkippedByReason) > 0 {
+		reasons := make([]string, 0, len(s.SkippedByReason))
+		for reason := range s.SkippedByReason {
+			reasons = append(reasons, reason)
+		}
+		sort.Slice(reasons, func(i, j int) bool {
+			ni, nj := s.SkippedByReason[reasons[i]], s.SkippedByReason[reasons[j]]
+			if ni != nj {
+				return ni > nj
+			}
+			return reasons[i] < reasons[j]
+		})
+		counts := make([]string, len(reasons))
+		for i, reason := range reasons {
+			msg, ok := skipMessages[validation.SkipReason(reason)]
+			if !ok {
+				msg = reason
+			}
+			counts[i] = fmt.Sprintf("%s: %d", msg, s.SkippedByReason[reason])
+		}
+		fmt.Fprintf(w, " (%s)", strings.Join(counts, ", "))
+	}
+	fmt.Fprintln(w)
+}
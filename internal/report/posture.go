@@ -0,0 +1,136 @@
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/fatih/color"
+
+	"github.com/flightctl/fips-validator/internal/validation"
+)
+
+// PostureDiff is the change in FIPS posture between two targets, e.g. two
+// versions of a base image. Unlike Compare, which checks a target against
+// its manifest, it only compares verdicts: Changes list the binaries that
+// were added or removed, with their status, and the binaries and OpenSSL
+// installation whose verdict changed.
+type PostureDiff struct {
+	Old     *Target       `json:"old"`
+	New     *Target       `json:"new"`
+	Changes []Discrepancy `json:"changes"`
+	// Regressions and Improvements count the changes that newly fail and
+	// newly pass, see Discrepancy.Regression.
+	Regressions  int `json:"regressions"`
+	Improvements int `json:"improvements"`
+}
+
+// ComparePosture returns the change in FIPS posture from old to t.
+func ComparePosture(old, t *Target) *PostureDiff {
+	normalize(old)
+	normalize(t)
+	d := &PostureDiff{Old: old, New: t, Changes: []Discrepancy{}}
+	if o, n := validText(old.OpenSSLValid), validText(t.OpenSSLValid); o != n {
+		d.Changes = append(d.Changes, Discrepancy{Target: t.Name, Path: "openssl", Kind: DriftVerdict, Old: o, New: n})
+	}
+
+	oldBinaries := map[string]*validation.BinaryResult{}
+	for _, b := range old.Binaries {
+		oldBinaries[b.Path] = b
+	}
+	for _, b := range t.Binaries {
+		o, ok := oldBinaries[b.Path]
+		switch {
+		case !ok:
+			d.Changes = append(d.Changes, Discrepancy{Target: t.Name, Path: b.Path, Kind: DriftAdded, New: string(b.Status)})
+		case o.Status != b.Status:
+			d.Changes = append(d.Changes, Discrepancy{Target: t.Name, Path: b.Path, Kind: DriftVerdict, Old: string(o.Status), New: string(b.Status)})
+		}
+		delete(oldBinaries, b.Path)
+	}
+	for _, o := range old.Binaries {
+		if _, ok := oldBinaries[o.Path]; ok {
+			d.Changes = append(d.Changes, Discrepancy{Target: t.Name, Path: o.Path, Kind: DriftRemoved, Old: string(o.Status)})
+		}
+	}
+
+	for _, c := range d.Changes {
+		if c.Regression() {
+			d.Regressions++
+		} else if c.Improvement() {
+			d.Improvements++
+		}
+	}
+	return d
+}
+
+// Regression returns whether the change makes the posture worse: a binary or
+// OpenSSL installation that newly fails, or an added binary that fails.
+func (d Discrepancy) Regression() bool {
+	return d.New == "failed" && d.Old != "failed" && d.Kind != DriftRemoved
+}
+
+// Improvement returns whether the change makes the posture better: a binary
+// or OpenSSL installation that failed and no longer does, or a removed
+// binary that failed.
+func (d Discrepancy) Improvement() bool {
+	return d.Old == "failed" && d.New != "failed" && d.Kind != DriftAdded
+}
+
+// WritePostureDiff writes d to w as JSON, pretty-printed unless compact is
+// set.
+func WritePostureDiff(w io.Writer, d *PostureDiff, compact bool) error {
+	enc := json.NewEncoder(w)
+	if !compact {
+		enc.SetIndent("", "  ")
+	}
+	return enc.Encode(d)
+}
+
+// PrintPostureDiff prints d to w in human-readable form: the regressions
+// first, then the improvements and the other changes, e.g. binaries that are
+// skipped now.
+func PrintPostureDiff(w io.Writer, d *PostureDiff) {
+	red := color.New(color.Bold, color.FgRed).SprintFunc()
+	green := color.New(color.Bold, color.FgGreen).SprintFunc()
+	yellow := color.New(color.FgYellow).SprintFunc()
+	sections := []struct {
+		title   string
+		mark    string
+		matches func(Discrepancy) bool
+	}{
+		{"Regressions", red("✘"), Discrepancy.Regression},
+		{"Improvements", green("✔"), Discrepancy.Improvement},
+		{"Other changes", yellow("~"), func(c Discrepancy) bool { return !c.Regression() && !c.Improvement() }},
+	}
+	for _, s := range sections {
+		var lines []string
+		for _, c := range d.Changes {
+			if s.matches(c) {
+				lines = append(lines, postureLine(c))
+			}
+		}
+		if len(lines) == 0 {
+			continue
+		}
+		fmt.Fprintf(w, "\n%s:\n", s.title)
+		for _, l := range lines {
+			fmt.Fprintf(w, "  %s %s\n", s.mark, l)
+		}
+	}
+}
+
+// postureLine describes a change in FIPS posture.
+func postureLine(c Discrepancy) string {
+	what := c.Path
+	if what == "openssl" {
+		what = "OpenSSL installation"
+	}
+	switch c.Kind {
+	case DriftAdded:
+		return fmt.Sprintf("%s was added (%s)", what, c.New)
+	case DriftRemoved:
+		return fmt.Sprintf("%s was removed (was %s)", what, c.Old)
+	}
+	return fmt.Sprintf("%s %s, was %s", what, c.New, c.Old)
+}
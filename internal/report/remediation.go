@@ -0,0 +1,131 @@
+package report
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/flightctl/fips-validator/internal/validation"
+)
+
+// Remediation is the guidance for the failures of one or more checks across
+// all targets of a report. Checks whose documented remediation is the same are
+// grouped, so that each fix is listed once.
+type Remediation struct {
+	// Checks are the IDs of the failed checks the remediation fixes.
+	Checks []string `json:"checks"`
+	// Binaries is the number of failed binaries the remediation applies to.
+	Binaries int `json:"binaries,omitempty"`
+	// OpenSSL is the number of targets whose OpenSSL installation failed
+	// one of the checks.
+	OpenSSL     int    `json:"openssl,omitempty"`
+	Remediation string `json:"remediation"`
+}
+
+// remediations aggregates the failures of all targets into remediations,
+// ordered by the number of OpenSSL installations and binaries they apply to.
+// Only findings with error severity of checks with a documented remediation
+// are considered.
+func remediations(targets []*Target) []Remediation {
+	groups := map[string]*Remediation{}
+	apply := func(check string, binary bool) {
+		ci, ok := validation.LookupCheck(check)
+		if !ok || ci.Remediation == "" {
+			return
+		}
+		g := groups[ci.Remediation]
+		if g == nil {
+			g = &Remediation{Remediation: ci.Remediation}
+			groups[ci.Remediation] = g
+		}
+		if !slices.Contains(g.Checks, check) {
+			g.Checks = append(g.Checks, check)
+		}
+		if binary {
+			g.Binaries++
+		} else {
+			g.OpenSSL++
+		}
+	}
+
+	for _, t := range targets {
+		if t.OpenSSL != nil {
+			for _, check := range failedRemediations(t.OpenSSL.Findings) {
+				apply(check, false)
+			}
+		}
+		for _, b := range t.Binaries {
+			if b.Status != validation.StatusFailed {
+				continue
+			}
+			for _, check := range failedRemediations(b.Findings) {
+				apply(check, true)
+			}
+		}
+	}
+
+	var rs []Remediation
+	for _, g := range groups {
+		sort.Strings(g.Checks)
+		rs = append(rs, *g)
+	}
+	sort.Slice(rs, func(i, j int) bool {
+		if rs[i].OpenSSL != rs[j].OpenSSL {
+			return rs[i].OpenSSL > rs[j].OpenSSL
+		}
+		if rs[i].Binaries != rs[j].Binaries {
+			return rs[i].Binaries > rs[j].Binaries
+		}
+		return rs[i].Checks[0] < rs[j].Checks[0]
+	})
+	return rs
+}
+
+// failedRemediations returns the IDs of the checks that failed with error
+// severity, one per remediation, so that a binary failing two checks with the
+// same remediation is counted once.
+func failedRemediations(findings []validation.Finding) []string {
+	var checks []string
+	seen := map[string]bool{}
+	for _, f := range findings {
+		if f.Severity != validation.SeverityError {
+			continue
+		}
+		ci, ok := validation.LookupCheck(f.Check)
+		if !ok || seen[ci.Remediation] {
+			continue
+		}
+		seen[ci.Remediation] = true
+		checks = append(checks, f.Check)
+	}
+	return checks
+}
+
+// PrintRemediations prints the remediations of a failed report to w in
+// human-readable form, one line per remediation with the number of binaries it
+// applies to and the IDs of the checks it fixes.
+func PrintRemediations(w io.Writer, rs []Remediation) {
+	if len(rs) == 0 {
+		return
+	}
+	fmt.Fprintf(w, "\nHow to fix:\n")
+	for _, r := range rs {
+		var applies []string
+		switch {
+		case r.OpenSSL == 1:
+			applies = append(applies, "the OpenSSL installation")
+		case r.OpenSSL > 1:
+			applies = append(applies, fmt.Sprintf("the OpenSSL installations of %d targets", r.OpenSSL))
+		}
+		switch {
+		case r.Binaries == 1:
+			applies = append(applies, "1 binary")
+		case r.Binaries > 1:
+			applies = append(applies, fmt.Sprintf("%d binaries", r.Binaries))
+		}
+		fmt.Fprintf(w, "• %s (%s): %s\n", strings.Join(applies, " and "), strings.Join(r.Checks, ", "), r.Remediation)
+	}
+	fmt.Fprintln(w)
+}
@@ -0,0 +1,208 @@
+package report
+
+import (
+	"io"
+	"sort"
+
+	"github.com/flightctl/fips-validator/internal/validation"
+)
+
+// Report is the result of a validation run over one or more targets.
+type Report struct {
+	Valid   bool      `json:"valid"`
+	Summary Summary   `json:"summary"`
+	Targets []*Target `json:"targets"`
+	// Tools lists the external tools the run depended on, so that
+	// differing verdicts of two runs can be traced to differing tools.
+	Tools []Tool `json:"tools,omitempty"`
+	// Commands lists the external commands the run executed, with
+	// credentials redacted. It's only recorded with --debug.
+	Commands []Command `json:"commands,omitempty"`
+	// Remediations is the consolidated guidance for fixing the failures
+	// of all targets.
+	Remediations []Remediation `json:"remediations,omitempty"`
+}
+
+// Tool is an external tool, e.g. podman or nm, with the version it reported.
+// Version is empty if the tool didn't report one.
+type Tool struct {
+	Name    string `json:"name"`
+	Path    string `json:"path"`
+	Version string `json:"version,omitempty"`
+}
+
+// Command is an external command a run executed, e.g. "podman image mount".
+// Args start with the command itself.
+type Command struct {
+	Args     []string `json:"args"`
+	Dir      string   `json:"dir,omitempty"`
+	ExitCode int      `json:"exitCode"`
+}
+
+// Target is the result of validating a single binary, RPM package, or image.
+type Target struct {
+	Mode         string  `json:"mode"`
+	Name         string  `json:"name"`
+	Valid        bool    `json:"valid"`
+	OpenSSLValid *bool   `json:"opensslValid,omitempty"`
+	Summary      Summary `json:"summary"`
+	// OpenSSL is the result of validating the target's OpenSSL
+	// installation, for images, directories, and ostree commits.
+	OpenSSL *validation.OpenSSLResult `json:"openssl,omitempty"`
+	// StoppedEarly is set if validation stopped after the maximum number of
+	// failures, so that not all binaries of the target were validated.
+	StoppedEarly bool `json:"stoppedEarly,omitempty"`
+	// AllELFFiles is set if the target's files had no permissions, e.g. a
+	// zip archive created without them, so that all ELF files were
+	// validated rather than only executables.
+	AllELFFiles bool `json:"allElfFiles,omitempty"`
+	// SinceGit is the git ref given with --since-git, if only the files
+	// of a directory that changed since then were validated.
+	SinceGit string `json:"sinceGit,omitempty"`
+	// Errors lists problems with the target as a whole, e.g. insufficient
+	// coverage.
+	Errors   []string                   `json:"errors,omitempty"`
+	Binaries []*validation.BinaryResult `json:"binaries"`
+	// Subjects are the artifacts the target consists of, for attestations.
+	// They are only part of the in-toto statement, not of the report.
+	Subjects []Subject `json:"-"`
+}
+
+// Summary counts the binaries of one or more targets by validation status.
+type Summary struct {
+	Binaries int `json:"binaries"`
+	Passed   int `json:"passed"`
+	Failed   int `json:"failed"`
+	Skipped  int `json:"skipped"`
+	// Libcrypto is the number of libcrypto libraries found, if the target's
+	// OpenSSL installation was validated.
+	Libcrypto *int `json:"libcrypto,omitempty"`
+	// FailuresByCheck counts the failed binaries by the IDs of the checks
+	// that failed for them. A binary failing several checks is counted for
+	// each of them.
+	FailuresByCheck map[string]int `json:"failuresByCheck,omitempty"`
+	// SkippedByReason counts the skipped binaries by skip reason, e.g. to
+	// tell how many files exceeded the maximum file size.
+	SkippedByReason map[string]int `json:"skippedByReason,omitempty"`
+	// FailedPrivileged is the number of failed binaries with the setuid or
+	// setgid bit, which are especially dangerous.
+	FailedPrivileged int `json:"failedPrivileged,omitempty"`
+	// Runtimes counts the validated crypto shims of managed runtimes by
+	// runtime, e.g. {".NET": 1}, as their crypto is that of the managed
+	// applications rather than of a native binary.
+	Runtimes map[string]int `json:"runtimes,omitempty"`
+}
+
+// NewSummary counts the given binaries by validation status.
+func NewSummary(results []*validation.BinaryResult) Summary {
+	s := Summary{Binaries: len(results)}
+	for _, r := range results {
+		if r.Runtime != "" && r.Status != validation.StatusSkipped {
+			if s.Runtimes == nil {
+				s.Runtimes = map[string]int{}
+			}
+			s.Runtimes[r.Runtime]++
+		}
+		switch r.Status {
+		case validation.StatusPassed:
+			s.Passed++
+		case validation.StatusFailed:
+			s.Failed++
+			s.countFailures(r)
+			if r.Setuid || r.Setgid {
+				s.FailedPrivileged++
+			}
+		case validation.StatusSkipped:
+			s.Skipped++
+			if s.SkippedByReason == nil {
+				s.SkippedByReason = map[string]int{}
+			}
+			s.SkippedByReason[string(r.Reason)]++
+		}
+	}
+	return s
+}
+
+// countFailures counts each check that failed for r once.
+func (s *Summary) countFailures(r *validation.BinaryResult) {
+	seen := map[string]bool{}
+	for _, f := range r.Findings {
+		if f.Severity != validation.SeverityError || f.Check == "" || seen[f.Check] {
+			continue
+		}
+		seen[f.Check] = true
+		if s.FailuresByCheck == nil {
+			s.FailuresByCheck = map[string]int{}
+		}
+		s.FailuresByCheck[f.Check]++
+	}
+}
+
+func (s *Summary) add(o Summary) {
+	s.Binaries += o.Binaries
+	s.Passed += o.Passed
+	s.Failed += o.Failed
+	s.Skipped += o.Skipped
+	s.FailedPrivileged += o.FailedPrivileged
+	for check, n := range o.FailuresByCheck {
+		if s.FailuresByCheck == nil {
+			s.FailuresByCheck = map[string]int{}
+		}
+		s.FailuresByCheck[check] += n
+	}
+	for runtime, n := range o.Runtimes {
+		if s.Runtimes == nil {
+			s.Runtimes = map[string]int{}
+		}
+		s.Runtimes[runtime] += n
+	}
+	for reason, n := range o.SkippedByReason {
+		if s.SkippedByReason == nil {
+			s.SkippedByReason = map[string]int{}
+		}
+		s.SkippedByReason[reason] += n
+	}
+	if o.Libcrypto != nil {
+		n := *o.Libcrypto
+		if s.Libcrypto != nil {
+			n += *s.Libcrypto
+		}
+		s.Libcrypto = &n
+	}
+}
+
+// New returns a report over the given targets, which are kept in the order
+// they were given in.
+func New(targets ...*Target) *Report {
+	r := &Report{Valid: true, Targets: targets}
+	for _, t := range targets {
+		if !t.Valid {
+			r.Valid = false
+		}
+		r.Summary.add(t.Summary)
+	}
+	r.Remediations = remediations(targets)
+	return r
+}
+
+// WriteJSON writes the report as JSON to w, either pretty-printed or on a
+// single line if compact is set. Binaries are ordered by path and findings by
+// check ID so that the output of repeated runs can be compared byte by byte.
+// The report is written in the latest version of the schema, FormatVersion.
+func WriteJSON(w io.Writer, r *Report, compact bool) error {
+	return WriteJSONVersion(w, r, FormatVersion, compact)
+}
+
+func normalize(t *Target) {
+	if t.Binaries == nil {
+		t.Binaries = []*validation.BinaryResult{}
+	}
+	sort.SliceStable(t.Binaries, func(i, j int) bool {
+		return t.Binaries[i].Path < t.Binaries[j].Path
+	})
+	for _, b := range t.Binaries {
+		sort.SliceStable(b.Findings, func(i, j int) bool {
+			return b.Findings[i].Check < b.Findings[j].Check
+		})
+	}
+}
@@ -0,0 +1,253 @@
+package report
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/fatih/color"
+
+	"github.com/flightctl/fips-validator/internal/validation"
+)
+
+var skipMessages = map[validation.SkipReason]string{
+	validation.SkipShellScript:    "shell script",
+	validation.SkipReadError:      "failed to read ELF info",
+	validation.SkipNotElf:         "not an ELF executable",
+	validation.SkipNoCrypto:       "no crypto",
+	validation.SkipNonElfPlatform: "non-ELF platform",
+	validation.SkipArch:           "excluded architecture",
+	validation.SkipNoSymbols:      "no symbols",
+	validation.SkipPacked:         "packed executable",
+	validation.SkipTooLarge:       "exceeds max-file-size",
+	validation.SkipNotBuilt:       "not built",
+}
+
+// PrintBinaryResult prints the result of validating a single binary to w in
+// human-readable form.
+func PrintBinaryResult(w io.Writer, r *validation.BinaryResult) {
+	switch r.BuildMode {
+	case "", "exe", "pie":
+		if r.Runtime != "" {
+			fmt.Fprintf(w, "• validating %s crypto shim %s... ", r.Runtime, r.Path)
+			break
+		}
+		fmt.Fprintf(w, "• validating binary %s... ", r.Path)
+	default:
+		fmt.Fprintf(w, "• validating binary %s (-buildmode=%s)... ", r.Path, r.BuildMode)
+	}
+	fmt.Fprintf(w, "%s\n", statusText(r))
+	printFindings(w, r)
+}
+
+// PrintBinaryResultRow prints the result of validating a single binary to w
+// as a table row that fits into the given number of columns: the path, elided
+// in the middle if too long, followed by the right-aligned status.
+func PrintBinaryResultRow(w io.Writer, r *validation.BinaryResult, width int) {
+	path := r.Path
+	switch r.BuildMode {
+	case "", "exe", "pie":
+		if r.Runtime != "" {
+			path += " (" + r.Runtime + " crypto shim)"
+		}
+	default:
+		path += " (-buildmode=" + r.BuildMode + ")"
+	}
+	status := statusText(r)
+	statusWidth := utf8.RuneCountInString(statusPlain(r))
+
+	// "• " + path + at least one space + status
+	pathWidth := max(width-2-1-statusWidth, 16)
+	path = elide(path, pathWidth)
+	padding := max(pathWidth-utf8.RuneCountInString(path), 0)
+	fmt.Fprintf(w, "• %s %s%s\n", path, strings.Repeat(" ", padding), status)
+	printFindings(w, r)
+}
+
+// PrintOpenSSLResult prints the result of validating an OpenSSL installation
+// to w in human-readable form.
+func PrintOpenSSLResult(w io.Writer, r *validation.OpenSSLResult) {
+	fmt.Fprintf(w, "• validating libcrypto is present and FIPS-capable... ")
+	if r.Valid {
+		fmt.Fprintf(w, "%s\n", color.New(color.Bold, color.FgGreen).Sprint("success"))
+	} else {
+		fmt.Fprintf(w, "%s\n", color.New(color.Bold, color.FgRed).Sprint("failed"))
+	}
+	for _, f := range r.Findings {
+		fmt.Fprintf(w, "  %s %s\n", FindingMark(f), f.Message)
+	}
+	if r.ProviderVersion != "" {
+		fmt.Fprintf(w, "  FIPS provider version: %s\n", r.ProviderVersion)
+	}
+}
+
+// PrintPrivilegedFailures prints the failed binaries of r that have the setuid
+// or setgid bit to w, as they are especially dangerous.
+func PrintPrivilegedFailures(w io.Writer, r *Report) {
+	if r.Summary.FailedPrivileged == 0 {
+		return
+	}
+	bold := color.New(color.Bold, color.FgRed)
+	if r.Summary.FailedPrivileged == 1 {
+		fmt.Fprintf(w, "\n%s\n", bold.Sprint("1 setuid or setgid binary fails FIPS validation:"))
+	} else {
+		fmt.Fprintf(w, "\n%s\n", bold.Sprintf("%d setuid or setgid binaries fail FIPS validation:", r.Summary.FailedPrivileged))
+	}
+	for _, t := range r.Targets {
+		prefix := ""
+		if len(r.Targets) > 1 {
+			prefix = t.Name + ": "
+		}
+		for _, b := range t.Binaries {
+			if b.Status == validation.StatusFailed && b.Privilege() != "" {
+				fmt.Fprintf(w, "  %s %s%s (%s)\n", bold.Sprint("✘"), prefix, b.Path, b.Privilege())
+			}
+		}
+	}
+}
+
+// PrintSummary prints only the summary of r to w in human-readable form, for
+// dashboards that don't need the results of the single binaries: the targets
+// and binaries by status, the verdict on the OpenSSL installations, problems
+// with the targets as a whole, e.g. insufficient coverage, and the number of
+// binaries failing each check, most frequent first.
+func PrintSummary(w io.Writer, r *Report) {
+	if len(r.Targets) > 1 {
+		failed := 0
+		for _, t := range r.Targets {
+			if !t.Valid {
+				failed++
+			}
+		}
+		fmt.Fprintf(w, "Targets: %d failed, %d passed\n", failed, len(r.Targets)-failed)
+	}
+	printCounts(w, r.Summary)
+	if len(r.Summary.Runtimes) > 0 {
+		runtimes := make([]string, 0, len(r.Summary.Runtimes))
+		for runtime, n := range r.Summary.Runtimes {
+			runtimes = append(runtimes, fmt.Sprintf("%s: %d", runtime, n))
+		}
+		sort.Strings(runtimes)
+		fmt.Fprintf(w, "Managed runtime crypto shims: %s\n", strings.Join(runtimes, ", "))
+	}
+	if r.Summary.FailedPrivileged > 0 {
+		fmt.Fprintf(w, "Failed setuid or setgid binaries: %d\n", r.Summary.FailedPrivileged)
+	}
+	valid, invalid := 0, 0
+	for _, t := range r.Targets {
+		switch {
+		case t.OpenSSLValid == nil:
+		case *t.OpenSSLValid:
+			valid++
+		default:
+			invalid++
+		}
+	}
+	if valid+invalid > 0 {
+		fmt.Fprintf(w, "OpenSSL installations: %d failed, %d passed\n", invalid, valid)
+	}
+	for _, t := range r.Targets {
+		for _, e := range t.Errors {
+			if len(r.Targets) > 1 {
+				e = t.Name + ": " + e
+			}
+			fmt.Fprintf(w, "%s %s\n", color.New(color.Bold, color.FgRed).Sprint("✘"), e)
+		}
+	}
+
+	checks := make([]string, 0, len(r.Summary.FailuresByCheck))
+	for check := range r.Summary.FailuresByCheck {
+		checks = append(checks, check)
+	}
+	if len(checks) == 0 {
+		return
+	}
+	sort.Slice(checks, func(i, j int) bool {
+		ni, nj := r.Summary.FailuresByCheck[checks[i]], r.Summary.FailuresByCheck[checks[j]]
+		if ni != nj {
+			return ni > nj
+		}
+		return checks[i] < checks[j]
+	})
+	width := 0
+	for _, check := range checks {
+		width = max(width, len(check))
+	}
+	fmt.Fprintf(w, "\nFailures by check:\n")
+	for _, check := range checks {
+		fmt.Fprintf(w, "• %-*s %d\n", width, check, r.Summary.FailuresByCheck[check])
+	}
+}
+
+// elide shortens s to at most n runes by replacing its middle with "…".
+func elide(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	head := (n - 1) / 2
+	tail := n - 1 - head
+	return string(runes[:head]) + "…" + string(runes[len(runes)-tail:])
+}
+
+// statusPlain returns the uncolored status of a result.
+func statusPlain(r *validation.BinaryResult) string {
+	switch r.Status {
+	case validation.StatusPassed:
+		return "success"
+	case validation.StatusFailed:
+		return "failed"
+	}
+	return fmt.Sprintf("skipped (%s)", SkipMessage(r))
+}
+
+// statusText returns the status of a result, colored for the terminal.
+func statusText(r *validation.BinaryResult) string {
+	switch r.Status {
+	case validation.StatusPassed:
+		return color.New(color.Bold, color.FgGreen).Sprint(statusPlain(r))
+	case validation.StatusFailed:
+		return color.New(color.Bold, color.FgRed).Sprint(statusPlain(r))
+	}
+	return statusPlain(r)
+}
+
+func printFindings(w io.Writer, r *validation.BinaryResult) {
+	if r.StaticExemption != "" {
+		fmt.Fprintf(w, "  static (exempted by %s)\n", r.StaticExemption)
+	}
+	for _, f := range r.Findings {
+		fmt.Fprintf(w, "  %s %s\n", FindingMark(f), f.Message)
+		if f.Hint != "" {
+			fmt.Fprintf(w, "    hint: %s\n", f.Hint)
+		}
+	}
+}
+
+// FindingMark returns the colored mark a finding is printed with.
+func FindingMark(f validation.Finding) string {
+	switch f.Severity {
+	case validation.SeverityWarning:
+		return color.New(color.Bold, color.FgYellow).Sprint("⚠")
+	case validation.SeverityInfo:
+		return color.New(color.Bold, color.FgCyan).Sprint("ℹ")
+	}
+	return color.New(color.Bold, color.FgRed).Sprint("✘")
+}
+
+// SkipMessage returns a human-readable explanation of why a binary was skipped.
+func SkipMessage(r *validation.BinaryResult) string {
+	if r.Reason == validation.SkipHardlink {
+		return "same as " + r.Detail
+	}
+	msg, ok := skipMessages[r.Reason]
+	if !ok {
+		msg = string(r.Reason)
+	}
+	if r.Detail != "" {
+		msg += ": " + r.Detail
+	}
+	return msg
+}
@@ -0,0 +1,129 @@
+package rootfs
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// maxSymlinks is the maximum number of symlinks followed when resolving a
+// path, matching the limit of the Linux kernel.
+const maxSymlinks = 40
+
+// Resolve follows all symlinks in name as if the root filesystem at root was
+// mounted at "/", i.e. absolute link targets and ".." components never escape
+// root. It returns the resolved path relative to root, starting with "/".
+func Resolve(root, name string) (string, error) {
+	resolved := "/"
+	rest := splitPath(name)
+	links := 0
+	for len(rest) > 0 {
+		component := rest[0]
+		rest = rest[1:]
+		if component == ".." {
+			resolved = filepath.Dir(resolved)
+			continue
+		}
+
+		next := filepath.Join(resolved, component)
+		fi, err := os.Lstat(filepath.Join(root, next))
+		if err != nil {
+			return "", err
+		}
+		if fi.Mode()&os.ModeSymlink == 0 {
+			resolved = next
+			continue
+		}
+
+		links++
+		if links > maxSymlinks {
+			return "", fmt.Errorf("too many levels of symbolic links: %s", name)
+		}
+		target, err := os.Readlink(filepath.Join(root, next))
+		if err != nil {
+			return "", err
+		}
+		if filepath.IsAbs(target) {
+			resolved = "/"
+		}
+		rest = append(splitPath(target), rest...)
+	}
+	return resolved, nil
+}
+
+// Stat returns the file info of name within the root filesystem at root,
+// following symlinks as Resolve does.
+func Stat(root, name string) (os.FileInfo, error) {
+	resolved, err := Resolve(root, name)
+	if err != nil {
+		return nil, err
+	}
+	return os.Stat(filepath.Join(root, resolved))
+}
+
+func splitPath(p string) []string {
+	var components []string
+	for _, c := range strings.Split(p, "/") {
+		if c != "" && c != "." {
+			components = append(components, c)
+		}
+	}
+	return components
+}
+
+// FS returns a file system for the tree at root that resolves symlinks as
+// Resolve does, so that links never lead outside of root. Names are relative
+// to root, as for any fs.FS, and are resolved on every access.
+func FS(root string) fs.FS {
+	return rootFS(root)
+}
+
+type rootFS string
+
+func (r rootFS) resolve(op, name string) (string, error) {
+	if !fs.ValidPath(name) {
+		return "", &fs.PathError{Op: op, Path: name, Err: fs.ErrInvalid}
+	}
+	resolved, err := Resolve(string(r), name)
+	if err != nil {
+		return "", &fs.PathError{Op: op, Path: name, Err: unwrapPathError(err)}
+	}
+	return filepath.Join(string(r), resolved), nil
+}
+
+func (r rootFS) Open(name string) (fs.File, error) {
+	path, err := r.resolve("open", name)
+	if err != nil {
+		return nil, err
+	}
+	return os.Open(path)
+}
+
+func (r rootFS) Stat(name string) (fs.FileInfo, error) {
+	path, err := r.resolve("stat", name)
+	if err != nil {
+		return nil, err
+	}
+	return os.Stat(path)
+}
+
+func (r rootFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	path, err := r.resolve("readdir", name)
+	if err != nil {
+		return nil, err
+	}
+	return os.ReadDir(path)
+}
+
+// unwrapPathError returns the underlying error of a *fs.PathError, so that
+// errors report the name within the file system rather than the host path.
+func unwrapPathError(err error) error {
+	var pe *fs.PathError
+	if errors.As(err, &pe) {
+		return pe.Err
+	}
+	return err
+}
@@ -0,0 +1,253 @@
+package rpm
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+)
+
+const leadSize = 96
+
+var (
+	leadMagic   = []byte{0xed, 0xab, 0xee, 0xdb}
+	headerMagic = []byte{0x8e, 0xad, 0xe8, 0x01}
+)
+
+// Header tags and types, see rpmtag.h.
+const (
+	tagOldFilenames = 1027
+	tagFileModes    = 1030
+	tagFileFlags    = 1037
+	tagDirIndexes   = 1116
+	tagBasenames    = 1117
+	tagDirnames     = 1118
+
+	typeInt16       = 3
+	typeInt32       = 4
+	typeStringArray = 8
+
+	// fileFlagGhost marks files that are owned by the package but not
+	// included in its payload.
+	fileFlagGhost = 1 << 6
+)
+
+// maxHeaderSize bounds the memory allocated for a header, so that a corrupt
+// package can't make readHeader allocate arbitrary amounts of memory.
+const maxHeaderSize = 256 << 20
+
+// File is a file listed in the header of an RPM package.
+type File struct {
+	// Path is the absolute path the file is installed to.
+	Path string
+	// Mode is the file's mode in the format of stat(2)'s st_mode.
+	Mode uint16
+	// Ghost is set for files that aren't included in the payload.
+	Ghost bool
+}
+
+// IsExecutable returns whether the file is a regular file with any of the
+// execute bits set.
+func (f File) IsExecutable() bool {
+	const sIFMT, sIFREG = 0o170000, 0o100000
+	return f.Mode&sIFMT == sIFREG && f.Mode&0o111 != 0
+}
+
+// ReadFiles reads the file list from the header of the RPM package at
+// pkgPath.
+func ReadFiles(pkgPath string) ([]File, error) {
+	f, err := os.Open(pkgPath)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	r := bufio.NewReader(f)
+
+	lead := make([]byte, leadSize)
+	if _, err := io.ReadFull(r, lead); err != nil {
+		return nil, fmt.Errorf("failed to read lead: %v", err)
+	}
+	if !bytes.Equal(lead[:4], leadMagic) {
+		return nil, errors.New("not an RPM package")
+	}
+
+	// The signature header is padded to a multiple of 8 bytes.
+	_, sigSize, err := readHeader(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read signature header: %v", err)
+	}
+	if pad := (8 - sigSize%8) % 8; pad > 0 {
+		if _, err := r.Discard(pad); err != nil {
+			return nil, fmt.Errorf("failed to read signature header: %v", err)
+		}
+	}
+
+	h, _, err := readHeader(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read header: %v", err)
+	}
+	return h.files()
+}
+
+type indexEntry struct {
+	Tag, Type, Offset, Count int32
+}
+
+type header struct {
+	entries map[int32]indexEntry
+	data    []byte
+}
+
+// readHeader reads a header structure and returns it along with its size.
+func readHeader(r io.Reader) (*header, int, error) {
+	intro := make([]byte, 16)
+	if _, err := io.ReadFull(r, intro); err != nil {
+		return nil, 0, err
+	}
+	if !bytes.Equal(intro[:4], headerMagic) {
+		return nil, 0, errors.New("bad header magic")
+	}
+	nindex := binary.BigEndian.Uint32(intro[8:12])
+	hsize := binary.BigEndian.Uint32(intro[12:16])
+	if uint64(nindex)*16+uint64(hsize) > maxHeaderSize {
+		return nil, 0, errors.New("header too large")
+	}
+
+	index := make([]indexEntry, nindex)
+	if err := binary.Read(r, binary.BigEndian, index); err != nil {
+		return nil, 0, err
+	}
+	h := &header{entries: map[int32]indexEntry{}, data: make([]byte, hsize)}
+	if _, err := io.ReadFull(r, h.data); err != nil {
+		return nil, 0, err
+	}
+	for _, e := range index {
+		h.entries[e.Tag] = e
+	}
+	return h, 16 + int(nindex)*16 + int(hsize), nil
+}
+
+// entry returns the index entry of tag if it has the given type, or false if
+// the header doesn't contain tag.
+func (h *header) entry(tag, typ int32) (indexEntry, bool, error) {
+	e, ok := h.entries[tag]
+	if !ok {
+		return e, false, nil
+	}
+	if e.Type != typ {
+		return e, false, fmt.Errorf("tag %d has type %d, expected %d", tag, e.Type, typ)
+	}
+	if e.Offset < 0 || int(e.Offset) > len(h.data) || e.Count < 0 {
+		return e, false, fmt.Errorf("tag %d is out of bounds", tag)
+	}
+	return e, true, nil
+}
+
+func (h *header) strings(tag int32) ([]string, error) {
+	e, ok, err := h.entry(tag, typeStringArray)
+	if !ok {
+		return nil, err
+	}
+	data := h.data[e.Offset:]
+	if int(e.Count) > len(data) {
+		return nil, fmt.Errorf("tag %d is out of bounds", tag)
+	}
+	strs := make([]string, 0, e.Count)
+	for range e.Count {
+		i := bytes.IndexByte(data, 0)
+		if i < 0 {
+			return nil, fmt.Errorf("tag %d is out of bounds", tag)
+		}
+		strs = append(strs, string(data[:i]))
+		data = data[i+1:]
+	}
+	return strs, nil
+}
+
+func (h *header) ints(tag, typ int32) ([]uint32, error) {
+	e, ok, err := h.entry(tag, typ)
+	if !ok {
+		return nil, err
+	}
+	size := 4
+	if typ == typeInt16 {
+		size = 2
+	}
+	if int(e.Offset)+int(e.Count)*size > len(h.data) {
+		return nil, fmt.Errorf("tag %d is out of bounds", tag)
+	}
+	vals := make([]uint32, e.Count)
+	for i := range vals {
+		b := h.data[int(e.Offset)+i*size:]
+		if size == 2 {
+			vals[i] = uint32(binary.BigEndian.Uint16(b))
+		} else {
+			vals[i] = binary.BigEndian.Uint32(b)
+		}
+	}
+	return vals, nil
+}
+
+func (h *header) files() ([]File, error) {
+	paths, err := h.paths()
+	if err != nil {
+		return nil, err
+	}
+	modes, err := h.ints(tagFileModes, typeInt16)
+	if err != nil {
+		return nil, err
+	}
+	flags, err := h.ints(tagFileFlags, typeInt32)
+	if err != nil {
+		return nil, err
+	}
+	if len(modes) != len(paths) || (flags != nil && len(flags) != len(paths)) {
+		return nil, errors.New("inconsistent file list")
+	}
+
+	files := make([]File, len(paths))
+	for i, p := range paths {
+		files[i] = File{Path: p, Mode: uint16(modes[i])}
+		if flags != nil {
+			files[i].Ghost = flags[i]&fileFlagGhost != 0
+		}
+	}
+	return files, nil
+}
+
+// paths returns the paths of all files, which are either stored as basenames
+// with an index into the list of directories or, in old packages, as full
+// paths.
+func (h *header) paths() ([]string, error) {
+	basenames, err := h.strings(tagBasenames)
+	if err != nil {
+		return nil, err
+	}
+	if basenames == nil {
+		return h.strings(tagOldFilenames)
+	}
+	dirnames, err := h.strings(tagDirnames)
+	if err != nil {
+		return nil, err
+	}
+	dirIndexes, err := h.ints(tagDirIndexes, typeInt32)
+	if err != nil {
+		return nil, err
+	}
+	if len(dirIndexes) != len(basenames) {
+		return nil, errors.New("inconsistent file list")
+	}
+
+	paths := make([]string, len(basenames))
+	for i, base := range basenames {
+		if int(dirIndexes[i]) >= len(dirnames) {
+			return nil, errors.New("inconsistent file list")
+		}
+		paths[i] = path.Join(dirnames[dirIndexes[i]], base)
+	}
+	return paths, nil
+}
@@ -0,0 +1,209 @@
+package rpm
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// tag is an entry of a header built by buildHeader.
+type tag struct {
+	tag, typ int32
+	value    interface{}
+}
+
+// buildHeader returns a header structure with the given tags, whose values
+// are []string for string arrays and []uint16 or []uint32 for integers.
+func buildHeader(tags ...tag) []byte {
+	var index, data bytes.Buffer
+	for _, t := range tags {
+		offset := int32(data.Len())
+		var count int
+		switch v := t.value.(type) {
+		case []string:
+			for _, s := range v {
+				data.WriteString(s)
+				data.WriteByte(0)
+			}
+			count = len(v)
+		case []uint16:
+			binary.Write(&data, binary.BigEndian, v)
+			count = len(v)
+		case []uint32:
+			binary.Write(&data, binary.BigEndian, v)
+			count = len(v)
+		}
+		binary.Write(&index, binary.BigEndian, indexEntry{Tag: t.tag, Type: t.typ, Offset: offset, Count: int32(count)})
+	}
+	var h bytes.Buffer
+	h.Write(headerMagic)
+	h.Write(make([]byte, 4))
+	binary.Write(&h, binary.BigEndian, uint32(len(tags)))
+	binary.Write(&h, binary.BigEndian, uint32(data.Len()))
+	h.Write(index.Bytes())
+	h.Write(data.Bytes())
+	return h.Bytes()
+}
+
+// withCount sets the count of the i-th index entry of the header structure
+// hdr to count.
+func withCount(hdr []byte, i int, count uint32) []byte {
+	binary.BigEndian.PutUint32(hdr[16+i*16+12:], count)
+	return hdr
+}
+
+// buildPackage returns an RPM package with the given header after a minimal
+// signature header, without a payload.
+func buildPackage(hdr []byte) []byte {
+	var pkg bytes.Buffer
+	pkg.Write(leadMagic)
+	pkg.Write(make([]byte, leadSize-len(leadMagic)))
+	sig := buildHeader(tag{tag: 1000, typ: typeInt32, value: []uint32{42}})
+	pkg.Write(sig)
+	pkg.Write(make([]byte, (8-len(sig)%8)%8))
+	pkg.Write(hdr)
+	return pkg.Bytes()
+}
+
+func TestReadFiles(t *testing.T) {
+	const (
+		dir  = 0o040755
+		exe  = 0o100755
+		file = 0o100644
+	)
+	fileList := buildHeader(
+		tag{tagDirnames, typeStringArray, []string{"/usr/bin/", "/etc/"}},
+		tag{tagBasenames, typeStringArray, []string{"app", "app.conf", "app.log"}},
+		tag{tagDirIndexes, typeInt32, []uint32{0, 1, 1}},
+		tag{tagFileModes, typeInt16, []uint16{exe, file, file}},
+		tag{tagFileFlags, typeInt32, []uint32{0, 0, fileFlagGhost}},
+	)
+	valid := buildPackage(fileList)
+
+	tests := []struct {
+		name    string
+		pkg     []byte
+		want    []File
+		wantErr string
+	}{
+		{
+			name: "valid",
+			pkg:  valid,
+			want: []File{{Path: "/usr/bin/app", Mode: exe}, {Path: "/etc/app.conf", Mode: file}, {Path: "/etc/app.log", Mode: file, Ghost: true}},
+		},
+		{
+			name: "old file names",
+			pkg: buildPackage(buildHeader(
+				tag{tagOldFilenames, typeStringArray, []string{"/usr/bin", "/usr/bin/app"}},
+				tag{tagFileModes, typeInt16, []uint16{dir, exe}},
+			)),
+			want: []File{{Path: "/usr/bin", Mode: dir}, {Path: "/usr/bin/app", Mode: exe}},
+		},
+		{
+			name: "no files",
+			pkg:  buildPackage(buildHeader(tag{1000, typeInt32, []uint32{1}})),
+			want: []File{},
+		},
+		{name: "empty", pkg: nil, wantErr: "failed to read lead"},
+		{name: "truncated lead", pkg: valid[:50], wantErr: "failed to read lead"},
+		{name: "bad lead magic", pkg: append([]byte{0, 0, 0, 0}, valid[4:]...), wantErr: "not an RPM package"},
+		{name: "truncated signature header", pkg: valid[:leadSize+20], wantErr: "failed to read signature header"},
+		{name: "truncated header intro", pkg: valid[:len(valid)-len(fileList)+8], wantErr: "failed to read header"},
+		{name: "truncated header data", pkg: valid[:len(valid)-1], wantErr: "failed to read header"},
+		{
+			name:    "bad signature header magic",
+			pkg:     slices.Concat(valid[:leadSize], []byte{0, 0, 0, 0}, valid[leadSize+4:]),
+			wantErr: "failed to read signature header: bad header magic",
+		},
+		{
+			name:    "bad header magic",
+			pkg:     slices.Concat(valid[:len(valid)-len(fileList)], []byte{0, 0, 0, 0}, fileList[4:]),
+			wantErr: "failed to read header: bad header magic",
+		},
+		{
+			name: "header too large",
+			pkg: slices.Concat(valid[:len(valid)-len(fileList)], headerMagic, make([]byte, 4),
+				[]byte{0, 0, 0, 1}, []byte{0x7f, 0xff, 0xff, 0xff}),
+			wantErr: "header too large",
+		},
+		{
+			name: "wrong tag type",
+			pkg: buildPackage(buildHeader(
+				tag{tagOldFilenames, typeStringArray, []string{"/usr/bin/app"}},
+				tag{tagFileModes, typeInt32, []uint32{exe}},
+			)),
+			wantErr: "tag 1030 has type 4, expected 3",
+		},
+		{
+			name: "inconsistent file list",
+			pkg: buildPackage(buildHeader(
+				tag{tagOldFilenames, typeStringArray, []string{"/usr/bin/app", "/usr/bin/other"}},
+				tag{tagFileModes, typeInt16, []uint16{exe}},
+			)),
+			wantErr: "inconsistent file list",
+		},
+		{
+			name: "directory index out of range",
+			pkg: buildPackage(buildHeader(
+				tag{tagDirnames, typeStringArray, []string{"/usr/bin/"}},
+				tag{tagBasenames, typeStringArray, []string{"app"}},
+				tag{tagDirIndexes, typeInt32, []uint32{1}},
+				tag{tagFileModes, typeInt16, []uint16{exe}},
+			)),
+			wantErr: "inconsistent file list",
+		},
+		{
+			name:    "more strings than stored",
+			pkg:     buildPackage(withCount(buildHeader(tag{tagOldFilenames, typeStringArray, []string{"/usr/bin/app", "/usr/bin/other"}}), 0, 3)),
+			wantErr: "tag 1027 is out of bounds",
+		},
+		{
+			name: "integers out of bounds",
+			pkg: buildPackage(withCount(buildHeader(
+				tag{tagFileModes, typeInt16, []uint16{exe}},
+				tag{tagOldFilenames, typeStringArray, []string{"/usr/bin/app"}},
+			), 0, 100)),
+			wantErr: "tag 1030 is out of bounds",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "test.rpm")
+			if err := os.WriteFile(path, tt.pkg, 0o644); err != nil {
+				t.Fatal(err)
+			}
+			files, err := ReadFiles(path)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("ReadFiles() = %v, %v, want error containing %q", files, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ReadFiles(): %v", err)
+			}
+			if !slices.Equal(files, tt.want) {
+				t.Errorf("ReadFiles() = %+v, want %+v", files, tt.want)
+			}
+		})
+	}
+}
+
+func TestFileIsExecutable(t *testing.T) {
+	for mode, want := range map[uint16]bool{
+		0o100755: true,
+		0o100700: true,
+		0o100001: true,
+		0o100644: false,
+		0o040755: false, // directory
+		0o120777: false, // symlink
+	} {
+		if got := (File{Mode: mode}).IsExecutable(); got != want {
+			t.Errorf("File{Mode: %o}.IsExecutable() = %v, want %v", mode, got, want)
+		}
+	}
+}
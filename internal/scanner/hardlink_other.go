@@ -0,0 +1,11 @@
+//go:build !unix
+
+package scanner
+
+import "io/fs"
+
+// hardlinkOf never reports a hard link, as inode numbers are only available
+// on Unix systems, so that hard-linked files are validated like other files.
+func hardlinkOf(links map[fileID]string, fi fs.FileInfo, path string) (string, bool) {
+	return "", false
+}
@@ -0,0 +1,25 @@
+//go:build unix
+
+package scanner
+
+import (
+	"io/fs"
+	"syscall"
+)
+
+// hardlinkOf returns the path of the first file seen with the same device and
+// inode number as fi, and true if there is one. Otherwise, it records fi under
+// path in links. Files without hard links, or whose file system doesn't report
+// inode numbers, are never reported.
+func hardlinkOf(links map[fileID]string, fi fs.FileInfo, path string) (string, bool) {
+	st, ok := fi.Sys().(*syscall.Stat_t)
+	if !ok || st.Nlink < 2 {
+		return "", false
+	}
+	id := fileID{dev: uint64(st.Dev), ino: st.Ino}
+	if first, ok := links[id]; ok {
+		return first, true
+	}
+	links[id] = path
+	return "", false
+}
@@ -0,0 +1,6 @@
+package scanner
+
+// pseudoDirs are the top-level directories of a running system that only
+// hold kernel interfaces, devices, and runtime state, which are skipped when
+// the host's root directory is scanned.
+var pseudoDirs = []string{"proc", "sys", "dev", "run"}
@@ -0,0 +1,77 @@
+package scanner
+
+import (
+	"io/fs"
+	"os"
+	"syscall"
+)
+
+// nonDiskFileSystems maps the statfs(2) magic numbers of file systems that
+// don't hold an installed system's files to their names: virtual file
+// systems, whose contents are generated by the kernel or held in memory, and
+// network and overlay file systems, which expose other systems' or
+// containers' files.
+var nonDiskFileSystems = map[uint32]string{
+	0x9fa0:     "proc",
+	0x62656572: "sysfs",
+	0x1cd1:     "devpts",
+	0x01021994: "tmpfs",
+	0x27e0eb:   "cgroup",
+	0x63677270: "cgroup2",
+	0x64626720: "debugfs",
+	0x74726163: "tracefs",
+	0x73636673: "securityfs",
+	0x6165676c: "pstore",
+	0xcafe4a11: "bpf",
+	0x19800202: "mqueue",
+	0x62656570: "configfs",
+	0x958458f6: "hugetlbfs",
+	0x0187:     "autofs",
+	0x65735543: "fusectl",
+	0x42494e4d: "binfmt_misc",
+	0xf97cff8c: "selinuxfs",
+	0xde5e81e4: "efivarfs",
+	0x6e736673: "nsfs",
+	0x6969:     "nfs",
+	0x517b:     "smb",
+	0xff534d42: "cifs",
+	0xfe534d42: "smb2",
+	0x00c36400: "ceph",
+	0x794c7630: "overlay",
+}
+
+// deviceOf returns the number of the device the directory entry resides on,
+// and false if its file system doesn't report one.
+func deviceOf(file fs.DirEntry) (uint64, bool) {
+	fi, err := file.Info()
+	if err != nil {
+		return 0, false
+	}
+	st, ok := fi.Sys().(*syscall.Stat_t)
+	if !ok {
+		return 0, false
+	}
+	return uint64(st.Dev), true
+}
+
+// nonDiskFileSystem returns the name of the file system mounted at the
+// directory name within fsys and true if it isn't an on-disk file system,
+// see nonDiskFileSystems. Directories that can't be examined are assumed to
+// be on disk.
+func nonDiskFileSystem(fsys fs.FS, name string) (string, bool) {
+	f, err := fsys.Open(name)
+	if err != nil {
+		return "", false
+	}
+	defer f.Close()
+	osf, ok := f.(*os.File)
+	if !ok {
+		return "", false
+	}
+	var st syscall.Statfs_t
+	if err := syscall.Fstatfs(int(osf.Fd()), &st); err != nil {
+		return "", false
+	}
+	fsType, ok := nonDiskFileSystems[uint32(st.Type)]
+	return fsType, ok
+}
@@ -0,0 +1,17 @@
+//go:build !linux
+
+package scanner
+
+import "io/fs"
+
+// deviceOf reports no device, so that on systems other than Linux mounts
+// aren't detected and the scan descends into them.
+func deviceOf(file fs.DirEntry) (uint64, bool) {
+	return 0, false
+}
+
+// nonDiskFileSystem reports every file system as on disk, as the statfs(2)
+// magic numbers of nonDiskFileSystems are specific to Linux.
+func nonDiskFileSystem(fsys fs.FS, name string) (string, bool) {
+	return "", false
+}
@@ -2,22 +2,210 @@ package scanner
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
 	"io/fs"
-	"path/filepath"
+	"os"
+	"path"
+	"slices"
 	"strings"
+	"sync"
 
+	"golang.org/x/sync/errgroup"
+
+	"github.com/flightctl/fips-validator/internal/archive"
+	"github.com/flightctl/fips-validator/internal/rootfs"
 	"github.com/flightctl/fips-validator/internal/validation"
+	"github.com/flightctl/fips-validator/pkg/elfinfo"
 )
 
-func ScanDirTree(ctx context.Context, rootPath string, debugFunc func(string, ...interface{})) bool {
-	allValid := true
+// Options controls how ScanDirTree scans a directory tree.
+type Options struct {
+	// MaxArchiveDepth is the maximum nesting level up to which archives
+	// (.tar, .tar.gz, .zip) found during the scan are extracted and their
+	// contents validated. Zero disables scanning archives.
+	MaxArchiveDepth int
+	// ExtractLimit caps the total number of bytes extracted from nested
+	// archives. It may be shared with other extractions of the same target.
+	ExtractLimit *archive.Limit
+	// Policy configures the checks performed on each binary.
+	Policy *validation.Policy
+	// SharedObjects also validates shared libraries (files named *.so or
+	// *.so.*), whether or not they are executable.
+	SharedObjects bool
+	// AllELFFiles validates all ELF files, whether or not they are
+	// executable, for trees whose permission bits were lost, e.g. extracted
+	// from a zip archive without them.
+	AllELFFiles bool
+	// Jobs is the number of binaries validated concurrently. Values below
+	// one validate one binary at a time.
+	Jobs int
+	// MaxFailures stops the scan once that many binaries failed
+	// validation. Zero means unlimited.
+	MaxFailures int
+	// Arch restricts the scan to binaries of some architectures. Binaries
+	// of other architectures are skipped.
+	Arch ArchFilter
+	// MaxFileSize skips executables larger than that many bytes, which are
+	// slow to parse, e.g. self-extracting installers. Zero means unlimited.
+	MaxFileSize int64
+	// Hash records the SHA-256 digest of each file in its result, also for
+	// skipped files, e.g. to compare the target against a manifest later.
+	Hash bool
+	// LanguagePackages also extracts the language packages found during
+	// the scan, e.g. Python wheels, up to MaxArchiveDepth like archives,
+	// and validates the shared libraries they bundle even if
+	// SharedObjects isn't set.
+	LanguagePackages bool
+	// OneFileSystem doesn't descend into directories on other file systems
+	// than the scanned directory, like find -xdev. Mounts of virtual,
+	// network, and overlay file systems are always skipped.
+	OneFileSystem bool
+	// Files, if not nil, restricts the scan to the files at these paths,
+	// which are slash-separated and relative to the scanned tree, e.g.
+	// "usr/bin/app". Directories that contain none of them aren't
+	// descended into. Nested archives among them are scanned in full.
+	Files map[string]bool
+}
+
+// ArchFilter selects binaries by architecture, given in Go's naming (GOARCH),
+// e.g. "amd64". An empty filter selects all binaries.
+type ArchFilter struct {
+	// Only, if not empty, lists the only architectures that are validated.
+	Only []string
+	// Exclude lists architectures that aren't validated.
+	Exclude []string
+}
+
+func (f *ArchFilter) empty() bool {
+	return len(f.Only) == 0 && len(f.Exclude) == 0
+}
+
+func (f *ArchFilter) allows(arch string) bool {
+	if len(f.Only) > 0 && !slices.Contains(f.Only, arch) {
+		return false
+	}
+	return !slices.Contains(f.Exclude, arch)
+}
+
+// ErrMaxFailuresReached is returned along with the partial results by
+// ScanDirTree if the scan was stopped early because Options.MaxFailures
+// binaries failed validation.
+var ErrMaxFailuresReached = errors.New("stopped early after reaching the maximum number of failures")
+
+// ScanDirTree validates all executables in the directory tree at rootPath.
+// resultFunc, if not nil, is called with each result as soon as it is
+// available. Calls to resultFunc are serialized, but with more than one job,
+// results arrive in no particular order.
+//
+// Mounts of file systems that don't hold installed files, e.g. /proc in a
+// chroot, aren't descended into, see Options.OneFileSystem. If rootPath is the
+// host's root directory, /proc, /sys, /dev, and /run are skipped, too.
+//
+// Binaries found inside nested archives are reported with the archive's path
+// and the path inside the archive separated by "!", e.g.
+// "/opt/app.tar!/usr/bin/foo".
+func ScanDirTree(ctx context.Context, rootPath string, opts Options, debugFunc func(string, ...interface{}), resultFunc func(*validation.BinaryResult)) ([]*validation.BinaryResult, error) {
+	return scanFS(ctx, rootfs.FS(rootPath), rootPath, opts, debugFunc, resultFunc)
+}
+
+// ScanFS is like ScanDirTree, but validates all executables in fsys. All
+// files, including the libraries that binaries load, are read from fsys, so
+// the scan can be confined to a file system that guards against symlinks
+// leading outside of it, e.g. one returned by rootfs.FS. Nested archives are
+// still extracted to temporary directories.
+func ScanFS(ctx context.Context, fsys fs.FS, opts Options, debugFunc func(string, ...interface{}), resultFunc func(*validation.BinaryResult)) ([]*validation.BinaryResult, error) {
+	return scanFS(ctx, fsys, "file system", opts, debugFunc, resultFunc)
+}
+
+func scanFS(ctx context.Context, fsys fs.FS, name string, opts Options, debugFunc func(string, ...interface{}), resultFunc func(*validation.BinaryResult)) ([]*validation.BinaryResult, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	jobs := opts.Jobs
+	if jobs < 1 {
+		jobs = 1
+	}
+	g := &errgroup.Group{}
+	g.SetLimit(jobs)
+
+	s := &dirScanner{opts: opts, debugFunc: debugFunc, resultFunc: resultFunc, workers: g, cancel: cancel}
+	if opts.Files != nil {
+		s.fileDirs = map[string]bool{}
+		for name := range opts.Files {
+			for dir := path.Dir(name); dir != "." && dir != "/"; dir = path.Dir(dir) {
+				s.fileDirs[dir] = true
+			}
+		}
+	}
+	// Only ScanDirTree names the tree by its path.
+	s.hostRoot = name == "/"
+	err := s.scan(ctx, fsys, name, "", 0, opts.SharedObjects)
+	// Workers never fail, errors are only returned by the walk.
+	_ = g.Wait()
+	if err != nil {
+		return s.results, err
+	}
+	if s.stoppedEarly {
+		return s.results, ErrMaxFailuresReached
+	}
+	return s.results, nil
+}
+
+type dirScanner struct {
+	opts       Options
+	debugFunc  func(string, ...interface{})
+	resultFunc func(*validation.BinaryResult)
+	workers    *errgroup.Group
+	cancel     context.CancelFunc
+	// hostRoot is set if the host's root directory is scanned.
+	hostRoot bool
+	// fileDirs are the directories containing Options.Files, if set.
+	fileDirs map[string]bool
+
+	mu           sync.Mutex
+	results      []*validation.BinaryResult
+	failures     int
+	stoppedEarly bool
+}
 
-	err := filepath.WalkDir(rootPath, func(path string, file fs.DirEntry, err error) error {
+// scan validates the executables in fsys, and its shared libraries if
+// sharedObjects is set. name identifies fsys in errors.
+func (s *dirScanner) scan(ctx context.Context, fsys fs.FS, name string, prefix string, depth int, sharedObjects bool) error {
+	// Archives are extracted to temporary directories that are removed once
+	// scan returns, so wait for all binaries of this tree to be validated.
+	var pending sync.WaitGroup
+	defer pending.Wait()
+	links := map[fileID]string{}
+	var rootDev uint64
+	var haveRootDev bool
+
+	err := fs.WalkDir(fsys, ".", func(path string, file fs.DirEntry, err error) error {
+		if ctx.Err() != nil {
+			return fs.SkipAll
+		}
 		if err != nil {
 			return err
 		}
 
 		if file.IsDir() {
+			if path == "." {
+				rootDev, haveRootDev = deviceOf(file)
+				return nil
+			}
+			if depth == 0 && s.hostRoot && slices.Contains(pseudoDirs, path) {
+				s.debugFunc("skipping /%s", path)
+				return fs.SkipDir
+			}
+			if depth == 0 && s.fileDirs != nil && !s.fileDirs[path] {
+				return fs.SkipDir
+			}
+			if dev, ok := deviceOf(file); haveRootDev && ok && dev != rootDev {
+				return s.enterMount(fsys, path, prefix)
+			}
 			return nil
 		}
 		// Skip over all non-regular files. This is a very fast check
@@ -25,30 +213,186 @@ func ScanDirTree(ctx context.Context, rootPath string, debugFunc func(string, ..
 		if !file.Type().IsRegular() {
 			return nil
 		}
-		// Check if the file has any x bits set. This is a slower check
-		// as it calls lstat(2) under the hood.
+		if depth == 0 && s.opts.Files != nil && !s.opts.Files[path] {
+			return nil
+		}
+		innerPath := "/" + path
+		if depth < s.opts.MaxArchiveDepth && archive.IsArchive(file.Name()) {
+			return s.scanArchive(ctx, fsys, path, prefix+innerPath+"!", depth+1, sharedObjects)
+		}
+		if depth < s.opts.MaxArchiveDepth && s.opts.LanguagePackages && archive.IsLanguagePackage(file.Name()) {
+			return s.scanArchive(ctx, fsys, path, prefix+innerPath+"!", depth+1, true)
+		}
+		// Check if the file has any x bits set. This is a slower check as
+		// it calls lstat(2) under the hood.
 		fi, err := file.Info()
 		if err != nil {
 			return err
 		}
-		if fi.Mode().Perm()&0o111 == 0 {
+		// The crypto shims of managed runtimes are always validated.
+		sharedObject := sharedObjects && isSharedObjectName(file.Name()) || validation.RuntimeCryptoShim(file.Name()) != ""
+		if !sharedObject && fi.Mode().Perm()&0o111 == 0 && (!s.opts.AllELFFiles || !isELF(fsys, path)) {
 			// Not an executable.
 			return nil
 		}
-
-		innerPath := stripMountPath(rootPath, path)
-		if !validation.ValidateBinary(ctx, rootPath, innerPath, debugFunc) {
-			allValid = false
+		if s.opts.MaxFileSize > 0 && fi.Size() > s.opts.MaxFileSize {
+			s.debugFunc("skipping %s%s (%d bytes)", prefix, innerPath, fi.Size())
+			s.finish(fsys, path, &validation.BinaryResult{Path: prefix + innerPath, Status: validation.StatusSkipped, Reason: validation.SkipTooLarge, Detail: fmt.Sprintf("%d bytes", fi.Size())})
+			return nil
+		}
+		if first, ok := hardlinkOf(links, fi, prefix+innerPath); ok {
+			s.debugFunc("skipping %s%s (hardlink of %s)", prefix, innerPath, first)
+			s.finish(fsys, path, &validation.BinaryResult{Path: prefix + innerPath, Status: validation.StatusSkipped, Reason: validation.SkipHardlink, Detail: first})
+			return nil
 		}
+
+		pending.Add(1)
+		s.workers.Go(func() error {
+			defer pending.Done()
+			if ctx.Err() != nil {
+				return nil
+			}
+			if arch, ok := s.excludedArch(fsys, path); ok {
+				s.debugFunc("skipping %s%s (architecture %s)", prefix, innerPath, arch)
+				s.finish(fsys, path, &validation.BinaryResult{Path: prefix + innerPath, Status: validation.StatusSkipped, Reason: validation.SkipArch, Detail: "linux/" + arch})
+				return nil
+			}
+			result := validation.ValidateBinaryFS(ctx, fsys, innerPath, sharedObject, s.opts.Policy, s.debugFunc)
+			result.Path = prefix + result.Path
+			s.finish(fsys, path, result)
+			return nil
+		})
 		return nil
 	})
+	if err != nil {
+		return fmt.Errorf("failed to scan %s: %v", name, err)
+	}
+	return nil
+}
+
+// enterMount returns fs.SkipDir if the walk shouldn't descend into the
+// directory at path within fsys, which is on another file system than the
+// scanned tree.
+func (s *dirScanner) enterMount(fsys fs.FS, path, prefix string) error {
+	if s.opts.OneFileSystem {
+		s.debugFunc("skipping %s/%s (on another file system)", prefix, path)
+		return fs.SkipDir
+	}
+	if fsType, ok := nonDiskFileSystem(fsys, path); ok {
+		s.debugFunc("skipping %s/%s (%s mount)", prefix, path, fsType)
+		return fs.SkipDir
+	}
+	return nil
+}
+
+// fileID identifies a file by device and inode number.
+type fileID struct {
+	dev, ino uint64
+}
+
+// excludedArch returns the architecture of the ELF file at name within fsys and
+// true if Options.Arch excludes it. Files that aren't ELF files are left to the
+// validation to report.
+func (s *dirScanner) excludedArch(fsys fs.FS, name string) (string, bool) {
+	if s.opts.Arch.empty() {
+		return "", false
+	}
+	f, err := fsys.Open(name)
+	if err != nil {
+		return "", false
+	}
+	defer f.Close()
+	ra, ok := f.(io.ReaderAt)
+	if !ok {
+		return "", false
+	}
+	arch, err := elfinfo.ReadArch(ra)
+	if err != nil {
+		return "", false
+	}
+	return arch, !s.opts.Arch.allows(arch)
+}
+
+// isELF returns whether the file at name within fsys is an ELF file.
+func isELF(fsys fs.FS, name string) bool {
+	f, err := fsys.Open(name)
 	if err != nil {
 		return false
 	}
+	defer f.Close()
+	format, err := elfinfo.DetectFormatFrom(f)
+	return err == nil && format == elfinfo.FormatELF
+}
+
+// finish completes the result for the file at name within fsys and records
+// it.
+func (s *dirScanner) finish(fsys fs.FS, name string, result *validation.BinaryResult) {
+	if s.opts.Hash {
+		digest, err := hashFile(fsys, name)
+		if err != nil {
+			s.debugFunc("failed to hash %s: %v", result.Path, err)
+		}
+		result.SHA256 = digest
+	}
+	s.record(result)
+}
+
+// hashFile returns the hex-encoded SHA-256 digest of the file at name within
+// fsys.
+func hashFile(fsys fs.FS, name string) (string, error) {
+	f, err := fsys.Open(name)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// record adds a result and stops the scan once the maximum number of failures
+// is reached. Results that arrive after that are dropped.
+func (s *dirScanner) record(result *validation.BinaryResult) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.stoppedEarly {
+		return
+	}
+	if s.resultFunc != nil {
+		s.resultFunc(result)
+	}
+	s.results = append(s.results, result)
+	if result.Status != validation.StatusFailed {
+		return
+	}
+	s.failures++
+	if s.opts.MaxFailures > 0 && s.failures >= s.opts.MaxFailures {
+		s.stoppedEarly = true
+		s.cancel()
+	}
+}
 
-	return allValid
+// scanArchive extracts the archive at path within fsys to a temporary
+// directory and scans its contents.
+func (s *dirScanner) scanArchive(ctx context.Context, fsys fs.FS, path string, prefix string, depth int, sharedObjects bool) error {
+	tempDir, err := os.MkdirTemp("", "fips-validator-archive-")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	s.debugFunc("extracting archive %s to %s", path, tempDir)
+	if err := archive.ExtractFS(fsys, path, tempDir, s.opts.ExtractLimit); err != nil {
+		return fmt.Errorf("failed to extract archive %s: %v", strings.TrimSuffix(prefix, "!"), err)
+	}
+	return s.scan(ctx, rootfs.FS(tempDir), tempDir, prefix, depth, sharedObjects)
 }
 
-func stripMountPath(mountPath, path string) string {
-	return strings.TrimPrefix(path, mountPath)
+// isSharedObjectName returns whether name is the file name of a shared
+// library, e.g. "libfoo.so" or "libfoo.so.1.2".
+func isSharedObjectName(name string) bool {
+	return strings.HasSuffix(name, ".so") || strings.Contains(name, ".so.")
 }
@@ -0,0 +1,83 @@
+package scanner
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/flightctl/fips-validator/internal/validation"
+)
+
+// failingTree returns a directory tree with n copies of a Go binary that uses
+// crypto without the golang-fips OpenSSL bindings, so that each of them fails
+// validation.
+func failingTree(t *testing.T, n int) string {
+	t.Helper()
+	goTool, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("go not installed")
+	}
+	src := filepath.Join(t.TempDir(), "main.go")
+	if err := os.WriteFile(src, []byte("package main\n\nimport \"crypto/sha256\"\n\nfunc main() { println(sha256.Sum256(nil)[0]) }\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	exe := filepath.Join(t.TempDir(), "app")
+	cmd := exec.Command(goTool, "build", "-o", exe, src)
+	cmd.Env = append(os.Environ(), "CGO_ENABLED=0")
+	if output, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("go build: %v\n%s", err, output)
+	}
+	data, err := os.ReadFile(exe)
+	if err != nil {
+		t.Fatal(err)
+	}
+	root := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(root, "usr/bin"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < n; i++ {
+		if err := os.WriteFile(filepath.Join(root, "usr/bin", fmt.Sprintf("app%d", i)), data, 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return root
+}
+
+func TestScanDirTreeMaxFailures(t *testing.T) {
+	const binaries = 10
+	root := failingTree(t, binaries)
+	debugf := func(string, ...interface{}) {}
+
+	results, err := ScanDirTree(context.Background(), root, Options{Policy: validation.DefaultPolicy(), Jobs: 1}, debugf, nil)
+	if err != nil {
+		t.Fatalf("ScanDirTree without --max-failures: %v", err)
+	}
+	if len(results) != binaries {
+		t.Fatalf("ScanDirTree without --max-failures validated %d binaries, want %d", len(results), binaries)
+	}
+	for _, r := range results {
+		if r.Status != validation.StatusFailed {
+			t.Fatalf("%s: status %s, want failed", r.Path, r.Status)
+		}
+	}
+
+	for _, jobs := range []int{1, 4} {
+		t.Run(fmt.Sprintf("jobs %d", jobs), func(t *testing.T) {
+			const maxFailures = 3
+			var reported int
+			opts := Options{Policy: validation.DefaultPolicy(), Jobs: jobs, MaxFailures: maxFailures}
+			results, err := ScanDirTree(context.Background(), root, opts, debugf, func(*validation.BinaryResult) { reported++ })
+			if err != ErrMaxFailuresReached {
+				t.Fatalf("ScanDirTree error = %v, want ErrMaxFailuresReached", err)
+			}
+			// Results that arrive after the cutoff are dropped, so exactly
+			// maxFailures binaries are reported.
+			if len(results) != maxFailures || reported != maxFailures {
+				t.Errorf("ScanDirTree returned %d results and reported %d, want %d", len(results), reported, maxFailures)
+			}
+		})
+	}
+}
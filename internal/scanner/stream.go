@@ -0,0 +1,42 @@
+package scanner
+
+import (
+	"context"
+	"io/fs"
+
+	"github.com/flightctl/fips-validator/internal/rootfs"
+	"github.com/flightctl/fips-validator/internal/validation"
+)
+
+// StreamDirTree is like ScanDirTree, but sends each result on the returned
+// results channel as soon as it is available, e.g. so that a service can
+// report progress while a large image is validated. The results channel is
+// closed when the scan is done, after which the error channel receives the
+// scan's error or nil. Callers must receive all results, or cancel ctx to
+// abandon the scan.
+func StreamDirTree(ctx context.Context, rootPath string, opts Options, debugFunc func(string, ...interface{})) (<-chan *validation.BinaryResult, <-chan error) {
+	return stream(ctx, rootfs.FS(rootPath), rootPath, opts, debugFunc)
+}
+
+// StreamFS is like StreamDirTree, but validates all executables in fsys, like
+// ScanFS.
+func StreamFS(ctx context.Context, fsys fs.FS, opts Options, debugFunc func(string, ...interface{})) (<-chan *validation.BinaryResult, <-chan error) {
+	return stream(ctx, fsys, "file system", opts, debugFunc)
+}
+
+func stream(ctx context.Context, fsys fs.FS, name string, opts Options, debugFunc func(string, ...interface{})) (<-chan *validation.BinaryResult, <-chan error) {
+	results := make(chan *validation.BinaryResult)
+	errc := make(chan error, 1)
+	go func() {
+		defer close(errc)
+		_, err := scanFS(ctx, fsys, name, opts, debugFunc, func(r *validation.BinaryResult) {
+			select {
+			case results <- r:
+			case <-ctx.Done():
+			}
+		})
+		close(results)
+		errc <- err
+	}()
+	return results, errc
+}
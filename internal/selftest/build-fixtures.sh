@@ -0,0 +1,30 @@
+#!/bin/sh
+# Builds the fixtures in rootfs from the sources in src with GCC for x86-64.
+# Run "go generate ./internal/selftest" after changing the sources, and update
+# the fixtures' expected verdicts in selftest.go if they change.
+set -eu
+cd "$(dirname "$0")"
+
+cflags="-Os -fno-asynchronous-unwind-tables"
+tmp=$(mktemp -d)
+trap 'rm -rf "$tmp"' EXIT
+rm -rf rootfs
+mkdir -p rootfs/usr/bin rootfs/usr/lib64 rootfs/usr/lib/nonfips rootfs/usr/lib/importfips
+
+gcc $cflags -shared -fPIC -DFIPS -Wl,-soname,libcrypto.so.3 -o rootfs/usr/lib64/libcrypto.so.3 src/libcrypto.c
+gcc $cflags -shared -fPIC -Wl,-soname,libcrypto.so.3 -o rootfs/usr/lib/nonfips/libcrypto.so.3 src/libcrypto.c
+gcc $cflags -c -o "$tmp/libcrypto.o" src/libcrypto.c
+ar rcs "$tmp/libcrypto.a" "$tmp/libcrypto.o"
+gcc $cflags -shared -fPIC -DIMPORT_FIPS -Wl,-soname,libcrypto.so.3 -o rootfs/usr/lib/importfips/libcrypto.so.3 src/libcrypto.c
+
+gcc $cflags -DDLOPEN -o rootfs/usr/bin/compliant src/gobinary.c
+gcc $cflags -DDLOPEN -static -nostdlib -Wl,-e,main -o rootfs/usr/bin/static src/gobinary.c
+gcc $cflags -o rootfs/usr/bin/missing-symbol src/gobinary.c
+gcc $cflags -DDLOPEN_REF -o rootfs/usr/bin/undefined-symbol src/gobinary.c
+gcc $cflags -o rootfs/usr/bin/nonfips-libcrypto src/cbinary.c \
+	-Lrootfs/usr/lib/nonfips -lcrypto -Wl,-rpath,'$ORIGIN/../lib/nonfips' -Wl,--enable-new-dtags
+gcc $cflags -o rootfs/usr/bin/imported-fips-symbol src/cbinary.c \
+	-Lrootfs/usr/lib/importfips -lcrypto -Wl,-rpath,'$ORIGIN/../lib/importfips' -Wl,--enable-new-dtags \
+	-Wl,--allow-shlib-undefined
+gcc $cflags -o rootfs/usr/bin/static-libcrypto src/cbinary.c "$tmp/libcrypto.a"
+gcc $cflags -s -o rootfs/usr/bin/static-libcrypto-stripped src/cbinary.c "$tmp/libcrypto.a"
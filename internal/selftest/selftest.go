@@ -0,0 +1,89 @@
+// Package selftest validates embedded fixture binaries whose verdicts are
+// known, to confirm that a build of the validator works as intended, e.g.
+// after packaging it.
+package selftest
+
+import (
+	"context"
+	"embed"
+	"io/fs"
+	"slices"
+	"sort"
+
+	"github.com/flightctl/fips-validator/internal/validation"
+)
+
+//go:generate sh build-fixtures.sh
+
+// fixtures is a minimal root filesystem with the fixture binaries and the
+// libraries they load. The binaries are built from the sources in src by
+// build-fixtures.sh; the Go binaries among them are C programs carrying the
+// build info and symbols that the Go checks examine.
+//
+//go:embed rootfs
+var fixtures embed.FS
+
+// Fixture is a binary with a known verdict.
+type Fixture struct {
+	Path        string
+	Description string
+	// Failures are the IDs of the checks the binary fails, sorted, or
+	// empty if it passes validation.
+	Failures []string
+}
+
+// Fixtures are the fixture binaries in the order they are validated.
+var Fixtures = []Fixture{
+	{Path: "/usr/bin/compliant", Description: "FIPS-capable Go binary"},
+	{Path: "/usr/bin/static", Description: "statically linked Go binary", Failures: []string{validation.CheckDynamicLinking}},
+	{Path: "/usr/bin/missing-symbol", Description: "Go binary without golang-fips/openssl", Failures: []string{validation.CheckGoSymbols}},
+	{Path: "/usr/bin/undefined-symbol", Description: "Go binary only referencing golang-fips/openssl", Failures: []string{validation.CheckGoSymbols}},
+	{Path: "/usr/bin/nonfips-libcrypto", Description: "C binary loading a non-FIPS libcrypto", Failures: []string{validation.CheckOpenSSLLinkage}},
+	{Path: "/usr/bin/static-libcrypto", Description: "C binary statically linked against libcrypto.a", Failures: []string{validation.CheckStaticOpenSSL}},
+	{Path: "/usr/bin/static-libcrypto-stripped", Description: "stripped C binary statically linked against libcrypto.a", Failures: []string{validation.CheckStaticOpenSSL}},
+	{Path: "/usr/bin/imported-fips-symbol", Description: "C binary loading a libcrypto only importing FIPS_mode", Failures: []string{validation.CheckOpenSSLLinkage}},
+}
+
+// Result is the outcome of validating a fixture.
+type Result struct {
+	Fixture
+	// Status and FailedChecks, sorted, are the verdict the validator
+	// reached.
+	Status       validation.Status
+	FailedChecks []string
+	Binary       *validation.BinaryResult
+}
+
+// OK returns whether the validator reached the fixture's known verdict.
+func (r *Result) OK() bool {
+	want := validation.StatusPassed
+	if len(r.Failures) > 0 {
+		want = validation.StatusFailed
+	}
+	return r.Status == want && slices.Equal(r.FailedChecks, r.Failures)
+}
+
+// Run validates the fixtures with the default policy and rules, so that the
+// verdicts don't depend on the configuration, and returns their results in the
+// order of Fixtures.
+func Run(ctx context.Context, debugFunc func(string, ...interface{})) []*Result {
+	fsys, err := fs.Sub(fixtures, "rootfs")
+	if err != nil {
+		panic(err)
+	}
+	policy := validation.DefaultPolicy()
+
+	var results []*Result
+	for _, f := range Fixtures {
+		br := validation.ValidateBinaryFS(ctx, fsys, f.Path, false, policy, debugFunc)
+		r := &Result{Fixture: f, Status: br.Status, Binary: br}
+		for _, finding := range br.Findings {
+			if finding.Severity == validation.SeverityError && !slices.Contains(r.FailedChecks, finding.Check) {
+				r.FailedChecks = append(r.FailedChecks, finding.Check)
+			}
+		}
+		sort.Strings(r.FailedChecks)
+		results = append(results, r)
+	}
+	return results
+}
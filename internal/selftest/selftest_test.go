@@ -0,0 +1,43 @@
+package selftest
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestRun(t *testing.T) {
+	for _, r := range Run(context.Background(), t.Logf) {
+		if !r.OK() {
+			t.Errorf("%s (%s): %s, failed checks %v, want failures %v", r.Path, r.Description, r.Status, r.FailedChecks, r.Failures)
+		}
+	}
+}
+
+// TestStaticVerdicts checks that binaries statically linked against
+// libcrypto.a are reported as statically linking OpenSSL, unlike statically
+// linked binaries.
+func TestStaticVerdicts(t *testing.T) {
+	want := map[string]string{
+		"/usr/bin/static":                    "statically linked",
+		"/usr/bin/static-libcrypto":          "statically links OpenSSL (defines ",
+		"/usr/bin/static-libcrypto-stripped": "statically links OpenSSL (contains \"OpenSSL 3.0.7 1 Nov 2022\")",
+	}
+	for _, r := range Run(context.Background(), t.Logf) {
+		message, ok := want[r.Path]
+		if !ok {
+			continue
+		}
+		delete(want, r.Path)
+		var messages []string
+		for _, f := range r.Binary.Findings {
+			messages = append(messages, f.Message)
+		}
+		if len(messages) != 1 || !strings.HasPrefix(messages[0], message) {
+			t.Errorf("%s: findings %q, want one starting with %q", r.Path, messages, message)
+		}
+	}
+	for path := range want {
+		t.Errorf("fixture %s not validated", path)
+	}
+}
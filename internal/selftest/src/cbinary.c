@@ -0,0 +1,4 @@
+/* A C binary that links libcrypto. */
+int OPENSSL_init_crypto(unsigned long opts, const void *settings);
+
+int main(void) { return !OPENSSL_init_crypto(0, 0); }
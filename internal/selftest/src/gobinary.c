@@ -0,0 +1,59 @@
+/* A stand-in for a Go binary built with cgo: the build info that Go's linker
+ * writes to the .go.buildinfo section (version and settings inline, as
+ * written by Go >= 1.18), and the symbols whose presence the Go checks look
+ * for. With -DDLOPEN, it defines the function through which golang-fips/openssl
+ * loads libcrypto, like binaries of a FIPS-capable toolchain. With
+ * -DDLOPEN_REF, it only references the function, which is left undefined. */
+#define GO_VERSION "go1.23.4"
+#define MODINFO \
+	"0w\xaf\x0c\x92t\x08\x02A\xe1\xc1\x07\xe6\xd6\x18\xe6" \
+	"path\tselftest\n" \
+	"mod\tselftest\t(devel)\t\n" \
+	"build\t-buildmode=exe\n" \
+	"build\tCGO_ENABLED=1\n" \
+	"build\tGOARCH=amd64\n" \
+	"build\tGOEXPERIMENT=strictfipsruntime\n" \
+	"build\tGOOS=linux\n" \
+	"\xf9" "2C1\x86\x18 r\x00\x82" "B\x10" "A\x16\xd8\xf2"
+
+struct buildinfo {
+	char magic[14];
+	unsigned char ptrsize, flags;
+	char pad[16];
+	unsigned char versionlen;
+	char version[sizeof(GO_VERSION) - 1];
+	unsigned char modinfolen[2];
+	char modinfo[sizeof(MODINFO) - 1];
+};
+
+__attribute__((section(".go.buildinfo"), aligned(16), used))
+const struct buildinfo buildinfo = {
+	.magic = "\xff Go buildinf:",
+	.ptrsize = sizeof(void *),
+	.flags = 2,
+	.versionlen = sizeof(GO_VERSION) - 1,
+	.version = GO_VERSION,
+	.modinfolen = {((sizeof(MODINFO) - 1) & 0x7f) | 0x80, (sizeof(MODINFO) - 1) >> 7},
+	.modinfo = MODINFO,
+};
+
+void cgo_init(void) __asm__("_cgo_init");
+void cgo_init(void) {}
+void cgo_topofstack(void) __asm__("_cgo_topofstack");
+void cgo_topofstack(void) {}
+void sha256(void) __asm__("\"crypto/sha256.Sum256\"");
+void sha256(void) {}
+#ifdef DLOPEN
+void fips_dlopen(void) __asm__("\"vendor/github.com/golang-fips/openssl/v2.dlopen\"");
+void fips_dlopen(void) {}
+#elif defined(DLOPEN_REF)
+extern void fips_dlopen(void) __asm__("\"vendor/github.com/golang-fips/openssl/v2.dlopen\"") __attribute__((weak));
+#endif
+
+int main(void) {
+#ifdef DLOPEN_REF
+	if (fips_dlopen)
+		fips_dlopen();
+#endif
+	return 0;
+}
@@ -0,0 +1,13 @@
+/* A stand-in for OpenSSL 3's libcrypto. With -DFIPS, it defines the
+ * function that a FIPS-capable libcrypto provides to query FIPS mode. With
+ * -DIMPORT_FIPS, it only imports FIPS_mode, like a shim forwarding to another
+ * libcrypto, which leaves the function undefined. */
+const char *OpenSSL_version(int type) { return "OpenSSL 3.0.7 1 Nov 2022"; }
+int OPENSSL_init_crypto(unsigned long opts, const void *settings) { return 1; }
+#ifdef FIPS
+int EVP_default_properties_is_fips_enabled(void *libctx) { return 1; }
+#endif
+#ifdef IMPORT_FIPS
+extern int FIPS_mode(void);
+int OPENSSL_fips_mode(void) { return FIPS_mode(); }
+#endif
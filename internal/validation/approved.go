@@ -0,0 +1,36 @@
+package validation
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/flightctl/fips-validator/internal/rootfs"
+)
+
+// validateApprovedBuilds checks that the libcrypto libraries and the FIPS
+// provider module within rootPath are approved builds, i.e. that their SHA-256
+// digests are among hashes. The digest of an unapproved file is reported, so
+// that it can be added to the list once the build has been reviewed.
+func validateApprovedBuilds(rootPath string, cryptoLibs []string, hashes []string) []error {
+	var errs []error
+	files := cryptoLibs
+	if module := findFipsModule(rootPath); module != "" {
+		files = append(files[:len(files):len(files)], module)
+	}
+	for _, file := range files {
+		digest, err := fileSHA256(rootfs.FS(rootPath), file)
+		if err != nil {
+			errs = append(errs, checkErrorf(CheckApprovedBuild, "failed to compute digest of %s: %v", file, err))
+			continue
+		}
+		if !slices.ContainsFunc(hashes, func(h string) bool { return strings.EqualFold(h, digest) }) {
+			errs = append(errs, &CheckError{
+				Check: CheckApprovedBuild,
+				Err:   fmt.Errorf("%s is not an approved build (sha256 %s)", file, digest),
+				Hint:  fmt.Sprintf("install an approved build, or, after review, add %s to openssl.approvedHashes in the policy", digest),
+			})
+		}
+	}
+	return errs
+}
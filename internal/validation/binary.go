@@ -4,105 +4,271 @@ import (
 	"context"
 	"debug/buildinfo"
 	"debug/elf"
+	"errors"
 	"fmt"
+	"io"
+	"io/fs"
 	"path/filepath"
+	"regexp"
+	"runtime"
 	"slices"
 	"strings"
+	"time"
 
 	"github.com/Masterminds/semver/v3"
-	"github.com/fatih/color"
 
+	"github.com/flightctl/fips-validator/internal/rootfs"
 	"github.com/flightctl/fips-validator/pkg/elfinfo"
 )
 
-type versionConstrainedReqs struct {
-	versions     *semver.Constraints
-	requirements []string
-}
+// golangFIPSDlopenSymbol is the function that golang-fips/openssl uses to load
+// libcrypto at runtime.
+const golangFIPSDlopenSymbol = "vendor/github.com/golang-fips/openssl/v2.dlopen"
 
-var (
-	requiredSymbolsForGoVersions = []versionConstrainedReqs{
-		{
-			versions: newSemverConstraint(">= 1.23"),
-			requirements: []string{
-				"vendor/github.com/golang-fips/openssl/v2.dlopen",
-			},
-		},
-	}
-)
+// goVersionRegex matches the Go release of a toolchain version as reported in
+// build info, e.g. "go1.23.4", "go1.23rc1", "go1.24.1 X:boringcrypto", or
+// "devel go1.24-8fa31a2d7d Tue Dec 3 18:21:07 2024 +0000" for development
+// builds.
+var goVersionRegex = regexp.MustCompile(`^(devel )?go(\d+)\.(\d+)(?:\.(\d+))?(?:(rc|beta)(\d+))?`)
 
-func newSemverConstraint(str string) *semver.Constraints {
-	c, err := semver.NewConstraint(str)
-	if err != nil {
-		panic(fmt.Errorf("semver: can't parse constraint %v: %w", str, err))
+// parseGoVersion returns the Go release of a toolchain version. Release
+// candidates and betas are mapped to prereleases of the release they precede,
+// e.g. "go1.23rc1" to 1.23.0-rc.1, and development builds to its "devel"
+// prerelease, so that, as in semver, they don't satisfy constraints such as
+// ">= 1.23" unless the constraint includes prereleases. Anything following the
+// version, e.g. experiments or a custom suffix, is ignored.
+func parseGoVersion(version string) (*semver.Version, error) {
+	m := goVersionRegex.FindStringSubmatch(version)
+	if m == nil {
+		return nil, errors.New("unknown version format")
+	}
+	patch := m[4]
+	if patch == "" {
+		patch = "0"
 	}
-	return c
+	v := m[2] + "." + m[3] + "." + patch
+	switch {
+	case m[5] != "":
+		v += "-" + m[5] + "." + m[6]
+	case m[1] != "":
+		v += "-devel"
+	}
+	return semver.NewVersion(v)
 }
 
-func ValidateBinary(_ context.Context, rootPath string, path string, debugFunc func(string, ...interface{})) bool {
-	var errs []error
-	success := color.New(color.Bold, color.FgGreen).PrintfFunc()
-	failure := color.New(color.Bold, color.FgRed).PrintfFunc()
-	red := color.New(color.Bold, color.FgRed).SprintfFunc()
+// ValidateBinary validates the binary at path relative to rootPath according
+// to policy and returns the result. Binaries that aren't ELF executables or
+// don't use crypto are skipped.
+func ValidateBinary(ctx context.Context, rootPath string, path string, policy *Policy, debugFunc func(string, ...interface{})) *BinaryResult {
+	return validateELF(ctx, rootfs.FS(rootPath), path, false, policy, debugFunc)
+}
 
-	fmt.Printf("• validating binary %s... ", path)
+// ValidateSharedObject validates the shared library at path relative to
+// rootPath like ValidateBinary, but also accepts shared objects.
+func ValidateSharedObject(ctx context.Context, rootPath string, path string, policy *Policy, debugFunc func(string, ...interface{})) *BinaryResult {
+	return validateELF(ctx, rootfs.FS(rootPath), path, true, policy, debugFunc)
+}
 
-	ei, err := elfinfo.ReadFile(filepath.Join(rootPath, path))
+// ValidateBinaryFS is like ValidateBinary, but reads the binary and the
+// libraries it loads from fsys. path is the absolute path of the binary within
+// fsys, e.g. "/usr/bin/foo". If allowShared is set, shared objects are
+// validated, too, as by ValidateSharedObject.
+func ValidateBinaryFS(ctx context.Context, fsys fs.FS, path string, allowShared bool, policy *Policy, debugFunc func(string, ...interface{})) *BinaryResult {
+	return validateELF(ctx, fsys, path, allowShared, policy, debugFunc)
+}
+
+func validateELF(ctx context.Context, fsys fs.FS, path string, allowShared bool, policy *Policy, debugFunc func(string, ...interface{})) *BinaryResult {
+	result := &BinaryResult{Path: path, Runtime: RuntimeCryptoShim(filepath.Base(path))}
+	if result.Runtime != "" {
+		allowShared = true
+	}
+
+	f, err := openFile(fsys, path)
 	if err != nil {
-		if strings.HasPrefix(err.Error(), "bad magic number '[35 33") {
-			fmt.Printf("skipped (shell script)\n")
-		} else {
-			fmt.Printf("skipped (failed to read ELF info: %v)\n", err)
+		return result.skip(SkipReadError, err.Error())
+	}
+	defer f.Close()
+	readModeBits(fsys, path, result)
+
+	start := time.Now()
+	ei, err := elfinfo.ReadFrom(f)
+	addCheckTime(PhaseReadELF, start)
+	if err != nil {
+		format, _ := elfinfo.DetectFormatFrom(io.NewSectionReader(f, 0, 8))
+		switch format {
+		case elfinfo.FormatScript:
+			return result.skip(SkipShellScript, "")
+		case elfinfo.FormatPE, elfinfo.FormatMachO:
+			return result.skip(SkipNonElfPlatform, string(format))
 		}
-		return true // Skip is considered success
+		return result.skip(SkipReadError, err.Error())
 	}
 	if !ei.IsElf {
-		fmt.Printf("skipped (not an ELF executable)\n")
-		return true // Skip is considered success
+		return result.skip(SkipNotElf, "")
+	}
+	if ei.Packer != elfinfo.PackerNone {
+		result.Packer = string(ei.Packer)
+		// The sections and symbols are those of the decompression stub,
+		// so only the unpacked binary can be validated.
+		unpacked, uei, err := unpackUPX(ctx, f)
+		if err != nil {
+			debugFunc("failed to unpack %s: %v", path, err)
+			ce := &CheckError{Check: CheckNotPacked, Err: fmt.Errorf("packed executable (%s); cannot validate crypto usage", ei.Packer), Severity: SeverityWarning}
+			return result.inconclusive(ce, SkipPacked, string(ei.Packer), policy)
+		}
+		defer unpacked.Close()
+		debugFunc("validating %s unpacked with upx", path)
+		f, ei = unpacked, uei
+	}
+	if ei.IsDebugInfo {
+		return result.skip(SkipNotElf, "debuginfo file")
+	}
+	if ei.IsSharedObject && !allowShared {
+		return result.skip(SkipNotElf, "shared object")
+	}
+	if ei.GoBuildID != "" {
+		debugFunc("Go build ID %s", ei.GoBuildID)
+		result.GoBuildID = ei.GoBuildID
+	}
+	if lib := addDebugSymbols(fsys, path, ei, policy, debugFunc); lib != "" {
+		debugFunc("using symbols from debuginfo file %s", lib)
+		result.DebugInfo = lib
+	} else if len(ei.Symbols) == 0 && policy.SymbolSource != SymbolSourceDynsym {
+		debugFunc("binary is stripped, evaluating dynamic symbols only")
+		result.DynamicSymbolsOnly = true
 	}
-	if !usesCrypto(ei, debugFunc) {
-		fmt.Printf("skipped (no crypto)\n")
-		return true // Skip is considered success
+	if len(ei.Symbols) == 0 && len(ei.DynamicSymbols) == 0 {
+		// Without any symbols, usesCrypto can't tell whether the binary
+		// uses crypto, so don't skip it silently.
+		ce := &CheckError{Check: CheckSymbolsAvailable, Err: errors.New("binary fully stripped; crypto usage could not be determined"), Severity: SeverityWarning}
+		return result.inconclusive(ce, SkipNoSymbols, "", policy)
+	}
+	start = time.Now()
+	crypto := usesCrypto(ei, policy, debugFunc)
+	addCheckTime(PhaseCryptoUsage, start)
+	if !crypto && result.Runtime == "" {
+		// Stripped binaries with OpenSSL built in may not keep any
+		// crypto symbols, but still contain OpenSSL's version string.
+		evidence := bundlesOpenSSL(f, ei, debugFunc)
+		if evidence == "" {
+			return result.skip(SkipNoCrypto, "")
+		}
+		debugFunc("%s bundles OpenSSL (%s)", path, evidence)
+	}
+	if lib := resolveLibcrypto(fsys, path, ei, policy); lib != "" {
+		debugFunc("%s loads libcrypto from %s", path, lib)
+		result.Libcrypto = lib
 	}
-	errs = append(errs, validateNotStaticallyLinked(ei)...)
 
-	bi, err := buildinfo.ReadFile(filepath.Join(rootPath, path))
-	if err != nil {
-		debugFunc("skipping further validation (not a Go binary): %v", err)
-	} else {
-		ver := strings.TrimPrefix(bi.GoVersion, "go")
-		if i := strings.IndexByte(ver, ' '); i != -1 {
-			ver = ver[:i]
+	in := &CheckInput{
+		FS:        fsys,
+		Path:      path,
+		Info:      ei,
+		File:      f,
+		Libcrypto: result.Libcrypto,
+		Policy:    policy,
+		Debugf:    debugFunc,
+	}
+	if ei.IsStatic {
+		if e := findStaticExemption(fsys, path, policy, debugFunc); e != nil {
+			debugFunc("%s is statically linked, but exempted by policy (%s)", path, e)
+			in.StaticExemption = e
+			result.StaticExemption = e.String()
 		}
-		goVersion, err := semver.NewVersion(ver)
-		if err != nil {
-			errs = append(errs, fmt.Errorf("failed to parse Go version %q: %v", bi.GoVersion, err))
+	}
+	// Reading the build info of a large binary is expensive, so with
+	// FailFast it's only read once a check needs it.
+	buildInfoRead := false
+	readBuildInfo := func() {
+		if buildInfoRead {
+			return
+		}
+		buildInfoRead = true
+		var bi *buildinfo.BuildInfo
+		var err error
+		if hasGoBuildInfo(ei) {
+			start := time.Now()
+			bi, err = buildinfo.Read(f)
+			addCheckTime(PhaseReadBuildInfo, start)
 		} else {
-			errs = append(errs, validateCgoEnabled(bi)...)
-			errs = append(errs, validateCgoInit(ei)...)
-			errs = append(errs, validateGoSymbols(ei, goVersion)...)
-			errs = append(errs, validateGoTagsAndExperiment(bi)...)
+			err = errors.New("no .go.buildinfo section")
 		}
+		if err != nil {
+			debugFunc("skipping further validation (not a Go binary): %v", err)
+			return
+		}
+		in.BuildInfo = bi
+		result.BuildMode = getBuildSetting(bi, "-buildmode")
+		if goos, goarch := getBuildSetting(bi, "GOOS"), getBuildSetting(bi, "GOARCH"); goos != "" && goarch != "" {
+			result.Platform = goos + "/" + goarch
+		}
+		result.VCS = getVCSInfo(bi)
+		if in.GoVersion, err = parseGoVersion(bi.GoVersion); err != nil {
+			result.Findings = append(result.Findings, newFinding(checkErrorf(CheckGoVersion, "failed to parse Go version %q: %v", bi.GoVersion, err)))
+		} else if in.isGoLibrary() {
+			debugFunc("skipping cgo checks for Go library (-buildmode=%s)", result.BuildMode)
+		}
+	}
+	if !policy.FailFast {
+		readBuildInfo()
 	}
 
-	if len(errs) > 0 {
-		failure("failed\n")
-		for _, e := range errs {
-			fmt.Printf("  %s %v\n", red("✘"), e)
+	// With CompareRules, the checks that apply the Go version rules also
+	// run under the other rules, and the other rules' findings replace
+	// theirs for the comparison.
+	var ruleFindings, compareFindings []Finding
+	for _, c := range registeredChecks {
+		if needsBuildInfo(c) {
+			readBuildInfo()
 		}
-		return false
+		if policy.FailFast && hasErrors(result.Findings) {
+			debugFunc("skipping remaining checks of %s (fail fast)", path)
+			break
+		}
+		findings := runCheck(ctx, c, in)
+		result.Findings = append(result.Findings, findings...)
+		if policy.CompareRules != nil && usesRules(c) {
+			ruleFindings = append(ruleFindings, findings...)
+			compareFindings = append(compareFindings, runCheck(ctx, c, in.withRules(policy.CompareRules))...)
+		}
+	}
+
+	result.Status = StatusPassed
+	if hasErrors(result.Findings) {
+		result.Status = StatusFailed
+	}
+	for _, err := range validatePrivileged(result) {
+		result.Findings = append(result.Findings, newFinding(err))
 	}
-	success("success\n")
-	return true
+	if policy.CompareRules != nil {
+		result.CompareStatus = StatusPassed
+		if countErrors(result.Findings) > countErrors(ruleFindings) || hasErrors(compareFindings) {
+			result.CompareStatus = StatusFailed
+		}
+	}
+	return result
 }
 
-func usesCrypto(info *elfinfo.ElfInfo, debugFunc func(string, ...interface{})) bool {
-	for _, sym := range info.Symbols {
-		if sym.Section >= elf.SHN_LORESERVE || int(sym.Section) >= len(info.Sections) {
-			continue
+// hasErrors returns whether any of the findings is an error.
+func hasErrors(findings []Finding) bool {
+	return countErrors(findings) > 0
+}
+
+// countErrors returns the number of findings that are errors.
+func countErrors(findings []Finding) int {
+	n := 0
+	for _, f := range findings {
+		if f.Severity == SeverityError {
+			n++
 		}
-		section := info.Sections[sym.Section]
-		if strings.Contains(sym.Name, "crypto") && !slices.Contains([]string{".bss"}, section) {
+	}
+	return n
+}
+
+func usesCrypto(info *elfinfo.ElfInfo, policy *Policy, debugFunc func(string, ...interface{})) bool {
+	for _, sym := range policy.symbols(info) {
+		section, ok := symbolSection(info, policy, sym)
+		if ok && strings.Contains(sym.Name, "crypto") {
 			debugFunc("found crypto symbol %q in section %q", sym.Name, section)
 			return true
 		}
@@ -110,89 +276,371 @@ func usesCrypto(info *elfinfo.ElfInfo, debugFunc func(string, ...interface{})) b
 	return false
 }
 
-func validateNotStaticallyLinked(info *elfinfo.ElfInfo) []error {
-	if info.IsStatic {
-		return []error{fmt.Errorf("statically linked")}
+func validateNotStaticallyLinked(info *elfinfo.ElfInfo, exemption *StaticExemption) []error {
+	if info.IsStatic && exemption == nil {
+		return []error{checkErrorf(CheckDynamicLinking, "statically linked")}
 	}
 	return []error{}
 }
 
+func validateRelro(info *elfinfo.ElfInfo, policy *Policy) []error {
+	if !policy.Hardening.RequireFullRelro || info.Relro == elfinfo.RelroFull {
+		return []error{}
+	}
+	return []error{&CheckError{
+		Check:    CheckFullRelro,
+		Err:      fmt.Errorf("not built with full RELRO (RELRO: %s)", info.Relro),
+		Severity: SeverityWarning,
+	}}
+}
+
+// validateControlFlow warns about binaries that lack any of the control-flow
+// protection features of their architecture. Architectures without such
+// features aren't reported.
+func validateControlFlow(info *elfinfo.ElfInfo, policy *Policy) []error {
+	if !policy.Hardening.RequireControlFlowProtection {
+		return []error{}
+	}
+	var missing []string
+	for _, f := range elfinfo.RequiredFeatures(info.Machine) {
+		if !slices.Contains(info.Features, f) {
+			missing = append(missing, string(f))
+		}
+	}
+	if len(missing) == 0 {
+		return []error{}
+	}
+	return []error{&CheckError{
+		Check:    CheckControlFlow,
+		Err:      fmt.Errorf("not built with control-flow protection (missing %s)", strings.Join(missing, ", ")),
+		Severity: SeverityWarning,
+	}}
+}
+
+// hasGoBuildInfo returns whether the binary may contain Go build info, so that
+// reading it can be skipped for C, C++, or Rust binaries. The Go linker always
+// writes a .go.buildinfo section; only binaries whose section headers have been
+// removed need to be searched by buildinfo.Read.
+func hasGoBuildInfo(info *elfinfo.ElfInfo) bool {
+	return len(info.Sections) <= 1 || slices.Contains(info.Sections, ".go.buildinfo")
+}
+
+// validateEntryPoint warns about binaries whose entry point isn't in an
+// executable section, which hints at a corrupted or tampered binary. Shared
+// objects usually have no entry point at all.
+func validateEntryPoint(info *elfinfo.ElfInfo, debugFunc func(string, ...interface{})) []error {
+	if info.IsSharedObject && info.Entry == 0 {
+		return []error{}
+	}
+	if info.EntrySection != "" {
+		debugFunc("entry point 0x%x in section %q", info.Entry, info.EntrySection)
+		return []error{}
+	}
+	return []error{&CheckError{
+		Check:    CheckEntryPoint,
+		Err:      fmt.Errorf("entry point 0x%x is outside of any executable section", info.Entry),
+		Severity: SeverityWarning,
+	}}
+}
+
+// crossCompilers are the usual names of the GCC cross-compilers for Linux
+// targets, by GOARCH, for suggesting a CC setting.
+var crossCompilers = map[string]string{
+	"amd64":   "x86_64-linux-gnu-gcc",
+	"386":     "i686-linux-gnu-gcc",
+	"arm64":   "aarch64-linux-gnu-gcc",
+	"arm":     "arm-linux-gnueabihf-gcc",
+	"ppc64le": "powerpc64le-linux-gnu-gcc",
+	"s390x":   "s390x-linux-gnu-gcc",
+	"riscv64": "riscv64-linux-gnu-gcc",
+}
+
+// validateCgoEnabled fails Go binaries built without cgo. Since the Go
+// toolchain disables cgo by default when cross-compiling, i.e. when GOOS or
+// GOARCH differ from the build host, the failure notes the platform the
+// binary was built for and, if it differs from the host fips-validator runs
+// on, that cross-compilation is the likely cause. The build host itself isn't
+// recorded in the build info.
 func validateCgoEnabled(bi *buildinfo.BuildInfo) []error {
-	for _, bs := range bi.Settings {
-		if bs.Key == "CGO_ENABLED" && bs.Value == "1" {
-			return []error{}
+	if getBuildSetting(bi, "CGO_ENABLED") == "1" {
+		return []error{}
+	}
+	goos, goarch := getBuildSetting(bi, "GOOS"), getBuildSetting(bi, "GOARCH")
+	if goos == "" || goarch == "" {
+		return []error{checkErrorf(CheckCgoEnabled, "not compiled with CGO_ENABLED=1")}
+	}
+
+	platform := goos + "/" + goarch
+	cc := crossCompilers[goarch]
+	if cc == "" || goos != "linux" {
+		cc = "<cross-compiler>"
+	}
+	err := &CheckError{
+		Check: CheckCgoEnabled,
+		Err:   fmt.Errorf("not compiled with CGO_ENABLED=1 (built for %s)", platform),
+		Hint:  fmt.Sprintf("rebuild with CGO_ENABLED=1 and a C compiler installed; when cross-compiling, which disables cgo by default, set CC to a C cross-compiler for %s, e.g. CC=%s", platform, cc),
+	}
+	if goos != runtime.GOOS || goarch != runtime.GOARCH {
+		err.Err = fmt.Errorf("not compiled with CGO_ENABLED=1 (built for %s, likely cross-compiled)", platform)
+		err.Hint = fmt.Sprintf("cross-compiling disables cgo by default: install a C cross-compiler for %s and rebuild with CGO_ENABLED=1 CC=%s", platform, cc)
+	}
+	return []error{err}
+}
+
+func validateCgoInit(info *elfinfo.ElfInfo, policy *Policy) []error {
+	if hasDefinedSymbol(info, policy, "_cgo_init") || hasDefinedSymbol(info, policy, "_cgo_topofstack") {
+		return []error{}
+	}
+	if kind := symbolReference(info, policy, "_cgo_init"); kind != "" {
+		return []error{checkErrorf(CheckCgoInit, "cgo_init symbol is not defined (%s)", kind)}
+	}
+	return []error{checkErrorf(CheckCgoInit, "missing cgo_init symbol")}
+}
+
+// hasDefinedSymbol returns whether the symbol with the given name is defined
+// outside of the sections ignored by policy, see isDefinition.
+func hasDefinedSymbol(info *elfinfo.ElfInfo, policy *Policy, name string) bool {
+	for _, sym := range policy.symbols(info) {
+		if _, ok := symbolSection(info, policy, sym); ok && sym.Name == name && isDefinition(sym) {
+			return true
 		}
 	}
-	return []error{fmt.Errorf("not compiled with CGO_ENABLED=1")}
+	return false
 }
 
-func validateCgoInit(info *elfinfo.ElfInfo) []error {
-	for _, sym := range info.Symbols {
-		if sym.Section >= elf.SHN_LORESERVE || int(sym.Section) >= len(info.Sections) {
+// isDefinition returns whether sym defines a function or object, rather than
+// referencing one that another object may or may not provide: undefined
+// (SHN_UNDEF) and weak symbols match a name without providing what it stands
+// for, e.g. a reference pulled in but never resolved.
+func isDefinition(sym elf.Symbol) bool {
+	if sym.Section == elf.SHN_UNDEF {
+		return false
+	}
+	if bind := elf.ST_BIND(sym.Info); bind != elf.STB_GLOBAL && bind != elf.STB_LOCAL {
+		return false
+	}
+	typ := elf.ST_TYPE(sym.Info)
+	return typ == elf.STT_FUNC || typ == elf.STT_OBJECT
+}
+
+// symbolReference describes why the symbol with the given name isn't a
+// definition, e.g. "undefined reference", or returns "" if there is no such
+// symbol.
+func symbolReference(info *elfinfo.ElfInfo, policy *Policy, name string) string {
+	for _, sym := range policy.symbols(info) {
+		if sym.Name != name || isDefinition(sym) {
 			continue
 		}
-		section := info.Sections[sym.Section]
-		if (sym.Name == "_cgo_init" || sym.Name == "_cgo_topofstack") && !slices.Contains([]string{".bss"}, section) {
-			return []error{}
+		switch {
+		case sym.Section == elf.SHN_UNDEF && elf.ST_BIND(sym.Info) == elf.STB_WEAK:
+			return "weak undefined reference"
+		case sym.Section == elf.SHN_UNDEF:
+			return "undefined reference"
+		case elf.ST_BIND(sym.Info) == elf.STB_WEAK:
+			return "weak symbol"
+		default:
+			return "symbol of type " + strings.TrimPrefix(elf.ST_TYPE(sym.Info).String(), "STT_")
 		}
 	}
-	return []error{fmt.Errorf("missing cgo_init symbol")}
+	return ""
 }
 
-func validateGoSymbols(info *elfinfo.ElfInfo, goVersion *semver.Version) []error {
-	var requiredSymbols []string
-	for _, req := range requiredSymbolsForGoVersions {
-		if req.versions.Check(goVersion) {
-			requiredSymbols = req.requirements
-			break
+// symbolSection returns the name of the section sym is defined in, or false if
+// it is not defined in a regular section or the section is ignored by policy.
+func symbolSection(info *elfinfo.ElfInfo, policy *Policy, sym elf.Symbol) (string, bool) {
+	if sym.Section >= elf.SHN_LORESERVE || int(sym.Section) >= len(info.Sections) {
+		return "", false
+	}
+	section := info.Sections[sym.Section]
+	return section, !policy.ignoresSection(section)
+}
+
+func validateGoSymbols(info *elfinfo.ElfInfo, policy *Policy, goVersion *semver.Version) []error {
+	rules := policy.rules()
+	rule := rules.forGoVersion(goVersion)
+	if rule == nil {
+		return []error{unsupportedGoVersion(goVersion, rules)}
+	}
+
+	var errs []error
+	for _, rs := range rule.RequiredSymbols {
+		if hasDefinedSymbol(info, policy, rs) {
+			continue
+		}
+		if kind := symbolReference(info, policy, rs); kind != "" {
+			errs = append(errs, checkErrorf(CheckGoSymbols, "required symbol %q is not defined (%s)", rs, kind))
+		} else {
+			errs = append(errs, checkErrorf(CheckGoSymbols, "missing required symbol %q", rs))
+		}
+	}
+	return errs
+}
+
+// unsupportedGoVersion returns the error for a Go version that no rule
+// covers, distinguishing versions that are too old to be FIPS-capable from
+// versions that are too new for the rules.
+func unsupportedGoVersion(goVersion *semver.Version, rules *Rules) error {
+	minGoVersion := rules.minGoVersion
+	if goVersion.LessThan(minGoVersion) {
+		return &CheckError{
+			Check: CheckGoVersion,
+			Err:   &GoVersionTooOldError{Version: goVersion.Original(), Minimum: minGoVersion.String()},
+			Hint:  fmt.Sprintf("upgrade the Go toolchain to >= %s and use one that uses OpenSSL for crypto, e.g. from the registry.access.redhat.com/ubi9/go-toolset image", minGoVersion),
 		}
 	}
-	if len(requiredSymbols) == 0 {
-		return []error{fmt.Errorf("uses Go version %s, which is not yet supported by fips-validator", goVersion)}
+	return &CheckError{
+		Check: CheckGoVersion,
+		Err:   &GoVersionTooNewError{Version: goVersion.Original()},
+		Hint:  "upgrade fips-validator to a release that supports this Go version",
 	}
+}
+
+func validateGoBuildTags(info *buildinfo.BuildInfo, policy *Policy) []error {
+	var errs []error
+	buildTags := getBuildTags(info)
+	for _, tag := range policy.DeniedBuildTags {
+		if slices.Contains(buildTags, tag) {
+			errs = append(errs, &CheckError{
+				Check: CheckGoBuildTags,
+				Err:   fmt.Errorf("uses forbidden build tag %v", tag),
+				Hint:  fmt.Sprintf("rebuild without -tags %s", tag),
+			})
+		}
+	}
+	return errs
+}
+
+// fipsDisablingGODEBUG are GODEBUG settings that turn off FIPS mode at
+// runtime, e.g. fips140=off for the native FIPS module of Go 1.24 and later.
+var fipsDisablingGODEBUG = []string{"fips140=off"}
 
+// validateGoDebug fails Go binaries whose default GODEBUG settings disable FIPS
+// mode. The defaults are baked in by //go:debug directives of the main package
+// and the godebug block of go.mod, so the binary has them even if GODEBUG isn't
+// set in its environment.
+func validateGoDebug(info *buildinfo.BuildInfo) []error {
 	var errs []error
-	for _, rs := range requiredSymbols {
-		found := false
-		for _, sym := range info.Symbols {
-			if sym.Section >= elf.SHN_LORESERVE || int(sym.Section) >= len(info.Sections) {
-				continue
-			}
-			section := info.Sections[sym.Section]
-			if sym.Name == rs && !slices.Contains([]string{".bss"}, section) {
-				found = true
-				break
-			}
-		}
-		if !found {
-			errs = append(errs, fmt.Errorf("missing required symbol %q", rs))
+	for _, setting := range strings.Split(getBuildSetting(info, "DefaultGODEBUG"), ",") {
+		if slices.Contains(fipsDisablingGODEBUG, setting) {
+			errs = append(errs, &CheckError{
+				Check: CheckGoDebug,
+				Err:   fmt.Errorf("disables FIPS mode with the default GODEBUG setting %s", setting),
+				Hint:  fmt.Sprintf("remove the \"//go:debug %s\" directive or the \"godebug %s\" line of go.mod and rebuild", setting, setting),
+			})
 		}
 	}
 	return errs
 }
 
-func validateGoTagsAndExperiment(info *buildinfo.BuildInfo) []error {
+func validateGoFIPSEnforcement(info *buildinfo.BuildInfo, policy *Policy, goVersion *semver.Version, debugFunc func(string, ...interface{})) []error {
 	var errs []error
 
-	buildTags := []string{}
+	rule := policy.rules().forGoVersion(goVersion)
+	if rule == nil || len(rule.FIPSEnforcement) == 0 {
+		// Unsupported Go versions are already reported by validateGoSymbols.
+		return errs
+	}
+
+	var names, found []string
+	for _, m := range rule.FIPSEnforcement {
+		names = append(names, m.String())
+		if m.present(info) {
+			found = append(found, m.String())
+		}
+	}
+	if len(found) == 0 {
+		errs = append(errs, &CheckError{
+			Check: CheckGoFIPSEnforcement,
+			Err:   fmt.Errorf("missing FIPS enforcement for Go %s (requires one of %s)", goVersion, strings.Join(names, ", ")),
+			Hint:  "rebuild with " + strings.Join(names, " or "),
+		})
+	} else {
+		debugFunc("found FIPS enforcement %s", strings.Join(found, ", "))
+	}
+
+	return errs
+}
+
+// getBuildSetting returns the value of the given build setting of a Go binary,
+// or "" if it isn't set.
+func getBuildSetting(info *buildinfo.BuildInfo, key string) string {
 	for _, bs := range info.Settings {
-		if bs.Key == "-tags" {
-			buildTags = strings.Split(bs.Value, ",")
-			break
+		if bs.Key == key {
+			return bs.Value
 		}
 	}
-	deniedTags := []string{"no_openssl"}
-	for _, tag := range deniedTags {
-		if slices.Contains(buildTags, tag) {
-			errs = append(errs, fmt.Errorf("uses forbidden build tag %v", tag))
+	return ""
+}
+
+// isLibraryBuildMode returns whether a Go build mode produces a library
+// rather than an executable.
+func isLibraryBuildMode(mode string) bool {
+	switch mode {
+	case "c-shared", "plugin", "shared":
+		return true
+	}
+	return false
+}
+
+// getVCSInfo returns the version control information of a Go binary.
+func getVCSInfo(info *buildinfo.BuildInfo) *VCSInfo {
+	vcs := &VCSInfo{}
+	for _, bs := range info.Settings {
+		switch bs.Key {
+		case "vcs":
+			vcs.System = bs.Value
+		case "vcs.revision":
+			vcs.Revision = bs.Value
+		case "vcs.time":
+			vcs.Time = bs.Value
+		case "vcs.modified":
+			modified := bs.Value == "true"
+			vcs.Modified = &modified
 		}
 	}
+	return vcs
+}
+
+func validateVCSModified(vcs *VCSInfo, policy *Policy, debugFunc func(string, ...interface{})) []error {
+	severity, enforced := policy.VCS.Modified.severity()
+	if !enforced {
+		return []error{}
+	}
+	if vcs.Modified == nil {
+		debugFunc("not checking for a modified working tree (no VCS information)")
+		return []error{}
+	}
+	if !*vcs.Modified {
+		return []error{}
+	}
+	revision := vcs.Revision
+	if revision == "" {
+		revision = "unknown revision"
+	}
+	return []error{&CheckError{
+		Check:    CheckVCSModified,
+		Err:      fmt.Errorf("built from a modified working tree (%s)", revision),
+		Severity: severity,
+	}}
+}
 
+// getBuildTags returns the build tags a Go binary has been built with.
+func getBuildTags(info *buildinfo.BuildInfo) []string {
 	for _, bs := range info.Settings {
-		if bs.Key == "GOEXPERIMENT" && !strings.Contains(bs.Value, "strictfipsruntime") {
-			errs = append(errs, fmt.Errorf("missing required GOEXPERIMENT value 'strictfipsruntime'"))
+		if bs.Key == "-tags" {
+			return strings.Split(bs.Value, ",")
 		}
 	}
+	return []string{}
+}
 
-	return errs
+// hasGoExperiment returns whether a Go binary has been built with the given
+// GOEXPERIMENT enabled.
+func hasGoExperiment(info *buildinfo.BuildInfo, experiment string) bool {
+	for _, bs := range info.Settings {
+		if bs.Key == "GOEXPERIMENT" && slices.Contains(strings.Split(bs.Value, ","), experiment) {
+			return true
+		}
+	}
+	return false
 }
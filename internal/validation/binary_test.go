@@ -0,0 +1,326 @@
+package validation
+
+import (
+	"context"
+	"debug/buildinfo"
+	"debug/elf"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/Masterminds/semver/v3"
+
+	"github.com/flightctl/fips-validator/internal/rootfs"
+	"github.com/flightctl/fips-validator/pkg/elfinfo"
+)
+
+// symbolsInSection returns an ElfInfo whose crypto, cgo, and golang-fips
+// symbols are all defined in the given section.
+func symbolsInSection(section string) *elfinfo.ElfInfo {
+	sections := []string{"", ".text", ".data", ".bss", ".custom"}
+	index := elf.SectionIndex(slices.Index(sections, section))
+	info := elf.ST_INFO(elf.STB_GLOBAL, elf.STT_FUNC)
+	return &elfinfo.ElfInfo{
+		IsElf:    true,
+		Sections: sections,
+		Symbols: []elf.Symbol{
+			{Name: "crypto/sha256.Sum256", Info: info, Section: index},
+			{Name: "_cgo_init", Info: info, Section: index},
+			{Name: "_cgo_topofstack", Info: info, Section: index},
+			{Name: golangFIPSDlopenSymbol, Info: info, Section: index},
+		},
+	}
+}
+
+func TestIgnoredSectionsConsistent(t *testing.T) {
+	goVersion := semver.MustParse("1.23.4")
+	customPolicy, err := ParsePolicy([]byte("ignoredSections: [.bss, .custom]\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		section string
+		policy  *Policy
+		ignored bool
+	}{
+		{name: "text", section: ".text", policy: DefaultPolicy(), ignored: false},
+		{name: "bss by default", section: ".bss", policy: DefaultPolicy(), ignored: true},
+		{name: "custom by default", section: ".custom", policy: DefaultPolicy(), ignored: false},
+		{name: "custom by policy", section: ".custom", policy: customPolicy, ignored: true},
+		{name: "bss by policy", section: ".bss", policy: customPolicy, ignored: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			info := symbolsInSection(tt.section)
+			if got := usesCrypto(info, tt.policy, t.Logf); got == tt.ignored {
+				t.Errorf("usesCrypto = %v, want %v", got, !tt.ignored)
+			}
+			if errs := validateCgoInit(info, tt.policy); (len(errs) > 0) != tt.ignored {
+				t.Errorf("validateCgoInit = %v, want failure %v", errs, tt.ignored)
+			}
+			if errs := validateGoSymbols(info, tt.policy, goVersion); (len(errs) > 0) != tt.ignored {
+				t.Errorf("validateGoSymbols = %v, want failure %v", errs, tt.ignored)
+			}
+		})
+	}
+}
+
+func TestHasGoBuildInfo(t *testing.T) {
+	tests := []struct {
+		name     string
+		sections []string
+		want     bool
+	}{
+		{name: "Go binary", sections: []string{"", ".text", ".go.buildinfo", ".data"}, want: true},
+		{name: "C binary", sections: []string{"", ".text", ".rodata", ".data"}, want: false},
+		// Without section headers (e_shnum 0), debug/elf reports no
+		// sections, and with only the null section header none that
+		// could hold the build info. The build info may then be anywhere
+		// in the data segment, so it must be searched for.
+		{name: "no section headers", sections: nil, want: true},
+		{name: "null section only", sections: []string{""}, want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := hasGoBuildInfo(&elfinfo.ElfInfo{Sections: tt.sections}); got != tt.want {
+				t.Errorf("hasGoBuildInfo(%q) = %v, want %v", tt.sections, got, tt.want)
+			}
+		})
+	}
+}
+
+// cHeavyTree returns a root filesystem with copies of the C fixture binaries
+// of the self-test and the libcrypto they load, and the paths of the binaries.
+func cHeavyTree(b *testing.B) (string, []string) {
+	b.Helper()
+	const fixtures = "../selftest/rootfs"
+	root := b.TempDir()
+	var paths []string
+	for _, lib := range []string{"usr/lib64/libcrypto.so.3", "usr/lib/nonfips/libcrypto.so.3"} {
+		copyFixture(b, filepath.Join(fixtures, lib), filepath.Join(root, lib))
+	}
+	for i := 0; i < 20; i++ {
+		for _, name := range []string{"nonfips-libcrypto", "static-libcrypto", "static-libcrypto-stripped"} {
+			path := filepath.Join("/usr/bin", fmt.Sprintf("%s-%d", name, i))
+			copyFixture(b, filepath.Join(fixtures, "usr/bin", name), filepath.Join(root, path))
+			paths = append(paths, path)
+		}
+	}
+	return root, paths
+}
+
+func copyFixture(b *testing.B, src, dst string) {
+	b.Helper()
+	data, err := os.ReadFile(src)
+	if err != nil {
+		b.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+		b.Fatal(err)
+	}
+	if err := os.WriteFile(dst, data, 0o755); err != nil {
+		b.Fatal(err)
+	}
+}
+
+// BenchmarkValidateCBinaries validates a tree of C binaries and reports the
+// bytes read per binary. The "buildinfo" variant also reads the Go build info
+// of each binary, as was done before hasGoBuildInfo, to show the I/O that
+// skipping it saves.
+func BenchmarkValidateCBinaries(b *testing.B) {
+	root, paths := cHeavyTree(b)
+	policy := DefaultPolicy()
+	policy.OpenSSL.InProcess = true
+	debugf := func(string, ...interface{}) {}
+
+	for _, readBuildInfo := range []bool{false, true} {
+		name := "skip"
+		if readBuildInfo {
+			name = "buildinfo"
+		}
+		b.Run(name, func(b *testing.B) {
+			before := ReadIOStats()
+			for i := 0; i < b.N; i++ {
+				for _, path := range paths {
+					ValidateBinary(context.Background(), root, path, policy, debugf)
+					if !readBuildInfo {
+						continue
+					}
+					f, err := openFile(rootfs.FS(root), path)
+					if err != nil {
+						b.Fatal(err)
+					}
+					if _, err := buildinfo.Read(f); err == nil {
+						b.Fatalf("%s: read Go build info of a C binary", path)
+					}
+					f.Close()
+				}
+			}
+			after := ReadIOStats()
+			b.ReportMetric(float64(after.BytesRead-before.BytesRead)/float64(b.N*len(paths)), "bytes/binary")
+		})
+	}
+}
+
+func TestUnsupportedGoVersion(t *testing.T) {
+	tests := []struct {
+		version string
+		want    string
+	}{
+		{"go1.20.14", "too old"},
+		{"go1.22.12", "too old"},
+		{"go1.22rc1", "too old"},
+		{"go1.23rc1", ""},
+		{"go1.23.0", ""},
+		{"go1.24rc1", ""},
+		{"devel go1.25-abcdef", ""},
+		{"go1.24.5", ""},
+		{"go1.27.1", ""},
+		{"go1.28.0", "too new"},
+		{"go1.28rc1", "too new"},
+		{"devel go1.28-abcdef", "too new"},
+		{"go1.29.2", "too new"},
+	}
+	rules := DefaultRules()
+	for _, tt := range tests {
+		v, err := parseGoVersion(tt.version)
+		if err != nil {
+			t.Fatal(err)
+		}
+		errs := validateGoSymbols(&elfinfo.ElfInfo{}, DefaultPolicy(), v)
+		var got string
+		for _, err := range errs {
+			ce, ok := err.(*CheckError)
+			if !ok || ce.Check != CheckGoVersion {
+				continue
+			}
+			switch ce.Err.(type) {
+			case *GoVersionTooOldError:
+				got = "too old"
+				if !strings.Contains(ce.Hint, "upgrade the Go toolchain") {
+					t.Errorf("%s: hint %q, want toolchain upgrade", tt.version, ce.Hint)
+				}
+			case *GoVersionTooNewError:
+				got = "too new"
+				if !strings.Contains(ce.Hint, "upgrade fips-validator") {
+					t.Errorf("%s: hint %q, want fips-validator upgrade", tt.version, ce.Hint)
+				}
+			default:
+				t.Errorf("%s: unexpected error %T: %v", tt.version, ce.Err, ce.Err)
+			}
+		}
+		if got != tt.want {
+			t.Errorf("%s: got %q, want %q (rule %v)", tt.version, got, tt.want, rules.forGoVersion(v))
+		}
+	}
+}
+
+func TestParseGoVersion(t *testing.T) {
+	tests := []struct {
+		version string
+		want    string
+	}{
+		{"go1.22", "1.22.0"},
+		{"go1.22.12", "1.22.12"},
+		{"go1.23.4", "1.23.4"},
+		{"go1.23rc1", "1.23.0-rc.1"},
+		{"go1.23beta2", "1.23.0-beta.2"},
+		{"go1.24.0", "1.24.0"},
+		{"go1.24.1 X:boringcrypto", "1.24.1"},
+		{"go1.24.1 X:strictfipsruntime", "1.24.1"},
+		{"go1.24.0-bigcorp", "1.24.0"},
+		{"devel go1.25-abcdef", "1.25.0-devel"},
+		{"devel go1.24-8fa31a2d7d Tue Dec 3 18:21:07 2024 +0000", "1.24.0-devel"},
+	}
+	for _, tt := range tests {
+		got, err := parseGoVersion(tt.version)
+		if err != nil {
+			t.Errorf("parseGoVersion(%q): %v", tt.version, err)
+			continue
+		}
+		if got.String() != tt.want {
+			t.Errorf("parseGoVersion(%q) = %s, want %s", tt.version, got, tt.want)
+		}
+	}
+
+	for _, version := range []string{"", "1.23.4", "go", "gox.y", "devel +abcdef"} {
+		if got, err := parseGoVersion(version); err == nil {
+			t.Errorf("parseGoVersion(%q) = %s, want error", version, got)
+		}
+	}
+}
+
+func TestParseGoVersionConstraints(t *testing.T) {
+	tests := []struct {
+		version    string
+		constraint string
+		want       bool
+	}{
+		{"go1.23.0", ">= 1.23", true},
+		{"go1.24.0", ">= 1.23", true},
+		{"go1.22.12", ">= 1.23", false},
+		// Prereleases of a release don't satisfy a constraint for it.
+		{"go1.23rc1", ">= 1.23", false},
+		{"devel go1.25-abcdef", ">= 1.23", false},
+		{"go1.23rc1", "< 1.23", false},
+		// Unless the constraint includes prereleases.
+		{"go1.23rc1", ">= 1.23.0-0", true},
+		{"go1.23beta1", ">= 1.23.0-0", true},
+		{"go1.22rc1", ">= 1.23.0-0", false},
+	}
+	for _, tt := range tests {
+		v, err := parseGoVersion(tt.version)
+		if err != nil {
+			t.Fatalf("parseGoVersion(%q): %v", tt.version, err)
+		}
+		c, err := semver.NewConstraint(tt.constraint)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := c.Check(v); got != tt.want {
+			t.Errorf("%q satisfies %q = %v, want %v", tt.version, tt.constraint, got, tt.want)
+		}
+	}
+}
+
+func TestParseGoVersionOrder(t *testing.T) {
+	// Prereleases sort before the release they precede, and after the
+	// previous one.
+	versions := []string{"go1.22.12", "go1.23beta1", "go1.23rc1", "go1.23rc2", "go1.23", "go1.23.1"}
+	for i := 1; i < len(versions); i++ {
+		a, _ := parseGoVersion(versions[i-1])
+		b, _ := parseGoVersion(versions[i])
+		if !a.LessThan(b) {
+			t.Errorf("%s (%s) is not less than %s (%s)", versions[i-1], a, versions[i], b)
+		}
+	}
+}
+
+func TestDefaultRulesPrerelease(t *testing.T) {
+	// Prereleases are covered by the rule of the release they precede.
+	rules := DefaultRules()
+	for version, release := range map[string]string{
+		"go1.23rc1":           "go1.23.0",
+		"go1.23beta1":         "go1.23.0",
+		"go1.24rc1":           "go1.24.0",
+		"devel go1.25-abcdef": "go1.25.0",
+		"go1.27rc2":           "go1.27.0",
+	} {
+		v, err := parseGoVersion(version)
+		if err != nil {
+			t.Fatal(err)
+		}
+		r, err := parseGoVersion(release)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, want := rules.forGoVersion(v), rules.forGoVersion(r); got == nil || got != want {
+			t.Errorf("rule for %s = %v, want the rule for %s (%v)", version, got, release, want)
+		}
+	}
+}
@@ -0,0 +1,234 @@
+package validation
+
+import (
+	"context"
+	"debug/buildinfo"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"time"
+
+	"github.com/Masterminds/semver/v3"
+
+	"github.com/flightctl/fips-validator/pkg/elfinfo"
+)
+
+// Check is a check performed on each binary that uses crypto.
+type Check interface {
+	// ID returns the check's ID, e.g. "cgo-init".
+	ID() string
+	// Severity returns the severity of the errors returned by Check that
+	// don't set their own severity.
+	Severity() Severity
+	// Check validates the binary described by in and returns the problems
+	// it found. Errors that aren't *CheckError are attributed to the check.
+	Check(ctx context.Context, in *CheckInput) []error
+}
+
+// CheckInput describes the binary that a check validates.
+type CheckInput struct {
+	// FS is the root filesystem the binary belongs to, Path is the binary's
+	// absolute path within it, e.g. "/usr/bin/foo".
+	FS   fs.FS
+	Path string
+	Info *elfinfo.ElfInfo
+	// File is the binary's contents, e.g. for reading sections not covered
+	// by Info.
+	File io.ReaderAt
+	// BuildInfo is the binary's Go build information, or nil if it isn't a
+	// Go binary. GoVersion is nil, too, if the Go version can't be parsed.
+	BuildInfo *buildinfo.BuildInfo
+	GoVersion *semver.Version
+	// Libcrypto is the libcrypto the binary loads, see BinaryResult.
+	Libcrypto string
+	// StaticExemption is the policy's exemption for a statically-linked
+	// binary, or nil if it isn't exempted.
+	StaticExemption *StaticExemption
+	Policy          *Policy
+	Debugf          func(string, ...interface{})
+}
+
+// withRules returns a copy of in whose policy applies the given Go version
+// rules.
+func (in *CheckInput) withRules(rules *Rules) *CheckInput {
+	p := *in.Policy
+	p.Rules = rules
+	c := *in
+	c.Policy = &p
+	return &c
+}
+
+// isGoLibrary returns whether the binary is a Go shared library or plugin.
+func (in *CheckInput) isGoLibrary() bool {
+	if in.Info.IsSharedObject {
+		return true
+	}
+	return in.BuildInfo != nil && isLibraryBuildMode(getBuildSetting(in.BuildInfo, "-buildmode"))
+}
+
+// checkFunc adapts a function to the Check interface.
+type checkFunc struct {
+	id       string
+	severity Severity
+	fn       func(ctx context.Context, in *CheckInput) []error
+	// goOnly is set for checks of Go binaries, which need their build
+	// info. usesRules is set for checks that apply the Go version rules.
+	goOnly    bool
+	usesRules bool
+}
+
+func (c *checkFunc) ID() string         { return c.id }
+func (c *checkFunc) Severity() Severity { return c.severity }
+
+func (c *checkFunc) Check(ctx context.Context, in *CheckInput) []error {
+	return c.fn(ctx, in)
+}
+
+// goCheck returns a check that only applies to Go binaries whose Go version is
+// known.
+func goCheck(id string, fn func(ctx context.Context, in *CheckInput) []error) Check {
+	return &checkFunc{id: id, severity: SeverityError, goOnly: true, fn: func(ctx context.Context, in *CheckInput) []error {
+		if in.GoVersion == nil {
+			return nil
+		}
+		return fn(ctx, in)
+	}}
+}
+
+// goRulesCheck returns a check like goCheck that applies the policy's Go
+// version rules.
+func goRulesCheck(id string, fn func(ctx context.Context, in *CheckInput) []error) Check {
+	c := goCheck(id, fn).(*checkFunc)
+	c.usesRules = true
+	return c
+}
+
+// usesRules returns whether c applies the Go version rules.
+func usesRules(c Check) bool {
+	cf, ok := c.(*checkFunc)
+	return ok && cf.usesRules
+}
+
+// needsBuildInfo returns whether c may use the build info of Go binaries.
+// Custom checks are assumed to use it.
+func needsBuildInfo(c Check) bool {
+	cf, ok := c.(*checkFunc)
+	return !ok || cf.goOnly
+}
+
+// registeredChecks are the checks ValidateBinary performs, in order.
+var registeredChecks = []Check{
+	&checkFunc{id: CheckDynamicLinking, severity: SeverityError, fn: func(_ context.Context, in *CheckInput) []error {
+		return validateNotStaticallyLinked(in.Info, in.StaticExemption)
+	}},
+	&checkFunc{id: CheckStaticOpenSSL, severity: SeverityError, fn: func(_ context.Context, in *CheckInput) []error {
+		return validateNoStaticOpenSSL(in.File, in.Info, in.Debugf)
+	}},
+	&checkFunc{id: CheckOpenSSLLinkage, severity: SeverityError, fn: func(_ context.Context, in *CheckInput) []error {
+		return validateOpenSSLLinkage(in.FS, in.Path, in.Info, in.Libcrypto, in.Policy.OpenSSL.SymbolMatch, in.Debugf)
+	}},
+	&checkFunc{id: CheckHardcodedLibcrypto, severity: SeverityError, fn: func(_ context.Context, in *CheckInput) []error {
+		return validateHardcodedLibcrypto(in.FS, in.File, in.Info, in.Policy.OpenSSL.SymbolMatch, in.Debugf)
+	}},
+	&checkFunc{id: CheckRuntimeCrypto, severity: SeverityError, fn: func(_ context.Context, in *CheckInput) []error {
+		return validateRuntimeCrypto(in.FS, in.Path, in.Info, in.Libcrypto)
+	}},
+	&checkFunc{id: CheckFullRelro, severity: SeverityWarning, fn: func(_ context.Context, in *CheckInput) []error {
+		return validateRelro(in.Info, in.Policy)
+	}},
+	&checkFunc{id: CheckControlFlow, severity: SeverityWarning, fn: func(_ context.Context, in *CheckInput) []error {
+		return validateControlFlow(in.Info, in.Policy)
+	}},
+	&checkFunc{id: CheckEntryPoint, severity: SeverityWarning, fn: func(_ context.Context, in *CheckInput) []error {
+		return validateEntryPoint(in.Info, in.Debugf)
+	}},
+	&checkFunc{id: CheckLibc, severity: SeverityInfo, fn: func(_ context.Context, in *CheckInput) []error {
+		return validateLibc(in.Info)
+	}},
+	&checkFunc{id: CheckVCSModified, severity: SeverityError, goOnly: true, fn: func(_ context.Context, in *CheckInput) []error {
+		if in.BuildInfo == nil {
+			return nil
+		}
+		return validateVCSModified(getVCSInfo(in.BuildInfo), in.Policy, in.Debugf)
+	}},
+	&checkFunc{id: CheckGoBannedModules, severity: SeverityError, goOnly: true, fn: func(_ context.Context, in *CheckInput) []error {
+		if in.BuildInfo == nil {
+			return nil
+		}
+		return validateBannedModules(in.BuildInfo.Deps, in.Policy)
+	}},
+	&checkFunc{id: CheckGoPureCrypto, severity: SeverityWarning, goOnly: true, fn: func(_ context.Context, in *CheckInput) []error {
+		if in.BuildInfo == nil {
+			return nil
+		}
+		return validatePureGoCrypto(in.Info, in.BuildInfo, in.Policy)
+	}},
+	// Go can only build libraries with cgo, and their cgo runtime symbols
+	// differ from those of executables, so the cgo checks are skipped for
+	// them.
+	goCheck(CheckCgoEnabled, func(_ context.Context, in *CheckInput) []error {
+		if in.isGoLibrary() {
+			return nil
+		}
+		return validateCgoEnabled(in.BuildInfo)
+	}),
+	goCheck(CheckCgoInit, func(_ context.Context, in *CheckInput) []error {
+		if in.isGoLibrary() {
+			return nil
+		}
+		return validateCgoInit(in.Info, in.Policy)
+	}),
+	goRulesCheck(CheckGoSymbols, func(_ context.Context, in *CheckInput) []error {
+		return validateGoSymbols(in.Info, in.Policy, in.GoVersion)
+	}),
+	goCheck(CheckGoOpenSSLModule, func(_ context.Context, in *CheckInput) []error {
+		return validateGoOpenSSLModule(in.Info, in.BuildInfo, in.Policy)
+	}),
+	goCheck(CheckGoBuildTags, func(_ context.Context, in *CheckInput) []error {
+		return validateGoBuildTags(in.BuildInfo, in.Policy)
+	}),
+	goCheck(CheckGoDebug, func(_ context.Context, in *CheckInput) []error {
+		return validateGoDebug(in.BuildInfo)
+	}),
+	goRulesCheck(CheckGoFIPSEnforcement, func(_ context.Context, in *CheckInput) []error {
+		return validateGoFIPSEnforcement(in.BuildInfo, in.Policy, in.GoVersion, in.Debugf)
+	}),
+}
+
+// RegisterCheck adds a check that ValidateBinary performs after the built-in
+// checks. If c also implements interface{ Info() CheckInfo }, its
+// documentation is shown by the explain command. RegisterCheck is meant to be
+// called from init functions and panics if a check with the same ID exists.
+func RegisterCheck(c Check) {
+	for _, rc := range registeredChecks {
+		if rc.ID() == c.ID() {
+			panic(fmt.Sprintf("validation: check %q registered twice", c.ID()))
+		}
+	}
+	registeredChecks = append(registeredChecks, c)
+	if d, ok := c.(interface{ Info() CheckInfo }); ok {
+		checkInfos = append(checkInfos, d.Info())
+	}
+}
+
+// runCheck runs c and converts the errors it returns into findings. The time
+// spent in c is added to its CheckTime.
+func runCheck(ctx context.Context, c Check, in *CheckInput) []Finding {
+	start := time.Now()
+	errs := c.Check(ctx, in)
+	addCheckTime(c.ID(), start)
+
+	var findings []Finding
+	for _, err := range errs {
+		var ce *CheckError
+		if !errors.As(err, &ce) {
+			ce = &CheckError{Check: c.ID(), Err: err}
+		}
+		if ce.Severity == "" {
+			ce = &CheckError{Check: ce.Check, Err: ce.Err, Hint: ce.Hint, Severity: c.Severity()}
+		}
+		findings = append(findings, newFinding(ce))
+	}
+	return findings
+}
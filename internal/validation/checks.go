@@ -0,0 +1,304 @@
+package validation
+
+// CheckInfo documents a check for users. The ID matches the check IDs used
+// in findings.
+type CheckInfo struct {
+	ID          string
+	Title       string
+	Description string
+	Rationale   string
+	Failure     string
+	Remediation string
+}
+
+var checkInfos = []CheckInfo{
+	{
+		ID:          CheckDynamicLinking,
+		Title:       "Binary is dynamically linked",
+		Description: "Checks that a binary using crypto is dynamically linked, i.e. it has a program interpreter (PT_INTERP).",
+		Rationale:   "On a FIPS system, crypto must be performed by the system's FIPS-validated OpenSSL library. A statically linked binary carries its own copy of any crypto code, which is not covered by the system's FIPS validation.",
+		Failure:     "The binary's crypto code was linked into the binary at build time and is not the system's validated module.",
+		Remediation: "link the binary dynamically; for Go binaries, build with CGO_ENABLED=1 and don't pass -extldflags=-static",
+	},
+	{
+		ID:          CheckCgoEnabled,
+		Title:       "Go binary is built with cgo",
+		Description: "Checks that a Go binary's build info records CGO_ENABLED=1.",
+		Rationale:   "Go toolchains with OpenSSL support call into libcrypto through cgo. Without cgo, the binary falls back to Go's native crypto implementation.",
+		Failure:     "The binary cannot use OpenSSL and performs crypto with the Go standard library's implementation.",
+		Remediation: "rebuild with CGO_ENABLED=1",
+	},
+	{
+		ID:          CheckCgoInit,
+		Title:       "Go binary contains the cgo runtime",
+		Description: "Checks that a Go binary defines the _cgo_init or _cgo_topofstack symbols. Undefined and weak symbols of these names don't count.",
+		Rationale:   "These symbols are only present if the cgo runtime was actually linked into the binary, which is required to call into OpenSSL.",
+		Failure:     "The binary was built without cgo support, even if its build settings suggest otherwise, e.g. because no C toolchain was available.",
+		Remediation: "rebuild with CGO_ENABLED=1 and make sure a C toolchain is available to the Go toolchain",
+	},
+	{
+		ID:          CheckGoVersion,
+		Title:       "Go version is supported",
+		Description: "Checks that the Go version recorded in a binary's build info can be parsed and is covered by fips-validator's rules.",
+		Rationale:   "How a Go binary uses OpenSSL differs between Go versions, so fips-validator needs to know which rules to apply.",
+		Failure:     "The binary was built with a Go version that is either too old to support FIPS, or newer than the versions known to this release of fips-validator.",
+		Remediation: "rebuild with a Go toolchain >= 1.23 that uses OpenSSL for crypto, e.g. from the registry.access.redhat.com/ubi9/go-toolset image, or, if the binary was built with a newer Go version, upgrade fips-validator",
+	},
+	{
+		ID:          CheckGoSymbols,
+		Title:       "Go binary contains the OpenSSL backend",
+		Description: "Checks that a Go binary defines the symbols of the golang-fips/openssl crypto backend required for its Go version, as functions or objects. Undefined and weak symbols of these names don't count.",
+		Rationale:   "Only Go toolchains patched to use OpenSSL, like those shipped with RHEL, route crypto through the system's FIPS-validated libcrypto.",
+		Failure:     "The binary was built with an upstream Go toolchain or with the OpenSSL backend disabled and performs crypto in Go.",
+		Remediation: "rebuild with a Go toolchain that uses OpenSSL for crypto, e.g. from the registry.access.redhat.com/ubi9/go-toolset image",
+	},
+	{
+		ID:          CheckGoBuildTags,
+		Title:       "Go binary doesn't use forbidden build tags",
+		Description: "Checks that a Go binary was not built with build tags that disable the OpenSSL backend, such as no_openssl. The forbidden tags are listed in deniedBuildTags in the policy.",
+		Rationale:   "Build tags like no_openssl make the Go toolchain fall back to its native crypto implementation.",
+		Failure:     "The binary performs crypto in Go rather than through OpenSSL.",
+		Remediation: "rebuild without the forbidden build tag",
+	},
+	{
+		ID:          CheckGoDebug,
+		Title:       "Go binary doesn't disable FIPS mode with a GODEBUG default",
+		Description: "Checks the default GODEBUG settings recorded in a Go binary's build information (DefaultGODEBUG), which are set by //go:debug directives in its main package and the godebug block of its go.mod, and fails binaries whose defaults disable FIPS mode, such as fips140=off.",
+		Rationale:   "A binary built with the native FIPS module of Go 1.24 and later applies its GODEBUG defaults whenever GODEBUG isn't set in its environment, so a baked-in fips140=off turns FIPS mode off at runtime, even though the binary passes all other checks.",
+		Failure:     "The binary runs with FIPS mode disabled.",
+		Remediation: "remove the //go:debug directive or the godebug setting of go.mod that disables FIPS mode and rebuild the binary",
+	},
+	{
+		ID:          CheckGoFIPSEnforcement,
+		Title:       "Go binary enforces FIPS mode",
+		Description: "Checks that a Go binary was built with one of the settings that make it enforce FIPS mode at runtime, depending on its Go version, e.g. GOEXPERIMENT=strictfipsruntime, the requirefips build tag, or, with Go 1.24 and later, the default GODEBUG setting fips140=only. Binaries built without any GOEXPERIMENT are checked, too.",
+		Rationale:   "Without enforcement, a binary built with OpenSSL support may still silently use non-FIPS crypto when OpenSSL can't be loaded or isn't in FIPS mode.",
+		Failure:     "The binary may run with non-FIPS crypto instead of failing.",
+		Remediation: "rebuild with GOEXPERIMENT=strictfipsruntime",
+	},
+	{
+		ID:          CheckStaticOpenSSL,
+		Title:       "Binary doesn't statically link OpenSSL",
+		Description: "Checks that a C or C++ binary that doesn't link libcrypto neither defines OpenSSL's functions, e.g. OPENSSL_init_crypto, nor contains OpenSSL's version string, e.g. \"OpenSSL 3.0.7 1 Nov 2022\".",
+		Rationale:   "A binary linked against libcrypto.a has OpenSSL's code built in, with no DT_NEEDED entry and no dlopen() of the system's libcrypto, so its crypto doesn't use the FIPS-validated module, even if the binary itself is dynamically linked.",
+		Failure:     "The binary's crypto doesn't use the FIPS-validated module. A stripped binary that doesn't otherwise show crypto usage is only checked for the version string.",
+		Remediation: "link libcrypto dynamically against the system's libcrypto.so instead of libcrypto.a",
+	},
+	{
+		ID:          CheckOpenSSLLinkage,
+		Title:       "Binary links a consistent, FIPS-capable OpenSSL",
+		Description: "Checks that the libssl and libcrypto SONAMEs in a binary's DT_NEEDED entries belong to the same OpenSSL release series, that the libssl found in the root filesystem links libcrypto of that series, too, and that the libcrypto the binary loads exports the FIPS mode functions.",
+		Rationale:   "A binary linking, e.g., a bundled libssl.so.1.1 alongside the system's libcrypto.so.3 loads two OpenSSL versions, so some of its crypto bypasses the FIPS-capable library.",
+		Failure:     "Parts of the binary's crypto may not use FIPS-validated implementations. If libcrypto can't be found in the root filesystem, e.g. in RPM packages, only the SONAMEs are compared.",
+		Remediation: "link all OpenSSL libraries against the system's FIPS-capable OpenSSL and remove bundled copies from the binary's RPATH/RUNPATH",
+	},
+	{
+		ID:          CheckHardcodedLibcrypto,
+		Title:       "Binary doesn't load libcrypto from a hardcoded path",
+		Description: "Checks the absolute libcrypto paths in a binary's read-only data, which it likely passes to dlopen(): a libcrypto found at such a path must be FIPS-capable, and paths outside the standard library directories are reported as warnings.",
+		Rationale:   "A binary that loads libcrypto from a hardcoded path bypasses the dynamic linker's library resolution, so it may load a bundled or non-FIPS OpenSSL even if the system's OpenSSL is FIPS-capable.",
+		Failure:     "The binary may use non-FIPS crypto at runtime. Only binaries detected as using crypto are checked, and paths built at runtime can't be found.",
+		Remediation: "load libcrypto by its SONAME, e.g. dlopen(\"libcrypto.so.3\"), so that the system's FIPS-capable OpenSSL is used",
+	},
+	{
+		ID:          CheckRuntimeCrypto,
+		Title:       "Managed runtime uses the system's libcrypto",
+		Description: "Checks the native crypto shims of managed runtimes, e.g. .NET's libSystem.Security.Cryptography.Native.OpenSsl.so, which are validated like shared libraries that use crypto, even without --shared-objects: the libcrypto the shim links or, in portable builds, dlopen()s by SONAME must be found in the standard library directories, and a portable shim must not come with a libcrypto of its own. The libcrypto must be FIPS-capable, see openssl-linkage. If no libcrypto is found, e.g. for a single binary, a note is reported.",
+		Rationale:   "Managed runtimes do crypto through a native shim that calls OpenSSL. A shim loading a libcrypto bundled with the runtime or application bypasses the system's FIPS-validated OpenSSL, while the application's code looks like it only uses the runtime's standard crypto APIs.",
+		Failure:     "The runtime's crypto goes through a libcrypto that isn't the system's, which may not be FIPS-capable or configured for FIPS mode.",
+		Remediation: "use a build of the runtime that loads the system's OpenSSL, e.g. the distribution's dotnet packages, and remove the bundled libcrypto",
+	},
+	{
+		ID:          CheckLibcryptoPresent,
+		Title:       "libcrypto is present",
+		Description: "Checks that the root filesystem contains OpenSSL's libcrypto in one of the standard library directories.",
+		Rationale:   "Binaries built for FIPS load the system's libcrypto at runtime and fail if it is missing.",
+		Failure:     "Binaries that use crypto can't run or can't run in FIPS mode.",
+		Remediation: "install the openssl-libs package",
+	},
+	{
+		ID:          CheckLibcryptoFIPS,
+		Title:       "libcrypto is FIPS-capable",
+		Description: "Checks that each libcrypto in the root filesystem exports the symbols used to query or enable FIPS mode.",
+		Rationale:   "Only OpenSSL builds with FIPS support can run in FIPS mode.",
+		Failure:     "Binaries loading this libcrypto can't run in FIPS mode.",
+		Remediation: "install an OpenSSL build with FIPS support, e.g. the openssl-libs package from RHEL",
+	},
+	{
+		ID:          CheckLibcryptoLoadable,
+		Title:       "libcrypto is loadable",
+		Description: "Checks that each libcrypto found in an image or directory is a valid ELF shared library of the root filesystem's architecture, as determined from /bin/sh, before looking for the FIPS mode functions.",
+		Rationale:   "A libcrypto of the wrong architecture, e.g. on a cross-architecture image, or a truncated or corrupt file can't be loaded by the dynamic loader, no matter which functions it defines.",
+		Failure:     "Binaries that link libcrypto fail to start, or the dynamic loader picks up another libcrypto that may not be FIPS-capable.",
+		Remediation: "reinstall the openssl-libs package for the image's architecture",
+	},
+
+	{
+		ID:          CheckFullRelro,
+		Title:       "Binary is built with full RELRO",
+		Description: "Optional hardening check, enabled with hardening.requireFullRelro in the policy, that warns if a binary using crypto lacks a PT_GNU_RELRO segment or isn't bound at startup (BIND_NOW).",
+		Rationale:   "FIPS doesn't require RELRO, but hardening baselines commonly do: with full RELRO, the dynamic loader makes the global offset table read-only, so function pointers into libcrypto can't be overwritten at runtime.",
+		Failure:     "An attacker with a memory write primitive could redirect calls meant for libcrypto. This is reported as a warning and doesn't fail validation.",
+		Remediation: "link with -Wl,-z,relro,-z,now; for Go binaries, pass -ldflags=-extldflags=-Wl,-z,relro,-z,now or build with -buildmode=pie",
+	},
+	{
+		ID:          CheckControlFlow,
+		Title:       "Binary is built with control-flow protection",
+		Description: "Optional hardening check, enabled with hardening.requireControlFlowProtection in the policy, that warns if a binary using crypto doesn't advertise Intel CET indirect branch tracking and shadow stack support (x86_64) or branch target identification (aarch64) in its PT_GNU_PROPERTY notes. Binaries of other architectures aren't reported.",
+		Rationale:   "FIPS doesn't require control-flow protection, but hardening baselines increasingly do: it stops return-oriented and jump-oriented attacks from chaining code in libcrypto. The dynamic loader only enables it if the executable and all libraries it loads support it.",
+		Failure:     "The kernel and CPU can't enforce control-flow integrity for the process. This is reported as a warning and doesn't fail validation. The Go linker doesn't emit these notes, so Go binaries are reported unless they are linked externally with objects that all carry them.",
+		Remediation: "compile and link all objects, including static libraries, with -fcf-protection=full on x86_64 or -mbranch-protection=standard on aarch64",
+	},
+	{
+		ID:          CheckEntryPoint,
+		Title:       "Entry point is in an executable section",
+		Description: "Warns if the ELF entry point of a binary using crypto doesn't fall within an executable PROGBITS section such as .text. Shared objects without an entry point aren't reported.",
+		Rationale:   "Compilers and linkers always place the entry point in executable code. An entry point elsewhere indicates a corrupted or tampered binary, whose crypto can't be trusted.",
+		Failure:     "The binary may not run at all or may run code that wasn't built from the validated sources. This is reported as a warning and doesn't fail validation.",
+		Remediation: "rebuild the binary from source and check how it was modified after linking, e.g. by a packer or post-processing tool",
+	},
+	{
+		ID:          CheckFipsModuleMAC,
+		Title:       "FIPS provider module matches its recorded MAC",
+		Description: "Optional check, enabled with openssl.verifyFipsModuleMac in the policy, that recomputes the HMAC-SHA256 of the OpenSSL 3 FIPS provider (ossl-modules/fips.so) and compares it to the module-mac in fipsmodule.cnf. Images without a fipsmodule.cnf, e.g. because the distribution embeds the MAC in the module, pass.",
+		Rationale:   "OpenSSL verifies the module's integrity when it loads the FIPS provider. If fips.so was replaced without re-running \"openssl fipsinstall\", the provider fails its self-test and the FIPS-capable libcrypto can't enter FIPS mode.",
+		Failure:     "Applications fail to load the FIPS provider at runtime, even though libcrypto passes the symbol checks.",
+		Remediation: "re-run \"openssl fipsinstall -out fipsmodule.cnf -module fips.so\" wherever fips.so is installed or updated, or install the fipsmodule.cnf shipped with the module's package",
+	},
+	{
+		ID:          CheckFipsProviderVersion,
+		Title:       "FIPS provider has a required version",
+		Description: "Optional check, enabled with --require-fips-provider-version or openssl.fipsProviderVersion in the policy, that reads the version of the OpenSSL 3 FIPS provider (ossl-modules/fips.so) from its read-only data and fails if it doesn't satisfy the given semver constraint. Build info appended to the version, e.g. \"3.0.7-395c1a240fbfffd8\", is ignored for the comparison.",
+		Rationale:   "FIPS 140 certificates cover specific module versions. Compliance regimes that require a NIST-validated module only accept the certified versions of the FIPS provider.",
+		Failure:     "The image's FIPS provider isn't one of the certified module versions, or no FIPS provider was found.",
+		Remediation: "install the openssl package version that ships a certified FIPS provider, or adjust the version constraint",
+	},
+	{
+		ID:          CheckApprovedBuild,
+		Title:       "OpenSSL is an approved build",
+		Description: "Optional check, enabled with openssl.approvedHashes in the policy, that computes the SHA-256 digest of every libcrypto library and of the OpenSSL 3 FIPS provider module (ossl-modules/fips.so) and fails if it isn't on the list, even if the library is FIPS-capable.",
+		Rationale:   "Exporting the FIPS mode functions only shows that a libcrypto supports FIPS mode. High-assurance environments pin the exact NIST-validated module builds they vetted, which only their content identifies.",
+		Failure:     "The library or module differs from every approved build, e.g. because it was updated or rebuilt.",
+		Remediation: "install an approved build, or, after reviewing the build, add its digest to openssl.approvedHashes",
+	},
+	{
+		ID:          CheckVCSModified,
+		Title:       "Go binary is built from a clean working tree",
+		Description: "Optional check, enabled with vcs.modified in the policy, that reports Go binaries whose embedded build information has vcs.modified=true. Binaries without version control information, e.g. built with -buildvcs=false, aren't reported.",
+		Rationale:   "A binary built from uncommitted changes can't be traced back to a reviewed revision, so it's unclear which code was actually validated.",
+		Failure:     "Depending on the policy, the binary fails validation or a warning is reported.",
+		Remediation: "commit or discard local changes and rebuild the binary from a clean checkout",
+	},
+	{
+		ID:          CheckGoBannedModules,
+		Title:       "Go binary doesn't depend on banned modules",
+		Description: "Optional check, configured with bannedModules in the policy, that fails Go binaries whose embedded module dependencies include a banned module, optionally only in versions matching a semver constraint. Modules replaced with a replace directive are reported if either the original or the replacement is banned.",
+		Rationale:   "Some modules implement crypto in pure Go or wrap it in ways that bypass the FIPS-validated OpenSSL, even if the binary is otherwise built correctly; organizations often keep a list of modules that mustn't be used in FIPS builds.",
+		Failure:     "The binary fails validation, even if all other checks pass.",
+		Remediation: "remove the dependency on the banned module, or upgrade it to a version that isn't banned, and rebuild the binary",
+	},
+	{
+		ID:          CheckGoPureCrypto,
+		Title:       "Go binary doesn't mix the OpenSSL backend with pure-Go crypto",
+		Description: "Checks that Go binaries using the golang-fips/openssl backend don't also link one of the pure-Go crypto modules listed in pureGoCrypto.modules in the policy, e.g. a fork of crypto/tls. A module counts as linked if the binary defines symbols of its packages, or, for binaries without symbols, if it's among the embedded module dependencies. Modules replaced with a replace directive are reported if either the original or the replacement is listed.",
+		Rationale:   "The OpenSSL backend only covers the standard library's crypto. A module that implements crypto in pure Go, often pulled in by an indirect dependency, does crypto outside the FIPS boundary while the binary passes all other checks.",
+		Failure:     "Depending on pureGoCrypto.enforcement in the policy, a warning is reported (the default) or the binary fails validation.",
+		Remediation: "remove the dependency on the pure-Go crypto module, e.g. by upgrading or replacing the module that pulls it in, or use the standard library's crypto instead",
+	},
+	{
+		ID:          CheckGoOpenSSLModule,
+		Title:       "Go binary's OpenSSL bindings come from golang-fips/openssl",
+		Description: "Checks that the OpenSSL bindings of a Go binary are those of github.com/golang-fips/openssl/v2, as vendored by the patched Go toolchain or as a module dependency. Fails binaries that define a dlopen function in another package named like an OpenSSL binding, e.g. a renamed fork, that replace golang-fips/openssl with another module, or that define its functions without depending on it.",
+		Rationale:   "The required symbols are matched by name only. A fork of golang-fips/openssl under another import path may look similar while behaving differently, so the symbols must agree with the module graph embedded in the binary.",
+		Failure:     "The binary fails validation, since its OpenSSL bindings may not enforce FIPS mode.",
+		Remediation: "use the golang-fips/openssl bindings of a patched Go toolchain, e.g. from the registry.access.redhat.com/ubi9/go-toolset image, and remove forks or replace directives of github.com/golang-fips/openssl/v2",
+	},
+	{
+		ID:          CheckLibcryptoPermissions,
+		Title:       "libcrypto can't be modified by unprivileged users",
+		Description: "Optional check, enabled with hardening.libcryptoPermissions in the policy, that warns if a libcrypto library or the OpenSSL 3 FIPS provider module (ossl-modules/fips.so) in an image or directory is writable by group or others, or owned by a user other than root.",
+		Rationale:   "A FIPS-validated libcrypto only protects the system as long as its code can't be replaced. If an unprivileged user can overwrite it, every application's crypto is at that user's mercy.",
+		Failure:     "The library or module could be tampered with by users other than root. This is reported as a warning and doesn't fail validation.",
+		Remediation: "make the file owned by root and not writable by group or others, e.g. with \"chown root\" and \"chmod go-w\"",
+	},
+	{
+		ID:          CheckOpenSSLProviders,
+		Title:       "openssl.cnf only activates the base and FIPS providers",
+		Description: "Checks that an openssl.cnf in an image or directory that activates the OpenSSL 3 FIPS provider doesn't also activate the default provider. Configurations that don't activate the FIPS provider aren't reported. The severity is set with openssl.defaultProvider in the policy or --strict-openssl-providers.",
+		Rationale:   "While the default provider is active, applications can still fetch its non-FIPS implementations of algorithms, e.g. by not requesting the \"fips=yes\" property. A locked-down configuration only leaves the base and FIPS providers active.",
+		Failure:     "Non-FIPS algorithm implementations remain reachable. This is reported as a warning by default, as many valid configurations keep the default provider active.",
+		Remediation: "remove the activate setting from the default provider's section in openssl.cnf and activate the base provider instead",
+	},
+	{
+		ID:          CheckLdCache,
+		Title:       "ld.so.cache resolves libcrypto to a FIPS-capable library",
+		Description: "Optional check, enabled with openssl.verifyLdCache in the policy, that parses /etc/ld.so.cache in an image or directory and warns if the first entry for a libcrypto SONAME, which the dynamic loader uses, isn't FIPS-capable while a FIPS-capable library with the same SONAME is installed. It also warns if the cache is stale: it lists libraries that don't exist, or /etc/ld.so.conf or a file in /etc/ld.so.conf.d was changed after the cache was built.",
+		Rationale:   "The dynamic loader resolves libraries through ld.so.cache before searching the standard library directories. A cache that lists a non-FIPS libcrypto, e.g. one in a directory added to ld.so.conf, ahead of the FIPS-capable one makes every binary load the wrong library at runtime, although each binary passes validation.",
+		Failure:     "Binaries may load a libcrypto that isn't FIPS-capable at runtime. This is reported as a warning and doesn't fail validation.",
+		Remediation: "remove the non-FIPS libcrypto or its directory from the loader configuration and run ldconfig in the image to rebuild the cache",
+	},
+	{
+		ID:          CheckFipsBoot,
+		Title:       "Bootable image enables FIPS mode at boot",
+		Description: "Optional check, enabled with boot.fipsMode in the policy, that inspects the initramfs images of a bootable image or directory, in /boot or /usr/lib/modules, for dracut's fips module, and the kernel command line configuration, e.g. /etc/kernel/cmdline, boot loader entries, GRUB's defaults, or bootc's kargs.d, for fips=1. Images and directories without a kernel, e.g. container images, aren't checked.",
+		Rationale:   "The kernel only enters FIPS mode if booted with fips=1, and dracut's fips module verifies the kernel's integrity and crypto self-tests early in the boot. A FIPS-capable userspace on a kernel that isn't in FIPS mode doesn't run in FIPS mode: OpenSSL follows the kernel's FIPS flag.",
+		Failure:     "A system booted from the image doesn't run in FIPS mode. Depending on the policy, validation fails or a warning is reported.",
+		Remediation: "include dracut's fips module in the initramfs, e.g. by installing dracut-fips, and add fips=1 to the kernel command line",
+	},
+	{
+		ID:          CheckSymbolsAvailable,
+		Title:       "Binary has symbols to detect crypto usage",
+		Description: "Reports binaries that have neither a symbol table (.symtab) nor dynamic symbols (.dynsym), and no separate debuginfo file, e.g. static Go binaries built with -ldflags=\"-s -w\". Such binaries are skipped with a warning, or fail with --strict or strict in the policy.",
+		Rationale:   "Crypto usage is detected from symbol names. Without any symbols, a binary that uses crypto can't be told apart from one that doesn't, so skipping it as not using crypto could hide a violation.",
+		Failure:     "It's unknown whether the binary uses crypto and, if so, whether it's FIPS-capable.",
+		Remediation: "build without stripping the symbol table, e.g. without -ldflags=\"-s\" for Go binaries, or ship a debuginfo file",
+	},
+	{
+		ID:          CheckNotPacked,
+		Title:       "Binary isn't compressed with an executable packer",
+		Description: "Reports binaries compressed with UPX that can't be unpacked with \"upx -d\", e.g. because upx isn't installed. Such binaries are skipped with a warning, or fail with --strict or strict in the policy. Packed binaries that can be unpacked are validated as if they were shipped unpacked.",
+		Rationale:   "A packed binary only shows the symbols of its decompression stub, so the crypto usage of the compressed program can't be detected and it would look like a binary that doesn't use crypto.",
+		Failure:     "It's unknown whether the binary uses crypto and, if so, whether it's FIPS-capable.",
+		Remediation: "ship the binary without compressing it with UPX, or install upx so that it can be unpacked for validation",
+	},
+	{
+		ID:          CheckPrivilegedCrypto,
+		Title:       "Setuid and setgid binaries using crypto are FIPS-capable",
+		Description: "Calls out binaries with the setuid or setgid bit that use crypto. If such a binary fails validation, this check fails it, too, and the summary lists it separately; if it passes, a note is reported.",
+		Rationale:   "A setuid or setgid binary runs with the privileges of its owner or group, often root, for every user who runs it, so non-FIPS crypto in it, e.g. for authentication, is especially dangerous, and any crypto in it warrants extra scrutiny.",
+		Failure:     "A privileged binary uses crypto that isn't FIPS-capable.",
+		Remediation: "fix the binary's other failures first, or remove the setuid or setgid bit if the binary doesn't need it",
+	},
+	{
+		ID:          CheckLibc,
+		Title:       "Binary uses a C library with FIPS support",
+		Description: "Reports binaries whose dynamic loader (PT_INTERP) is musl's, and images and directories that contain the musl dynamic loader, e.g. Alpine-based images. In musl environments, libraries are looked up in musl's search path.",
+		Rationale:   "FIPS-validated OpenSSL builds and the FIPS enforcement of patched Go toolchains target glibc-based distributions. On musl, FIPS mode is generally unsupported, which explains otherwise confusing failures.",
+		Failure:     "This is informational and doesn't fail validation by itself.",
+		Remediation: "build and run FIPS workloads on a glibc-based distribution, e.g. from a registry.access.redhat.com/ubi9 image",
+	},
+}
+
+// Checks returns the documentation of all checks.
+func Checks() []CheckInfo {
+	return checkInfos
+}
+
+// LookupCheck returns the documentation of the check with the given ID.
+func LookupCheck(id string) (CheckInfo, bool) {
+	for _, ci := range checkInfos {
+		if ci.ID == id {
+			return ci, true
+		}
+	}
+	return CheckInfo{}, false
+}
+
+// hintFor returns the remediation hint for a check error.
+func hintFor(ce *CheckError) string {
+	if ce.Hint != "" {
+		return ce.Hint
+	}
+	ci, _ := LookupCheck(ce.Check)
+	return ci.Remediation
+}
@@ -0,0 +1,67 @@
+package validation
+
+import (
+	"fmt"
+	"hash/crc32"
+	"io"
+	"io/fs"
+	"path/filepath"
+
+	"github.com/flightctl/fips-validator/pkg/elfinfo"
+)
+
+// addDebugSymbols adds the symbol table of the separate debuginfo file of the
+// stripped binary at path within fsys to info, if the binary links to one and
+// it can be found. It returns the path of the debuginfo file, or "" if none
+// was used.
+func addDebugSymbols(fsys fs.FS, path string, info *elfinfo.ElfInfo, policy *Policy, debugFunc func(string, ...interface{})) string {
+	if len(info.Symbols) > 0 || info.DebugLink == "" || policy.SymbolSource == SymbolSourceDynsym {
+		return ""
+	}
+	for _, candidate := range debugFileCandidates(path, info.DebugLink, policy.DebugInfo.Directories) {
+		if !isRegularFile(fsys, candidate) {
+			continue
+		}
+		if err := readDebugSymbols(fsys, candidate, info); err != nil {
+			debugFunc("not using debuginfo file %s: %v", candidate, err)
+			continue
+		}
+		return candidate
+	}
+	return ""
+}
+
+// debugFileCandidates returns the paths at which the debuginfo file with the
+// given name is looked up for the binary at path, in the same order as gdb:
+// the binary's directory, its .debug subdirectory, and the binary's directory
+// below each global debug directory.
+func debugFileCandidates(path, debugLink string, debugDirs []string) []string {
+	dir := filepath.Dir(path)
+	candidates := []string{
+		filepath.Join(dir, debugLink),
+		filepath.Join(dir, ".debug", debugLink),
+	}
+	for _, debugDir := range debugDirs {
+		candidates = append(candidates, filepath.Join(debugDir, dir, debugLink))
+	}
+	return candidates
+}
+
+// readDebugSymbols verifies that the debuginfo file at path within fsys
+// matches the CRC recorded in info and adds its symbol table to info.
+func readDebugSymbols(fsys fs.FS, path string, info *elfinfo.ElfInfo) error {
+	f, err := openFile(fsys, path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	crc := crc32.NewIEEE()
+	if _, err := io.Copy(crc, io.NewSectionReader(f, 0, 1<<62)); err != nil {
+		return err
+	}
+	if crc.Sum32() != info.DebugLinkCRC {
+		return fmt.Errorf("CRC mismatch (got %08x, expected %08x)", crc.Sum32(), info.DebugLinkCRC)
+	}
+	return info.AddDebugSymbols(f)
+}
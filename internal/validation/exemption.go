@@ -0,0 +1,54 @@
+package validation
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"io/fs"
+	"path"
+	"strings"
+)
+
+// findStaticExemption returns the first exemption of policy that matches the
+// statically-linked binary at path within fsys, or nil if there is none. The
+// binary is only hashed if an exemption requires it.
+func findStaticExemption(fsys fs.FS, binPath string, policy *Policy, debugFunc func(string, ...interface{})) *StaticExemption {
+	var digest string
+	for i := range policy.StaticExemptions {
+		e := &policy.StaticExemptions[i]
+		if e.Path != "" {
+			if ok, _ := path.Match(e.Path, binPath); !ok {
+				continue
+			}
+		}
+		if e.SHA256 != "" {
+			if digest == "" {
+				var err error
+				if digest, err = fileSHA256(fsys, binPath); err != nil {
+					debugFunc("failed to hash %s: %v", binPath, err)
+					return nil
+				}
+			}
+			if !strings.EqualFold(e.SHA256, digest) {
+				continue
+			}
+		}
+		return e
+	}
+	return nil
+}
+
+// fileSHA256 returns the hex-encoded SHA-256 digest of the file at path within
+// fsys.
+func fileSHA256(fsys fs.FS, path string) (string, error) {
+	f, err := fsys.Open(fsName(path))
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -0,0 +1,294 @@
+package validation
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/flightctl/fips-validator/internal/executor"
+	"github.com/flightctl/fips-validator/internal/rootfs"
+)
+
+var (
+	// initramfsPatterns match the initramfs images of bootable root
+	// filesystems: in /boot on package-based systems, and next to the
+	// kernel in /usr/lib/modules on image-based ones, e.g. bootc images.
+	initramfsPatterns = []string{"/boot/initramfs-*.img", "/usr/lib/modules/*/initramfs.img"}
+	// kernelPatterns match the kernels of bootable root filesystems.
+	kernelPatterns = []string{"/boot/vmlinuz-*", "/usr/lib/modules/*/vmlinuz"}
+	// kernelCmdlinePatterns match the files the kernel command line is
+	// configured in: kernel-install's cmdline files, the Boot Loader
+	// Specification entries, GRUB's defaults and environment block, and
+	// bootc's kernel arguments.
+	kernelCmdlinePatterns = []string{
+		"/etc/kernel/cmdline",
+		"/usr/lib/kernel/cmdline",
+		"/boot/loader/entries/*.conf",
+		"/etc/default/grub",
+		"/boot/grub2/grubenv",
+		"/usr/lib/bootc/kargs.d/*.toml",
+	}
+)
+
+// initramfsDecompressors map the magic numbers of the compression formats
+// dracut supports, other than gzip, to the tool that decompresses them.
+var initramfsDecompressors = []struct {
+	magic []byte
+	tool  string
+}{
+	{[]byte{0x28, 0xb5, 0x2f, 0xfd}, "zstd"},
+	{[]byte{0xfd, '7', 'z', 'X', 'Z', 0x00}, "xz"},
+	{[]byte{0x02, 0x21, 0x4c, 0x18}, "lz4"},
+	{[]byte("BZh"), "bzip2"},
+}
+
+// errFipsModuleFound stops reading an initramfs once the fips module was
+// found.
+var errFipsModuleFound = errors.New("fips module found")
+
+// validateFipsBoot checks that a bootable root filesystem enables FIPS mode at
+// boot: its initramfs images must include dracut's fips module, which checks
+// the kernel's crypto self-tests and the integrity of the kernel early in the
+// boot, and the kernel command line must contain fips=1. Root filesystems
+// without a kernel, e.g. container images, aren't checked.
+func validateFipsBoot(ctx context.Context, rootPath string, policy *Policy) []error {
+	severity, enforced := policy.Boot.FipsMode.severity()
+	if !enforced {
+		return []error{}
+	}
+	fsys := rootfs.FS(rootPath)
+	initramfs := globAll(fsys, initramfsPatterns)
+	if len(initramfs) == 0 && len(globAll(fsys, kernelPatterns)) == 0 {
+		return []error{}
+	}
+
+	var errs []error
+	if len(initramfs) == 0 {
+		errs = append(errs, &CheckError{Check: CheckFipsBoot, Err: errors.New("kernel found, but no initramfs"), Severity: severity})
+	}
+	for _, name := range initramfs {
+		found, err := initramfsHasFipsModule(ctx, fsys, name)
+		switch {
+		case err != nil:
+			errs = append(errs, &CheckError{Check: CheckFipsBoot, Err: fmt.Errorf("failed to read initramfs %s: %v", name, err), Severity: severity})
+		case !found:
+			errs = append(errs, &CheckError{
+				Check:    CheckFipsBoot,
+				Err:      fmt.Errorf("initramfs %s doesn't include the dracut fips module", name),
+				Hint:     "install dracut-fips or add add_dracutmodules+=\" fips \" to a file in /etc/dracut.conf.d, and regenerate the initramfs with dracut",
+				Severity: severity,
+			})
+		}
+	}
+
+	configs := globAll(fsys, kernelCmdlinePatterns)
+	for _, name := range configs {
+		if cmdlineHasFips(fsys, name) {
+			return errs
+		}
+	}
+	err := errors.New("fips=1 isn't set on the kernel command line (no kernel command line configuration found)")
+	if len(configs) > 0 {
+		err = fmt.Errorf("fips=1 isn't set on the kernel command line (checked %s)", strings.Join(configs, ", "))
+	}
+	return append(errs, &CheckError{
+		Check:    CheckFipsBoot,
+		Err:      err,
+		Hint:     "add fips=1 to the kernel command line, e.g. with \"grubby --update-kernel=ALL --args=fips=1\" or, in bootc images, with kargs = [\"fips=1\"] in a file in /usr/lib/bootc/kargs.d",
+		Severity: severity,
+	})
+}
+
+// globAll returns the files within fsys that match any of the patterns, which
+// are absolute paths with glob patterns as accepted by path.Match.
+func globAll(fsys fs.FS, patterns []string) []string {
+	var files []string
+	for _, pattern := range patterns {
+		matches, _ := fs.Glob(fsys, fsName(pattern))
+		for _, m := range matches {
+			if isRegularFile(fsys, "/"+m) {
+				files = append(files, "/"+m)
+			}
+		}
+	}
+	return files
+}
+
+// cmdlineHasFips returns whether the kernel command line configured in the
+// file at name within fsys contains fips=1. The file's words are compared
+// regardless of its syntax, so that fips=1 is found in a cmdline file, in the
+// options of a boot loader entry, in a quoted GRUB variable, and in a TOML
+// array.
+func cmdlineHasFips(fsys fs.FS, name string) bool {
+	data, err := fs.ReadFile(fsys, fsName(name))
+	if err != nil {
+		return false
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		if strings.HasPrefix(strings.TrimSpace(line), "#") {
+			continue
+		}
+		words := strings.FieldsFunc(line, func(r rune) bool {
+			return r == ' ' || r == '\t' || r == '"' || r == '\'' || r == ',' || r == '[' || r == ']'
+		})
+		for _, w := range words {
+			if w == "fips=1" || strings.HasSuffix(w, "=fips=1") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// initramfsHasFipsModule returns whether the initramfs at name within fsys
+// includes dracut's fips module: its fips.sh script or boot hooks, or an entry
+// in dracut's list of included modules.
+func initramfsHasFipsModule(ctx context.Context, fsys fs.FS, name string) (bool, error) {
+	f, err := fsys.Open(fsName(name))
+	if err != nil {
+		return false, err
+	}
+	defer f.Close()
+	err = readInitramfs(ctx, f, func(name string, r io.Reader) error {
+		name = strings.TrimPrefix(name, "./")
+		base := path.Base(name)
+		if base == "fips.sh" || strings.HasSuffix(base, "fips-boot.sh") {
+			return errFipsModuleFound
+		}
+		if name == "usr/lib/dracut/modules.txt" {
+			sc := bufio.NewScanner(r)
+			for sc.Scan() {
+				if strings.TrimSpace(sc.Text()) == "fips" {
+					return errFipsModuleFound
+				}
+			}
+		}
+		return nil
+	})
+	if errors.Is(err, errFipsModuleFound) {
+		return true, nil
+	}
+	return false, err
+}
+
+// readInitramfs calls fn with the name and contents of each file in the
+// initramfs read from r until fn returns an error. An initramfs is a sequence
+// of cpio archives in the "newc" format, usually an uncompressed one with CPU
+// microcode followed by a compressed one with the actual contents. gzip is
+// decompressed in-process, the other formats dracut supports with their tools,
+// if installed.
+func readInitramfs(ctx context.Context, r io.Reader, fn func(name string, r io.Reader) error) error {
+	br := bufio.NewReader(r)
+	for {
+		magic, err := br.Peek(6)
+		if len(magic) == 0 && errors.Is(err, io.EOF) {
+			return nil
+		}
+		switch {
+		case len(magic) > 0 && magic[0] == 0:
+			// Archives are padded with NUL bytes.
+			if _, err := br.ReadByte(); err != nil {
+				return err
+			}
+		case string(magic) == "070701" || string(magic) == "070702":
+			if err := readCpio(br, fn); err != nil {
+				return err
+			}
+		case bytes.HasPrefix(magic, []byte{0x1f, 0x8b}):
+			gz, err := gzip.NewReader(br)
+			if err != nil {
+				return err
+			}
+			defer gz.Close()
+			return readInitramfs(ctx, gz, fn)
+		default:
+			for _, d := range initramfsDecompressors {
+				if bytes.HasPrefix(magic, d.magic) {
+					return decompressInitramfs(ctx, br, d.tool, fn)
+				}
+			}
+			return fmt.Errorf("unsupported format (magic %x)", magic)
+		}
+	}
+}
+
+// decompressInitramfs reads the rest of an initramfs from r, compressed in a
+// format that tool decompresses.
+func decompressInitramfs(ctx context.Context, r io.Reader, tool string, fn func(name string, r io.Reader) error) error {
+	if !executor.Available(tool) {
+		return fmt.Errorf("compressed with %s, which isn't installed", tool)
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	pr, pw := io.Pipe()
+	done := make(chan error, 1)
+	go func() {
+		stderr, rc, err := executor.ExecuteWithIO(ctx, "", r, pw, tool, "-dc")
+		if err == nil && rc != 0 {
+			err = fmt.Errorf("%s -dc failed, exit code %d: %s", tool, rc, strings.TrimSpace(string(stderr)))
+		}
+		pw.CloseWithError(err)
+		done <- err
+	}()
+	err := readInitramfs(ctx, pr, fn)
+	pr.Close()
+	if err != nil {
+		// Stop decompressing, e.g. once fn found what it looked for.
+		cancel()
+		<-done
+		return err
+	}
+	return <-done
+}
+
+// readCpio calls fn for the regular files of the cpio archive in the "newc"
+// format read from r, up to its trailer.
+func readCpio(r *bufio.Reader, fn func(name string, r io.Reader) error) error {
+	hdr := make([]byte, 110)
+	for {
+		if _, err := io.ReadFull(r, hdr); err != nil {
+			return fmt.Errorf("failed to read cpio header: %v", err)
+		}
+		field := func(i int) (int64, error) {
+			return strconv.ParseInt(string(hdr[6+8*i:14+8*i]), 16, 64)
+		}
+		mode, err := field(1)
+		if err != nil {
+			return fmt.Errorf("invalid cpio header: %v", err)
+		}
+		size, err := field(6)
+		if err != nil {
+			return fmt.Errorf("invalid cpio header: %v", err)
+		}
+		nameSize, err := field(11)
+		if err != nil || nameSize < 1 || nameSize > 4096 {
+			return fmt.Errorf("invalid cpio header: name size %d", nameSize)
+		}
+		name := make([]byte, nameSize+(4-(110+nameSize)%4)%4)
+		if _, err := io.ReadFull(r, name); err != nil {
+			return fmt.Errorf("failed to read cpio entry name: %v", err)
+		}
+		entry := string(name[:nameSize-1])
+		if entry == "TRAILER!!!" {
+			return nil
+		}
+
+		data := &io.LimitedReader{R: r, N: size}
+		if mode&0o170000 == 0o100000 {
+			if err := fn(entry, data); err != nil {
+				return err
+			}
+		}
+		if _, err := io.CopyN(io.Discard, r, data.N+(4-size%4)%4); err != nil {
+			return fmt.Errorf("failed to read cpio entry %s: %v", entry, err)
+		}
+	}
+}
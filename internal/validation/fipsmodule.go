@@ -0,0 +1,215 @@
+package validation
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"debug/elf"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+
+	"github.com/flightctl/fips-validator/internal/rootfs"
+)
+
+var (
+	// fipsModuleDirs are the directories OpenSSL 3 loads provider modules
+	// from on common distributions.
+	fipsModuleDirs = []string{
+		"/usr/lib64/ossl-modules",
+		"/usr/lib/ossl-modules",
+		"/usr/local/lib64/ossl-modules",
+		"/usr/local/lib/ossl-modules",
+	}
+	// fipsModuleConfigs are the common locations of the config file written
+	// by "openssl fipsinstall".
+	fipsModuleConfigs = []string{
+		"/etc/pki/tls/fipsmodule.cnf",
+		"/etc/ssl/fipsmodule.cnf",
+		"/usr/lib/ssl/fipsmodule.cnf",
+		"/usr/local/ssl/fipsmodule.cnf",
+	}
+)
+
+// fipsModuleMACKey is the HMAC key "openssl fipsinstall" uses to compute the
+// module-mac of the FIPS provider unless OpenSSL was built with a custom key.
+const fipsModuleMACKey = "f4556650ac31d35461610bac4ed81b1a181b2d8a43ea2854cbae22ca74560813"
+
+// findFipsModule returns the path of the OpenSSL 3 FIPS provider module within
+// rootPath, or "" if there is none.
+func findFipsModule(rootPath string) string {
+	for _, dir := range fipsModuleDirs {
+		if p := filepath.Join(dir, "fips.so"); isRegularFile(rootfs.FS(rootPath), p) {
+			return p
+		}
+	}
+	return ""
+}
+
+// findFipsModuleConfig returns the path of fipsmodule.cnf within rootPath, or
+// "" if there is none.
+func findFipsModuleConfig(rootPath string) string {
+	for _, p := range fipsModuleConfigs {
+		if isRegularFile(rootfs.FS(rootPath), p) {
+			return p
+		}
+	}
+	return ""
+}
+
+// validateFipsModuleMAC checks that the module-mac recorded in fipsmodule.cnf
+// matches the FIPS provider module. OpenSSL verifies the MAC when loading the
+// provider and refuses to enter FIPS mode if it doesn't match, e.g. because
+// fips.so was updated without re-running "openssl fipsinstall". Distributions
+// that embed the MAC in the module itself don't ship a fipsmodule.cnf, in which
+// case there's nothing to check.
+func validateFipsModuleMAC(rootPath string) []error {
+	config := findFipsModuleConfig(rootPath)
+	if config == "" {
+		return []error{}
+	}
+	module := findFipsModule(rootPath)
+	if module == "" {
+		return []error{checkErrorf(CheckFipsModuleMAC, "%s found, but no FIPS provider module (fips.so)", config)}
+	}
+
+	expected, err := readModuleMAC(rootPath, config)
+	if err != nil {
+		return []error{checkErrorf(CheckFipsModuleMAC, "%s: %v", config, err)}
+	}
+	actual, err := computeModuleMAC(rootPath, module)
+	if err != nil {
+		return []error{checkErrorf(CheckFipsModuleMAC, "failed to compute MAC of %s: %v", module, err)}
+	}
+	if !hmac.Equal(expected, actual) {
+		return []error{checkErrorf(CheckFipsModuleMAC, "module-mac in %s doesn't match %s", config, module)}
+	}
+	return []error{}
+}
+
+// readModuleMAC reads the module-mac entry of a fipsmodule.cnf, which holds
+// the MAC as colon-separated hex bytes.
+func readModuleMAC(rootPath, config string) ([]byte, error) {
+	f, err := openInRoot(rootPath, config)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		key, value, ok := strings.Cut(sc.Text(), "=")
+		if !ok || strings.TrimSpace(key) != "module-mac" {
+			continue
+		}
+		mac, err := hex.DecodeString(strings.ReplaceAll(strings.TrimSpace(value), ":", ""))
+		if err != nil || len(mac) != sha256.Size {
+			return nil, fmt.Errorf("malformed module-mac %q", strings.TrimSpace(value))
+		}
+		return mac, nil
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	return nil, fmt.Errorf("missing module-mac entry")
+}
+
+func computeModuleMAC(rootPath, module string) ([]byte, error) {
+	f, err := openInRoot(rootPath, module)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	key, _ := hex.DecodeString(fipsModuleMACKey)
+	mac := hmac.New(sha256.New, key)
+	if _, err := io.Copy(mac, f); err != nil {
+		return nil, err
+	}
+	return mac.Sum(nil), nil
+}
+
+// openInRoot opens path within the root filesystem at rootPath, resolving
+// symlinks as if rootPath was mounted at "/".
+func openInRoot(rootPath, path string) (*os.File, error) {
+	resolved, err := rootfs.Resolve(rootPath, path)
+	if err != nil {
+		return nil, err
+	}
+	return os.Open(filepath.Join(rootPath, resolved))
+}
+
+// providerVersionRegex matches the version string of an OpenSSL provider, e.g.
+// "3.0.7" or, with distribution build info, "3.0.7-395c1a240fbfffd8".
+var providerVersionRegex = regexp.MustCompile(`^(\d+\.\d+\.\d+)([-+][0-9A-Za-z.+-]*)?$`)
+
+// fipsProviderVersion returns the version of the FIPS provider module at
+// module within rootPath. Providers report their name and version from
+// constant strings, which the compiler places next to each other, so the
+// version is the first version string following the provider name, e.g.
+// "OpenSSL FIPS Provider", in the read-only data.
+func fipsProviderVersion(rootPath, module string) (string, error) {
+	f, err := openInRoot(rootPath, module)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	file, err := elf.NewFile(f)
+	if err != nil {
+		return "", err
+	}
+	rodata := file.Section(".rodata")
+	if rodata == nil {
+		return "", fmt.Errorf("no .rodata section")
+	}
+	data, err := rodata.Data()
+	if err != nil {
+		return "", err
+	}
+
+	strs := bytes.Split(data, []byte{0})
+	for i, str := range strs {
+		if !bytes.HasSuffix(str, []byte("FIPS Provider")) {
+			continue
+		}
+		for _, next := range strs[i+1 : min(i+16, len(strs))] {
+			if providerVersionRegex.Match(next) {
+				return string(next), nil
+			}
+		}
+	}
+	return "", fmt.Errorf("no version string found")
+}
+
+// validateFipsProviderVersion checks that the version of the FIPS provider
+// module satisfies constraint and returns the version found. Build info
+// appended to the version, as done by distributions, is ignored.
+func validateFipsProviderVersion(rootPath string, constraint string) (string, []error) {
+	c, err := semver.NewConstraint(constraint)
+	if err != nil {
+		return "", []error{fmt.Errorf("invalid FIPS provider version constraint %q: %v", constraint, err)}
+	}
+	module := findFipsModule(rootPath)
+	if module == "" {
+		return "", []error{checkErrorf(CheckFipsProviderVersion, "FIPS provider module (fips.so) not found, but version %s is required", constraint)}
+	}
+	version, err := fipsProviderVersion(rootPath, module)
+	if err != nil {
+		return "", []error{checkErrorf(CheckFipsProviderVersion, "failed to determine version of %s: %v", module, err)}
+	}
+	v, err := semver.NewVersion(providerVersionRegex.FindStringSubmatch(version)[1])
+	if err != nil {
+		return version, []error{checkErrorf(CheckFipsProviderVersion, "failed to parse version %q of %s: %v", version, module, err)}
+	}
+	if !c.Check(v) {
+		return version, []error{checkErrorf(CheckFipsProviderVersion, "FIPS provider %s has version %s, but %s is required", module, version, constraint)}
+	}
+	return version, []error{}
+}
@@ -0,0 +1,53 @@
+package validation
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"slices"
+
+	"github.com/flightctl/fips-validator/pkg/elfinfo"
+)
+
+// validateHardcodedLibcrypto checks the absolute libcrypto paths embedded in
+// the read-only data of the binary read from r, which it likely passes to
+// dlopen() instead of linking libcrypto, so that the library is loaded without
+// the resolution validateOpenSSLLinkage checks. A library found at such a path
+// must be FIPS-capable. Paths outside the standard library directories are
+// reported as warnings, as they bypass the system's OpenSSL installation even
+// if they don't exist in the target. Paths in the standard directories that
+// don't exist are only candidates the binary tries in turn and aren't
+// reported.
+func validateHardcodedLibcrypto(fsys fs.FS, r io.ReaderAt, info *elfinfo.ElfInfo, match SymbolMatch, debugFunc func(string, ...interface{})) []error {
+	paths, err := elfinfo.FindPaths(r, "libcrypto.so")
+	if err != nil {
+		debugFunc("failed to search read-only data for libcrypto paths: %v", err)
+		return nil
+	}
+	var errs []error
+	for _, p := range paths {
+		debugFunc("found hardcoded libcrypto path %s", p)
+		if !slices.Contains(defaultLibraryPaths(fsys, info), path.Dir(p)) {
+			errs = append(errs, &CheckError{
+				Check:    CheckHardcodedLibcrypto,
+				Err:      fmt.Errorf("hardcodes libcrypto path %s outside the standard library directories", p),
+				Hint:     "load libcrypto by its SONAME, e.g. dlopen(\"libcrypto.so.3\"), or link it",
+				Severity: SeverityWarning,
+			})
+		}
+		if !isRegularFile(fsys, p) {
+			debugFunc("hardcoded libcrypto path %s doesn't exist in the root filesystem", p)
+			continue
+		}
+		libInfo, err := readLibrary(fsys, p)
+		if err != nil {
+			errs = append(errs, checkErrorf(CheckHardcodedLibcrypto, "failed to read %s: %v", p, err))
+			continue
+		}
+		if fipsModeSymbol(libInfo, match) == "" {
+			errs = append(errs, checkErrorf(CheckHardcodedLibcrypto, "hardcodes libcrypto path %s, which is not FIPS-capable", p))
+		}
+	}
+	return errs
+}
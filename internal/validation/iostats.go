@@ -0,0 +1,37 @@
+package validation
+
+import (
+	"sync/atomic"
+)
+
+// IOStats counts the I/O performed to validate binaries and libraries. Slow
+// scans that read many bytes per second of CPU time are I/O-bound, e.g. on
+// network or overlay file systems. Files read by external tools such as nm
+// aren't counted.
+type IOStats struct {
+	// FilesOpened is the number of binaries, libraries, and debuginfo
+	// files opened.
+	FilesOpened int64
+	// BytesRead is the number of bytes read from them, including ELF
+	// headers, symbol tables, and Go build info.
+	BytesRead int64
+}
+
+var filesOpened, bytesRead atomic.Int64
+
+// ReadIOStats returns the I/O counters accumulated by all validations of the
+// process so far.
+func ReadIOStats() IOStats {
+	return IOStats{FilesOpened: filesOpened.Load(), BytesRead: bytesRead.Load()}
+}
+
+// countingFile counts the bytes read from a file.
+type countingFile struct {
+	readerAtFile
+}
+
+func (f countingFile) ReadAt(p []byte, off int64) (int, error) {
+	n, err := f.readerAtFile.ReadAt(p, off)
+	bytesRead.Add(int64(n))
+	return n, err
+}
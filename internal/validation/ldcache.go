@@ -0,0 +1,223 @@
+package validation
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"time"
+
+	"github.com/flightctl/fips-validator/internal/rootfs"
+)
+
+const (
+	ldCachePath = "/etc/ld.so.cache"
+	// ldCacheMagic starts the cache format written by glibc 2.32 and later.
+	// Older versions prefix it with a cache in the libc5 format, which
+	// starts with ldCacheOldMagic.
+	ldCacheMagic    = "glibc-ld.so.cache1.1"
+	ldCacheOldMagic = "ld.so-1.7.0"
+
+	ldCacheHeaderSize   = 48
+	ldCacheEntrySize    = 24
+	ldCacheOldEntrySize = 12
+)
+
+// ldCacheEntry maps a SONAME to the path of a library in ld.so.cache.
+type ldCacheEntry struct {
+	// flags encode the library's type and architecture. Libraries with
+	// the same SONAME but different flags, e.g. of a 32-bit multilib, are
+	// looked up independently.
+	flags  int32
+	soname string
+	path   string
+}
+
+// parseLdCache parses an ld.so.cache in the glibc format, optionally prefixed
+// with a cache in the old format. The entries are in the order in which the
+// dynamic loader considers them: the first entry for a SONAME wins.
+func parseLdCache(data []byte) ([]ldCacheEntry, error) {
+	if bytes.HasPrefix(data, []byte(ldCacheOldMagic)) {
+		if len(data) < 16 {
+			return nil, errors.New("truncated header")
+		}
+		nlibs := binary.LittleEndian.Uint32(data[12:])
+		// The new format follows, aligned to 8 bytes.
+		offset := (16 + uint64(nlibs)*ldCacheOldEntrySize + 7) &^ 7
+		if offset > uint64(len(data)) {
+			return nil, errors.New("no cache in the glibc 2.x format")
+		}
+		data = data[offset:]
+	}
+	if !bytes.HasPrefix(data, []byte(ldCacheMagic)) {
+		return nil, errors.New("not an ld.so.cache in the glibc 2.x format")
+	}
+	if len(data) < ldCacheHeaderSize {
+		return nil, errors.New("truncated header")
+	}
+	// The cache is written in the byte order of the system, which
+	// ldconfig records in the low bits of the flags.
+	var order binary.ByteOrder = binary.LittleEndian
+	if data[28]&3 == 3 {
+		order = binary.BigEndian
+	}
+	nlibs := uint64(order.Uint32(data[20:]))
+	if ldCacheHeaderSize+nlibs*ldCacheEntrySize > uint64(len(data)) {
+		return nil, fmt.Errorf("truncated: %d entries don't fit in %d bytes", nlibs, len(data))
+	}
+	// String offsets are relative to the start of the header.
+	str := func(off uint32) (string, error) {
+		if uint64(off) >= uint64(len(data)) {
+			return "", fmt.Errorf("string offset %d out of range", off)
+		}
+		s, _, _ := bytes.Cut(data[off:], []byte{0})
+		return string(s), nil
+	}
+	entries := make([]ldCacheEntry, 0, nlibs)
+	for i := uint64(0); i < nlibs; i++ {
+		e := data[ldCacheHeaderSize+i*ldCacheEntrySize:]
+		soname, err := str(order.Uint32(e[4:]))
+		if err != nil {
+			return nil, fmt.Errorf("entry %d: %v", i, err)
+		}
+		path, err := str(order.Uint32(e[8:]))
+		if err != nil {
+			return nil, fmt.Errorf("entry %d: %v", i, err)
+		}
+		entries = append(entries, ldCacheEntry{flags: int32(order.Uint32(e)), soname: soname, path: path})
+	}
+	return entries, nil
+}
+
+// validateLdCache warns if the ld.so.cache within rootPath is stale or would
+// make the dynamic loader resolve a libcrypto SONAME to a library that isn't
+// FIPS-capable while a FIPS-capable one with the same SONAME is installed.
+// libs are the results of the libcrypto libraries in the standard library
+// directories; other libraries are checked for FIPS capability as set by match.
+// Root filesystems without a cache aren't reported, as the loader then only
+// searches the standard library directories.
+func validateLdCache(rootPath string, libs []LibcryptoResult, match SymbolMatch) []error {
+	fsys := rootfs.FS(rootPath)
+	cache, err := fs.Stat(fsys, fsName(ldCachePath))
+	if err != nil {
+		return nil
+	}
+	data, err := fs.ReadFile(fsys, fsName(ldCachePath))
+	if err != nil {
+		return []error{ldCacheWarning(fmt.Errorf("failed to read %s: %v", ldCachePath, err), "")}
+	}
+	entries, err := parseLdCache(data)
+	if err != nil {
+		return []error{ldCacheWarning(fmt.Errorf("%s is corrupt: %v", ldCachePath, err), "run ldconfig in the image to rebuild the cache")}
+	}
+
+	var errs []error
+	if conf := changedLdConf(fsys, cache.ModTime()); conf != "" {
+		errs = append(errs, ldCacheWarning(
+			fmt.Errorf("%s was changed after %s was built, so the cache may be stale", conf, ldCachePath),
+			"run ldconfig in the image after changing the loader configuration"))
+	}
+
+	type lookup struct {
+		soname string
+		flags  int32
+	}
+	seen := map[lookup]bool{}
+	for i, e := range entries {
+		if !cryptoLibRegex.MatchString(e.soname) {
+			continue
+		}
+		if _, err := rootfs.Stat(rootPath, e.path); err != nil {
+			errs = append(errs, ldCacheWarning(
+				fmt.Errorf("%s lists %s for %s, which doesn't exist, so the cache is stale", ldCachePath, e.path, e.soname),
+				"run ldconfig in the image to rebuild the cache"))
+			continue
+		}
+		l := lookup{e.soname, e.flags}
+		if seen[l] {
+			continue
+		}
+		seen[l] = true
+
+		// e is the library the loader resolves the SONAME to.
+		winner := libcryptoAt(rootPath, e.path, libs, match)
+		if winner.FIPSCapable {
+			continue
+		}
+		if fips := fipsCapableAlternative(rootPath, e, entries[i+1:], winner, libs, match); fips != "" {
+			errs = append(errs, ldCacheWarning(
+				fmt.Errorf("%s resolves %s to %s, which isn't FIPS-capable, ahead of the FIPS-capable %s", ldCachePath, e.soname, e.path, fips),
+				fmt.Sprintf("remove %s or its directory from /etc/ld.so.conf and run ldconfig in the image", e.path)))
+		}
+	}
+	return errs
+}
+
+// fipsCapableAlternative returns the path of a FIPS-capable library that
+// provides the SONAME of winner, either listed after it in the cache or
+// installed in a standard library directory, or "" if there is none.
+func fipsCapableAlternative(rootPath string, winner ldCacheEntry, later []ldCacheEntry, winnerResult LibcryptoResult, libs []LibcryptoResult, match SymbolMatch) string {
+	for _, e := range later {
+		if e.soname == winner.soname && e.flags == winner.flags && libcryptoAt(rootPath, e.path, libs, match).FIPSCapable {
+			return e.path
+		}
+	}
+	for _, lib := range libs {
+		if lib.FIPSCapable && lib.Soname == winner.soname && (winnerResult.Arch == "" || lib.Arch == winnerResult.Arch) {
+			return lib.Path
+		}
+	}
+	return ""
+}
+
+// libcryptoAt returns the result of the libcrypto at path within rootPath,
+// following symlinks. The results of the libraries in the standard library
+// directories are reused; other libraries are read in-process. Libraries that
+// can't be read are reported as not FIPS-capable.
+func libcryptoAt(rootPath, path string, libs []LibcryptoResult, match SymbolMatch) LibcryptoResult {
+	resolved, err := rootfs.Resolve(rootPath, path)
+	if err != nil {
+		return LibcryptoResult{Path: path}
+	}
+	for _, lib := range libs {
+		if lib.Path == resolved {
+			return lib
+		}
+	}
+	result := LibcryptoResult{Path: resolved}
+	info, err := readLibrary(rootfs.FS(rootPath), resolved)
+	if err != nil || !info.IsElf {
+		return result
+	}
+	result.Arch, result.Soname = info.Arch, info.Soname
+	result.Symbol = fipsModeSymbol(info, match)
+	result.FIPSCapable = result.Symbol != ""
+	return result
+}
+
+// changedLdConf returns the first of /etc/ld.so.conf and the files in
+// /etc/ld.so.conf.d that was modified after built, or "" if there is none.
+// ldconfig builds the cache from these files, so a newer one means that the
+// cache may not reflect the configuration.
+func changedLdConf(fsys fs.FS, built time.Time) string {
+	confs := []string{"/etc/ld.so.conf"}
+	if entries, err := fs.ReadDir(fsys, fsName("/etc/ld.so.conf.d")); err == nil {
+		for _, e := range entries {
+			if filepath.Ext(e.Name()) == ".conf" {
+				confs = append(confs, "/etc/ld.so.conf.d/"+e.Name())
+			}
+		}
+	}
+	for _, conf := range confs {
+		if fi, err := fs.Stat(fsys, fsName(conf)); err == nil && fi.ModTime().After(built) {
+			return conf
+		}
+	}
+	return ""
+}
+
+func ldCacheWarning(err error, hint string) error {
+	return &CheckError{Check: CheckLdCache, Err: err, Hint: hint, Severity: SeverityWarning}
+}
@@ -0,0 +1,121 @@
+package validation
+
+import (
+	"debug/elf"
+	"io/fs"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/flightctl/fips-validator/pkg/elfinfo"
+)
+
+var opensslLibRegex = regexp.MustCompile(`^lib(ssl|crypto)\.so\.(.+)$`)
+
+// opensslSeries returns the OpenSSL release series, e.g. "1.1" or "3", of a
+// libssl or libcrypto SONAME, or false if soname isn't one of them.
+func opensslSeries(soname string) (string, bool) {
+	m := opensslLibRegex.FindStringSubmatch(soname)
+	if m == nil {
+		return "", false
+	}
+	switch v := m[2]; {
+	case v == "10": // RHEL 7 ships OpenSSL 1.0.2 as libcrypto.so.10.
+		return "1.0", true
+	case strings.HasPrefix(v, "1.0"), strings.HasPrefix(v, "1.1"):
+		return v[:3], true
+	default:
+		major, _, _ := strings.Cut(v, ".")
+		return major, true
+	}
+}
+
+// validateOpenSSLLinkage checks that the libssl and libcrypto a binary links
+// belong to the same OpenSSL release series, also for the libcrypto linked by
+// the libssl found in the root filesystem, that the libraries found for them
+// have the SONAME the binary links, and that the libcrypto the binary loads is
+// FIPS-capable. Mixing series, e.g. a bundled libssl.so.1.1 with the
+// system's libcrypto.so.3, means that some crypto doesn't go through the
+// FIPS-capable library.
+func validateOpenSSLLinkage(fsys fs.FS, path string, info *elfinfo.ElfInfo, libcrypto string, match SymbolMatch, debugFunc func(string, ...interface{})) []error {
+	var errs []error
+
+	var linked []string
+	series := map[string]string{}
+	for _, soname := range info.Needed {
+		if s, ok := opensslSeries(soname); ok {
+			linked = append(linked, soname)
+			series[soname] = s
+		}
+	}
+	for i := 1; i < len(linked); i++ {
+		if series[linked[i]] != series[linked[0]] {
+			errs = append(errs, checkErrorf(CheckOpenSSLLinkage, "links OpenSSL libraries of different versions: %s (OpenSSL %s) and %s (OpenSSL %s)",
+				linked[0], series[linked[0]], linked[i], series[linked[i]]))
+			break
+		}
+	}
+
+	for _, soname := range linked {
+		lib := resolveLibrary(fsys, path, info, soname)
+		if lib == "" {
+			continue
+		}
+		libInfo, err := readLibrary(fsys, lib)
+		if err != nil {
+			errs = append(errs, checkErrorf(CheckOpenSSLLinkage, "failed to read %s: %v", lib, err))
+			continue
+		}
+		// The loader finds libraries by file name, so a misnamed file,
+		// e.g. a development symlink pointing to another version, is
+		// loaded in place of the library the binary was linked against.
+		if libInfo.Soname != "" && libInfo.Soname != soname {
+			errs = append(errs, checkErrorf(CheckOpenSSLLinkage, "%s loaded from %s has SONAME %s", soname, lib, libInfo.Soname))
+			continue
+		}
+		if !strings.HasPrefix(soname, "libssl.") {
+			continue
+		}
+		for _, dep := range libInfo.Needed {
+			if s, ok := opensslSeries(dep); ok && s != series[soname] {
+				errs = append(errs, checkErrorf(CheckOpenSSLLinkage, "%s loaded from %s links %s (OpenSSL %s)", soname, lib, dep, s))
+			}
+		}
+	}
+
+	if libcrypto == "" {
+		// RPM payloads and single binaries usually don't come with
+		// libcrypto, so there's nothing to check.
+		debugFunc("libcrypto not found in the root filesystem, not checking whether it is FIPS-capable")
+		return errs
+	}
+	libInfo, err := readLibrary(fsys, libcrypto)
+	if err != nil {
+		return append(errs, checkErrorf(CheckOpenSSLLinkage, "failed to read %s: %v", libcrypto, err))
+	}
+	if fipsModeSymbol(libInfo, match) == "" {
+		errs = append(errs, checkErrorf(CheckOpenSSLLinkage, "loads %s, which is not FIPS-capable", libcrypto))
+	}
+	return errs
+}
+
+// readLibrary reads the ELF info of the shared library at path within fsys.
+func readLibrary(fsys fs.FS, path string) (*elfinfo.ElfInfo, error) {
+	f, err := openFile(fsys, path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return elfinfo.ReadFrom(f)
+}
+
+// definesAnyFunction returns whether a shared library exports a function with
+// one of the given names.
+func definesAnyFunction(info *elfinfo.ElfInfo, names []string) bool {
+	for _, sym := range info.DynamicSymbols {
+		if sym.Section != elf.SHN_UNDEF && elf.ST_TYPE(sym.Info) == elf.STT_FUNC && slices.Contains(names, sym.Name) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,238 @@
+package validation
+
+import (
+	"debug/buildinfo"
+	"errors"
+	"fmt"
+	"path"
+	"runtime/debug"
+	"slices"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+
+	"github.com/flightctl/fips-validator/pkg/elfinfo"
+)
+
+// BannedModule bans a Go module, or some of its versions, from FIPS builds.
+type BannedModule struct {
+	// Path is the module path, e.g. "github.com/example/tls", or a glob
+	// pattern as accepted by path.Match, e.g. "github.com/example/*".
+	Path string `yaml:"path"`
+	// Versions is a semver constraint, e.g. "< 1.4.0", that restricts the
+	// ban to some versions. If empty, all versions are banned.
+	Versions string `yaml:"versions"`
+	// Reason documents why the module is banned.
+	Reason string `yaml:"reason"`
+}
+
+func (m *BannedModule) validate() error {
+	if m.Path == "" {
+		return errors.New("path must be set")
+	}
+	if _, err := path.Match(m.Path, ""); err != nil {
+		return fmt.Errorf("path: invalid pattern %q: %v", m.Path, err)
+	}
+	if m.Versions != "" {
+		if _, err := semver.NewConstraint(m.Versions); err != nil {
+			return fmt.Errorf("versions: invalid constraint %q: %v", m.Versions, err)
+		}
+	}
+	return nil
+}
+
+// matches returns whether the ban applies to the module at the given path and
+// version. Versions that aren't valid semver, e.g. of modules replaced by a
+// local directory, are banned unless they can be shown to be allowed.
+func (m *BannedModule) matches(modPath, version string) bool {
+	if ok, _ := path.Match(m.Path, modPath); !ok {
+		return false
+	}
+	if m.Versions == "" {
+		return true
+	}
+	c, err := semver.NewConstraint(m.Versions)
+	if err != nil {
+		return true
+	}
+	v, err := semver.NewVersion(version)
+	if err != nil {
+		return true
+	}
+	// Pseudo-versions, e.g. v0.0.0-20240101000000-abcdef123456, are
+	// prereleases in semver terms, but must be covered by the ban.
+	c.IncludePrerelease = true
+	return c.Check(v)
+}
+
+// validateBannedModules fails Go binaries that depend on a module banned by the
+// policy. Replaced modules are reported if either the original module or its
+// replacement is banned.
+func validateBannedModules(deps []*debug.Module, policy *Policy) []error {
+	errs := []error{}
+	for _, dep := range deps {
+		mods := []*debug.Module{dep}
+		if dep.Replace != nil {
+			mods = append(mods, dep.Replace)
+		}
+	bans:
+		for i := range policy.BannedModules {
+			ban := &policy.BannedModules[i]
+			for _, mod := range mods {
+				if !ban.matches(mod.Path, mod.Version) {
+					continue
+				}
+				msg := fmt.Sprintf("depends on banned module %s@%s", mod.Path, mod.Version)
+				if mod != dep {
+					msg += fmt.Sprintf(" (replacing %s@%s)", dep.Path, dep.Version)
+				}
+				if ban.Reason != "" {
+					msg += ": " + ban.Reason
+				}
+				errs = append(errs, checkErrorf(CheckGoBannedModules, "%s", msg))
+				break bans
+			}
+		}
+	}
+	return errs
+}
+
+// golangFIPSModule is the module path of golang-fips/openssl. Patched Go
+// toolchains vendor it into the standard library, where its packages are
+// prefixed with "vendor/", while programs may also depend on it as a module.
+const golangFIPSModule = "github.com/golang-fips/openssl/v2"
+
+// validateGoOpenSSLModule fails Go binaries whose OpenSSL bindings don't come
+// from golang-fips/openssl as vendored by the toolchain or as a module in the
+// binary's dependencies: binaries defining a dlopen function in another
+// package named like an OpenSSL binding, e.g. a renamed fork, binaries that
+// replace golang-fips/openssl with another module, and binaries defining its
+// functions without depending on it.
+func validateGoOpenSSLModule(info *elfinfo.ElfInfo, bi *buildinfo.BuildInfo, policy *Policy) []error {
+	errs := []error{}
+	var dep *debug.Module
+	for _, d := range bi.Deps {
+		if d.Path == golangFIPSModule {
+			dep = d
+			break
+		}
+	}
+	if dep != nil && dep.Replace != nil && dep.Replace.Path != golangFIPSModule {
+		errs = append(errs, checkErrorf(CheckGoOpenSSLModule, "replaces %s with %s", golangFIPSModule, dep.Replace.Path))
+	}
+
+	seen := map[string]bool{}
+	for _, sym := range policy.symbols(info) {
+		pkg, ok := strings.CutSuffix(sym.Name, ".dlopen")
+		if !ok || seen[pkg] || !strings.Contains(pkg, "openssl") {
+			continue
+		}
+		if _, ok := symbolSection(info, policy, sym); !ok || !isDefinition(sym) {
+			continue
+		}
+		seen[pkg] = true
+		switch {
+		case pkg == "vendor/"+golangFIPSModule:
+		case pkg == golangFIPSModule:
+			if dep == nil {
+				errs = append(errs, checkErrorf(CheckGoOpenSSLModule, "defines %s, but doesn't depend on the %s module", sym.Name, golangFIPSModule))
+			}
+		default:
+			msg := fmt.Sprintf("defines %s, which isn't part of golang-fips/openssl", sym.Name)
+			if mod := moduleOf(bi, pkg); mod != nil {
+				msg += fmt.Sprintf(" but of module %s@%s", mod.Path, mod.Version)
+				if mod.Replace != nil {
+					msg += fmt.Sprintf(" (replaced by %s)", mod.Replace.Path)
+				}
+			}
+			errs = append(errs, checkErrorf(CheckGoOpenSSLModule, "%s", msg))
+		}
+	}
+	return errs
+}
+
+// moduleOf returns the module of the binary that provides the package with the
+// given import path, or nil if it isn't known.
+func moduleOf(bi *buildinfo.BuildInfo, pkg string) *debug.Module {
+	var found *debug.Module
+	for _, m := range append([]*debug.Module{&bi.Main}, bi.Deps...) {
+		if m.Path == "" || (pkg != m.Path && !strings.HasPrefix(pkg, m.Path+"/")) {
+			continue
+		}
+		if found == nil || len(m.Path) > len(found.Path) {
+			found = m
+		}
+	}
+	return found
+}
+
+// validatePureGoCrypto reports Go binaries that use the golang-fips/openssl
+// backend, but also link a module of the policy's pure-Go crypto modules, e.g.
+// a fork of crypto/tls pulled in by an indirect dependency. The binary passes
+// the other checks, since its standard library crypto goes through OpenSSL,
+// while the module's crypto runs outside the FIPS boundary. A module counts as
+// linked if the binary defines a symbol of one of its packages, or, if the
+// binary has no symbols, if it's among the dependencies.
+func validatePureGoCrypto(info *elfinfo.ElfInfo, bi *buildinfo.BuildInfo, policy *Policy) []error {
+	severity, enforced := policy.PureGoCrypto.Enforcement.severity()
+	if !enforced || len(policy.PureGoCrypto.Modules) == 0 {
+		return []error{}
+	}
+	if moduleOf(bi, golangFIPSModule) == nil && !hasDefinedSymbol(info, policy, golangFIPSDlopenSymbol) && !hasDefinedSymbol(info, policy, golangFIPSModule+".dlopen") {
+		return []error{}
+	}
+
+	// linked holds the modules that define symbols.
+	symbols := policy.symbols(info)
+	linked := map[*debug.Module]bool{}
+	for _, sym := range symbols {
+		if _, ok := symbolSection(info, policy, sym); !ok || !isDefinition(sym) {
+			continue
+		}
+		if mod := moduleOf(bi, symbolPackage(sym.Name)); mod != nil {
+			linked[mod] = true
+		}
+	}
+
+	errs := []error{}
+	for _, dep := range bi.Deps {
+		if len(symbols) > 0 && !linked[dep] {
+			continue
+		}
+		mod := dep
+		if dep.Replace != nil {
+			mod = dep.Replace
+		}
+		if !slices.ContainsFunc(policy.PureGoCrypto.Modules, func(p string) bool {
+			ok, _ := path.Match(p, dep.Path)
+			okReplace, _ := path.Match(p, mod.Path)
+			return ok || okReplace
+		}) {
+			continue
+		}
+		msg := fmt.Sprintf("uses the golang-fips/openssl backend, but also links pure-Go crypto module %s@%s", mod.Path, mod.Version)
+		if mod != dep {
+			msg += fmt.Sprintf(" (replacing %s@%s)", dep.Path, dep.Version)
+		}
+		errs = append(errs, &CheckError{
+			Check:    CheckGoPureCrypto,
+			Err:      fmt.Errorf("%s, whose crypto bypasses OpenSSL", msg),
+			Severity: severity,
+		})
+	}
+	return errs
+}
+
+// symbolPackage returns the import path of the package defining the Go symbol
+// with the given name, e.g. "github.com/cloudflare/circl/sign/ed448" for
+// "github.com/cloudflare/circl/sign/ed448.(*PrivateKey).Sign".
+func symbolPackage(name string) string {
+	if i := strings.IndexAny(name, "(["); i >= 0 {
+		name = name[:i]
+	}
+	slash := strings.LastIndex(name, "/")
+	if i := strings.Index(name[slash+1:], "."); i >= 0 {
+		return name[:slash+1+i]
+	}
+	return name
+}
@@ -0,0 +1,85 @@
+package validation
+
+import (
+	"fmt"
+	"io/fs"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/flightctl/fips-validator/internal/rootfs"
+	"github.com/flightctl/fips-validator/pkg/elfinfo"
+)
+
+// muslLibPaths are the directories musl's dynamic loader searches if there is
+// no /etc/ld-musl-<arch>.path.
+var muslLibPaths = []string{"/lib", "/usr/local/lib", "/usr/lib"}
+
+// muslUnsupported explains why musl environments are reported.
+const muslUnsupported = "FIPS mode is generally unsupported on musl"
+
+// defaultLibraryPaths returns the directories that the dynamic loader of the
+// binary searches after its DT_RPATH and DT_RUNPATH.
+func defaultLibraryPaths(fsys fs.FS, info *elfinfo.ElfInfo) []string {
+	if info.Libc() == elfinfo.LibcMusl {
+		return muslLibraryPaths(fsys, info.Interpreter)
+	}
+	return libPaths
+}
+
+// muslLibraryPaths returns the directories searched by the musl dynamic loader
+// at interp, which are read from /etc/ld-musl-<arch>.path if it exists.
+func muslLibraryPaths(fsys fs.FS, interp string) []string {
+	arch := strings.TrimSuffix(strings.TrimPrefix(path.Base(interp), "ld-musl-"), ".so.1")
+	data, err := fs.ReadFile(fsys, fsName("/etc/ld-musl-"+arch+".path"))
+	if err != nil {
+		return muslLibPaths
+	}
+	var dirs []string
+	for _, dir := range strings.FieldsFunc(string(data), func(r rune) bool { return r == ':' || r == '\n' }) {
+		if dir = strings.TrimSpace(dir); dir != "" {
+			dirs = append(dirs, dir)
+		}
+	}
+	return dirs
+}
+
+// findMuslLoader returns the path of the musl dynamic loader within rootPath,
+// or "" if the root filesystem isn't a musl environment, e.g. Alpine.
+func findMuslLoader(rootPath string) string {
+	matches, _ := fs.Glob(rootfs.FS(rootPath), "lib/ld-musl-*.so.1")
+	if len(matches) == 0 {
+		return ""
+	}
+	return "/" + matches[0]
+}
+
+// cryptoLibDirs returns the directories searched for libcrypto in the root
+// filesystem at rootPath.
+func cryptoLibDirs(rootPath string) []string {
+	loader := findMuslLoader(rootPath)
+	if loader == "" {
+		return libPaths
+	}
+	dirs := slices.Clone(libPaths)
+	for _, dir := range muslLibraryPaths(rootfs.FS(rootPath), loader) {
+		if !slices.Contains(dirs, dir) {
+			dirs = append(dirs, dir)
+		}
+	}
+	return dirs
+}
+
+// validateLibc reports binaries linked against musl, on which crypto usually
+// can't be FIPS-validated. This is informational, as the other checks still
+// apply.
+func validateLibc(info *elfinfo.ElfInfo) []error {
+	if info.Libc() != elfinfo.LibcMusl {
+		return []error{}
+	}
+	return []error{&CheckError{
+		Check:    CheckLibc,
+		Err:      fmt.Errorf("linked against musl libc (%s); %s", info.Interpreter, muslUnsupported),
+		Severity: SeverityInfo,
+	}}
+}
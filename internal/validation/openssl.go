@@ -8,61 +8,244 @@ import (
 	"os"
 	"path/filepath"
 	"regexp"
-
-	"github.com/fatih/color"
+	"strings"
 
 	"github.com/flightctl/fips-validator/internal/executor"
+	"github.com/flightctl/fips-validator/internal/rootfs"
+	"github.com/flightctl/fips-validator/pkg/elfinfo"
 )
 
 var libPaths = []string{"/lib64", "/usr/lib64", "/lib", "/usr/lib"}
 var cryptoLibRegex = regexp.MustCompile(`^libcrypto.*\.so($|\..*)`)
 
-func ValidateOpenSSL(ctx context.Context, rootPath string) bool {
-	var errs []error
-	success := color.New(color.Bold, color.FgGreen).PrintfFunc()
-	failure := color.New(color.Bold, color.FgRed).PrintfFunc()
-	red := color.New(color.Bold, color.FgRed).SprintfFunc()
+// fipsSymbols are the functions of which a FIPS-capable libcrypto defines at
+// least one: FIPS_mode in OpenSSL 1.x (fips_mode in some forks) and
+// EVP_default_properties_is_fips_enabled in OpenSSL 3.
+var fipsSymbols = []string{"FIPS_mode", "fips_mode", "EVP_default_properties_is_fips_enabled"}
+
+// OpenSSLResult is the result of validating the OpenSSL installation of a
+// root filesystem.
+type OpenSSLResult struct {
+	Valid bool `json:"valid"`
+	// Libraries lists the libcrypto libraries found in the standard library
+	// directories.
+	Libraries []LibcryptoResult `json:"libraries"`
+	// ProviderVersion is the version of the FIPS provider module, if the
+	// policy requires a version.
+	ProviderVersion string    `json:"providerVersion,omitempty"`
+	Findings        []Finding `json:"findings,omitempty"`
+}
 
-	fmt.Printf("• validating libcrypto is present and FIPS-capable... ")
+// LibcryptoResult is the verdict on a single libcrypto library.
+type LibcryptoResult struct {
+	Path string `json:"path"`
+	// Arch is the library's architecture in Go's naming (GOARCH), if it
+	// is an ELF file.
+	Arch string `json:"arch,omitempty"`
+	// Soname is the library's DT_SONAME, e.g. "libcrypto.so.3", which can
+	// differ from its file name.
+	Soname      string `json:"soname,omitempty"`
+	FIPSCapable bool   `json:"fipsCapable"`
+	// Symbol is the FIPS mode function that showed the library to be
+	// FIPS-capable, see fipsSymbols.
+	Symbol string `json:"symbol,omitempty"`
+}
+
+// ValidateOpenSSL validates that the root filesystem at rootPath contains a
+// FIPS-capable libcrypto. If the policy enables it, it also verifies the
+// integrity MAC and the version of the OpenSSL 3 FIPS provider module.
+// Problems with the installation are reported as findings of the result; the
+// error is only set if validation couldn't be completed.
+func ValidateOpenSSL(ctx context.Context, rootPath string, policy *Policy) (*OpenSSLResult, error) {
+	var errs []error
+	result := &OpenSSLResult{Libraries: []LibcryptoResult{}}
 
-	cryptoLibs := findCryptoLibs(rootPath)
+	musl := findMuslLoader(rootPath)
+	if musl != "" {
+		errs = append(errs, &CheckError{
+			Check:    CheckLibc,
+			Err:      fmt.Errorf("musl libc environment detected (%s); %s", musl, muslUnsupported),
+			Severity: SeverityInfo,
+		})
+	}
+	cryptoLibs := FindCryptoLibs(rootPath)
 	if len(cryptoLibs) == 0 {
-		errs = append(errs, fmt.Errorf("libcrypto not found (missing package openssl-libs?)"))
+		pkg := "openssl-libs"
+		if musl != "" {
+			pkg = "libcrypto3"
+		}
+		errs = append(errs, checkErrorf(CheckLibcryptoPresent, "libcrypto not found (missing package %s?)", pkg))
 	} else {
+		// Without binutils, every library would fail with the same error,
+		// so read the symbols in-process instead.
+		inProcess := policy.OpenSSL.InProcess || !executor.Available("nm")
+		arch := rootArch(rootPath)
 		for _, lib := range cryptoLibs {
-			stdout, stderr, rc, err := executor.Execute(ctx, "", "nm", "-D", filepath.Join(rootPath, lib))
+			info, err := validateLoadable(rootPath, lib, arch)
+			libResult := LibcryptoResult{Path: lib}
+			if info != nil {
+				libResult.Arch, libResult.Soname = info.Arch, info.Soname
+			}
 			if err != nil {
+				result.Libraries = append(result.Libraries, libResult)
 				errs = append(errs, err)
 				continue
 			}
-			if rc != 0 {
-				errs = append(errs, errors.New(string(stderr)))
+			var symbol string
+			if inProcess {
+				symbol, err = fipsSymbol(rootPath, lib, policy.OpenSSL.SymbolMatch)
+			} else {
+				symbol, err = fipsSymbolNm(ctx, rootPath, lib, policy.OpenSSL.SymbolMatch)
+			}
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			if err != nil {
+				result.Libraries = append(result.Libraries, libResult)
+				errs = append(errs, err)
 				continue
 			}
-
-			hasFIPS := bytes.Contains(stdout, []byte("FIPS_mode")) ||
-				bytes.Contains(stdout, []byte("fips_mode")) ||
-				bytes.Contains(stdout, []byte("EVP_default_properties_is_fips_enabled"))
-			if !hasFIPS {
-				errs = append(errs, fmt.Errorf("%s is not FIPS-capable", lib))
+			libResult.FIPSCapable, libResult.Symbol = symbol != "", symbol
+			result.Libraries = append(result.Libraries, libResult)
+			if symbol == "" {
+				errs = append(errs, checkErrorf(CheckLibcryptoFIPS, "%s is not FIPS-capable", lib))
 			}
 		}
 	}
 
-	if len(errs) > 0 {
-		failure("failed\n")
-		for _, e := range errs {
-			fmt.Printf("  %s %v\n", red("✘"), e)
+	errs = append(errs, validateOpenSSLProviders(rootPath, policy)...)
+	if policy.Hardening.LibcryptoPermissions {
+		errs = append(errs, validateLibcryptoPermissions(rootPath, cryptoLibs)...)
+	}
+	if policy.OpenSSL.VerifyFipsModuleMAC {
+		errs = append(errs, validateFipsModuleMAC(rootPath)...)
+	}
+	if len(policy.OpenSSL.ApprovedHashes) > 0 {
+		errs = append(errs, validateApprovedBuilds(rootPath, cryptoLibs, policy.OpenSSL.ApprovedHashes)...)
+	}
+	if policy.OpenSSL.FipsProviderVersion != "" {
+		var versionErrs []error
+		result.ProviderVersion, versionErrs = validateFipsProviderVersion(rootPath, policy.OpenSSL.FipsProviderVersion)
+		errs = append(errs, versionErrs...)
+	}
+	if policy.OpenSSL.VerifyLdCache {
+		errs = append(errs, validateLdCache(rootPath, result.Libraries, policy.OpenSSL.SymbolMatch)...)
+	}
+	errs = append(errs, validateFipsBoot(ctx, rootPath, policy)...)
+
+	// Only errors make validation fail.
+	result.Valid = true
+	for _, err := range errs {
+		f := newFinding(err)
+		if f.Severity == SeverityError {
+			result.Valid = false
+		}
+		result.Findings = append(result.Findings, f)
+	}
+	return result, nil
+}
+
+// archReferenceBinaries are binaries present in virtually every root filesystem,
+// whose architecture is taken to be the architecture of the root filesystem.
+var archReferenceBinaries = []string{"/bin/sh", "/usr/bin/sh", "/bin/busybox", "/usr/bin/env"}
+
+// rootArch returns the architecture of the root filesystem at rootPath, or ""
+// if it can't be determined.
+func rootArch(rootPath string) string {
+	fsys := rootfs.FS(rootPath)
+	for _, p := range archReferenceBinaries {
+		f, err := openFile(fsys, p)
+		if err != nil {
+			continue
+		}
+		arch, err := elfinfo.ReadArch(f)
+		f.Close()
+		if err == nil {
+			return arch
+		}
+	}
+	return ""
+}
+
+// validateLoadable checks that the libcrypto at lib within rootPath is a shared
+// library that the dynamic loader could load, i.e. a valid ELF shared object of
+// the expected architecture, if known. It returns the library's ELF info, or
+// nil if it isn't an ELF file.
+func validateLoadable(rootPath, lib, arch string) (*elfinfo.ElfInfo, error) {
+	info, err := readLibrary(rootfs.FS(rootPath), lib)
+	if err != nil {
+		return nil, checkErrorf(CheckLibcryptoLoadable, "%s is present, but corrupt: %v", lib, err)
+	}
+	if !info.IsElf || !info.IsSharedObject {
+		return info, checkErrorf(CheckLibcryptoLoadable, "%s is present, but not a shared library", lib)
+	}
+	if arch != "" && info.Arch != arch {
+		return info, checkErrorf(CheckLibcryptoLoadable, "%s is present, but has the wrong architecture (%s instead of %s)", lib, info.Arch, arch)
+	}
+	return info, nil
+}
+
+// fipsSymbolNm returns the FIPS mode function that the libcrypto at lib within
+// rootPath defines according to "nm -D", or "" if it defines none. With
+// SymbolMatchPresent, any symbol of that name counts.
+func fipsSymbolNm(ctx context.Context, rootPath string, lib string, match SymbolMatch) (string, error) {
+	stdout, stderr, rc, err := executor.Execute(ctx, "", "nm", "-D", filepath.Join(rootPath, lib))
+	if err != nil {
+		return "", err
+	}
+	if rc != 0 {
+		return "", errors.New(string(stderr))
+	}
+	symbols := definedFunctions(stdout)
+	if match == SymbolMatchPresent {
+		symbols = nmSymbols(stdout)
+	}
+	for _, sym := range fipsSymbols {
+		if symbols[sym] {
+			return sym, nil
 		}
-		return false
 	}
-	success("success\n")
-	return true
+	return "", nil
+}
+
+// fipsSymbol is like fipsSymbolNm, but reads the dynamic symbol table
+// in-process.
+func fipsSymbol(rootPath string, lib string, match SymbolMatch) (string, error) {
+	info, err := readLibrary(rootfs.FS(rootPath), lib)
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s: %v", lib, err)
+	}
+	return fipsModeSymbol(info, match), nil
 }
 
-func findCryptoLibs(rootPath string) []string {
+// fipsModeSymbol returns the FIPS mode function that the libcrypto described
+// by info exports, matched as set by match, or "" if there is none.
+func fipsModeSymbol(info *elfinfo.ElfInfo, match SymbolMatch) string {
+	for _, sym := range fipsSymbols {
+		if match == SymbolMatchPresent && hasDynamicSymbol(info, sym) || definesAnyFunction(info, []string{sym}) {
+			return sym
+		}
+	}
+	return ""
+}
+
+// hasDynamicSymbol returns whether a shared library has a dynamic symbol
+// named name, whether defined or not.
+func hasDynamicSymbol(info *elfinfo.ElfInfo, name string) bool {
+	for _, sym := range info.DynamicSymbols {
+		if sym.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// FindCryptoLibs returns the paths of all libcrypto libraries in the standard
+// library directories of the root filesystem at rootPath, including those of
+// the musl dynamic loader in musl environments.
+func FindCryptoLibs(rootPath string) []string {
 	var libs []string
-	for _, libPath := range libPaths {
+	for _, libPath := range cryptoLibDirs(rootPath) {
 		dir := filepath.Join(rootPath, libPath)
 
 		if dirInfo, err := os.Lstat(dir); err != nil || dirInfo.Mode()&os.ModeSymlink != 0 {
@@ -84,3 +267,40 @@ func findCryptoLibs(rootPath string) []string {
 	}
 	return libs
 }
+
+// definedFunctions parses the output of "nm -D" and returns the names of the
+// functions defined in the text section (types T and t) and of weak function
+// definitions (type W), which the dynamic loader binds to like the others
+// unless another object defines the function, as for the in-process symbol
+// table. Symbols that are only referenced (types U and w) are left out, so
+// that a library importing a function isn't mistaken for one providing it.
+// Symbol versions ("@@OPENSSL_3.0.0") are stripped from the names.
+func definedFunctions(nmOutput []byte) map[string]bool {
+	defined := map[string]bool{}
+	for _, line := range bytes.Split(nmOutput, []byte("\n")) {
+		// Lines are "<value> <type> <name>"; undefined symbols have no value.
+		fields := strings.Fields(string(line))
+		if len(fields) != 3 || (fields[1] != "T" && fields[1] != "t" && fields[1] != "W") {
+			continue
+		}
+		name, _, _ := strings.Cut(fields[2], "@")
+		defined[name] = true
+	}
+	return defined
+}
+
+// nmSymbols is like definedFunctions, but returns the names of all symbols,
+// whatever their type, for SymbolMatchPresent.
+func nmSymbols(nmOutput []byte) map[string]bool {
+	symbols := map[string]bool{}
+	for _, line := range bytes.Split(nmOutput, []byte("\n")) {
+		// Undefined symbols are listed as "<type> <name>".
+		fields := strings.Fields(string(line))
+		if len(fields) != 2 && len(fields) != 3 {
+			continue
+		}
+		name, _, _ := strings.Cut(fields[len(fields)-1], "@")
+		symbols[name] = true
+	}
+	return symbols
+}
@@ -0,0 +1,93 @@
+package validation
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/flightctl/fips-validator/internal/executor"
+)
+
+func TestDefinedFunctions(t *testing.T) {
+	nmOutput := []byte(`                 U FIPS_mode
+                 w __gmon_start__
+0000000000001109 T OpenSSL_version
+0000000000001111 t local_function
+0000000000001117 W weak_function
+0000000000001120 T EVP_default_properties_is_fips_enabled@@OPENSSL_3.0.0
+0000000000001130 T OPENSSL_init_crypto@OPENSSL_3.0.0
+0000000000004010 D data_object
+0000000000004020 B bss_object
+0000000000004030 V weak_object
+                 U imported@@OPENSSL_3.0.0
+
+`)
+	defined := definedFunctions(nmOutput)
+	for name, want := range map[string]bool{
+		"OpenSSL_version":                        true,
+		"local_function":                         true,
+		"weak_function":                          true,
+		"EVP_default_properties_is_fips_enabled": true,
+		"OPENSSL_init_crypto":                    true,
+		"FIPS_mode":                              false,
+		"__gmon_start__":                         false,
+		"data_object":                            false,
+		"bss_object":                             false,
+		"weak_object":                            false,
+		"imported":                               false,
+	} {
+		if defined[name] != want {
+			t.Errorf("definedFunctions()[%q] = %v, want %v", name, defined[name], want)
+		}
+	}
+	if len(defined) != 5 {
+		t.Errorf("definedFunctions() = %v, want 5 functions", defined)
+	}
+
+	symbols := nmSymbols(nmOutput)
+	for _, name := range []string{"FIPS_mode", "weak_function", "EVP_default_properties_is_fips_enabled", "imported", "data_object"} {
+		if !symbols[name] {
+			t.Errorf("nmSymbols()[%q] = false, want true", name)
+		}
+	}
+}
+
+// TestValidateOpenSSLImportedSymbol validates a libcrypto that only imports
+// FIPS_mode, which a check for the symbol's name would mistake for a
+// FIPS-capable one.
+func TestValidateOpenSSLImportedSymbol(t *testing.T) {
+	lib, err := os.ReadFile("../selftest/rootfs/usr/lib/importfips/libcrypto.so.3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	root := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(root, "usr/lib64"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "usr/lib64/libcrypto.so.3"), lib, 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, inProcess := range []bool{true, false} {
+		if !inProcess && !executor.Available("nm") {
+			t.Log("nm not installed, skipping nm")
+			continue
+		}
+		for match, want := range map[SymbolMatch]bool{SymbolMatchDefined: false, SymbolMatchPresent: true} {
+			policy := DefaultPolicy()
+			policy.OpenSSL.InProcess = inProcess
+			policy.OpenSSL.SymbolMatch = match
+			result, err := ValidateOpenSSL(context.Background(), root, policy)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(result.Libraries) != 1 {
+				t.Fatalf("libraries = %+v, want one", result.Libraries)
+			}
+			if got := result.Libraries[0]; got.FIPSCapable != want {
+				t.Errorf("inProcess %v, symbolMatch %s: FIPS-capable = %v (symbol %q), want %v", inProcess, match, got.FIPSCapable, got.Symbol, want)
+			}
+		}
+	}
+}
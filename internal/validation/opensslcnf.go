@@ -0,0 +1,214 @@
+package validation
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/flightctl/fips-validator/internal/rootfs"
+)
+
+// opensslConfigs are the locations of openssl.cnf on common distributions.
+var opensslConfigs = []string{
+	"/etc/pki/tls/openssl.cnf",
+	"/etc/ssl/openssl.cnf",
+	"/usr/lib/ssl/openssl.cnf",
+	"/usr/local/ssl/openssl.cnf",
+}
+
+// maxIncludeDepth limits the nesting of .include directives, which also
+// protects against include cycles.
+const maxIncludeDepth = 8
+
+// opensslConfig is a parsed OpenSSL configuration file: the key/value pairs of
+// each section. Keys before the first section header are in the "default"
+// section.
+type opensslConfig map[string]map[string]string
+
+// parseOpenSSLConfig parses the OpenSSL configuration file at name within fsys,
+// following .include directives. Variable references aren't expanded.
+func parseOpenSSLConfig(fsys fs.FS, name string) (opensslConfig, error) {
+	c := opensslConfig{}
+	section := "default"
+	if err := c.parse(fsys, name, &section, 0); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func (c opensslConfig) parse(fsys fs.FS, name string, section *string, depth int) error {
+	if depth > maxIncludeDepth {
+		return fmt.Errorf("%s: includes nested too deeply", name)
+	}
+	f, err := fsys.Open(fsName(name))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	sc := bufio.NewScanner(f)
+	var line string
+	for sc.Scan() {
+		// A trailing backslash continues the line.
+		if l := sc.Text(); strings.HasSuffix(l, "\\") {
+			line += strings.TrimSuffix(l, "\\")
+			continue
+		} else {
+			line += l
+		}
+		l := strings.TrimSpace(stripConfigComment(line))
+		line = ""
+
+		switch {
+		case l == "":
+		case strings.HasPrefix(l, "[") && strings.HasSuffix(l, "]"):
+			*section = strings.TrimSpace(l[1 : len(l)-1])
+		case strings.HasPrefix(l, ".include"):
+			inc := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(strings.TrimPrefix(l, ".include")), "="))
+			if err := c.include(fsys, name, unquoteConfigValue(inc), section, depth+1); err != nil {
+				return err
+			}
+		case strings.HasPrefix(l, "."):
+			// Other directives, e.g. .pragma, don't affect the values.
+		default:
+			key, value, ok := strings.Cut(l, "=")
+			if !ok {
+				continue
+			}
+			if c[*section] == nil {
+				c[*section] = map[string]string{}
+			}
+			c[*section][strings.TrimSpace(key)] = unquoteConfigValue(strings.TrimSpace(value))
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return fmt.Errorf("%s: %v", name, err)
+	}
+	return nil
+}
+
+// include parses the file or, for a directory, all *.cnf and *.conf files in
+// it. Relative paths are resolved against the directory of the including file.
+// Missing files are skipped, as images often don't ship optional includes such
+// as crypto policy back-ends.
+func (c opensslConfig) include(fsys fs.FS, from, inc string, section *string, depth int) error {
+	if !path.IsAbs(inc) {
+		inc = path.Join(path.Dir(from), inc)
+	}
+	fi, err := fs.Stat(fsys, fsName(inc))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("%s: failed to include %s: %v", from, inc, err)
+	}
+	if !fi.IsDir() {
+		return c.parse(fsys, inc, section, depth)
+	}
+	entries, err := fs.ReadDir(fsys, fsName(inc))
+	if err != nil {
+		return fmt.Errorf("%s: failed to include %s: %v", from, inc, err)
+	}
+	for _, e := range entries {
+		if n := e.Name(); !e.IsDir() && (strings.HasSuffix(n, ".cnf") || strings.HasSuffix(n, ".conf")) {
+			if err := c.parse(fsys, path.Join(inc, n), section, depth); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// stripConfigComment removes a comment starting with an unquoted "#".
+func stripConfigComment(line string) string {
+	var quote rune
+	for i, r := range line {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '"' || r == '\'':
+			quote = r
+		case r == '#':
+			return line[:i]
+		}
+	}
+	return line
+}
+
+func unquoteConfigValue(v string) string {
+	if len(v) >= 2 && (v[0] == '"' || v[0] == '\'') && v[len(v)-1] == v[0] {
+		return v[1 : len(v)-1]
+	}
+	return v
+}
+
+// findOpenSSLConfig returns the path of openssl.cnf within rootPath, or "" if
+// there is none.
+func findOpenSSLConfig(rootPath string) string {
+	for _, p := range opensslConfigs {
+		if isRegularFile(rootfs.FS(rootPath), p) {
+			return p
+		}
+	}
+	return ""
+}
+
+// activeProviders returns the names of the providers that the configuration
+// activates, or nil if it doesn't configure providers, in which case OpenSSL
+// loads the default provider.
+func (c opensslConfig) activeProviders() []string {
+	initSection := c["default"]["openssl_conf"]
+	providerSection := c[initSection]["providers"]
+	if initSection == "" || providerSection == "" {
+		return nil
+	}
+	var active []string
+	for name, sectionName := range c[providerSection] {
+		activate, ok := c[sectionName]["activate"]
+		if !ok {
+			continue
+		}
+		// OpenSSL 3.0 activates a provider whatever the value, later
+		// versions parse it as a boolean.
+		switch strings.ToLower(activate) {
+		case "0", "no", "false", "off", "n":
+			continue
+		}
+		active = append(active, name)
+	}
+	slices.Sort(active)
+	return active
+}
+
+// validateOpenSSLProviders reports an openssl.cnf that activates the default
+// provider alongside the FIPS provider, which keeps non-FIPS implementations
+// of algorithms available to applications.
+func validateOpenSSLProviders(rootPath string, policy *Policy) []error {
+	severity, enforced := policy.OpenSSL.DefaultProvider.severity()
+	if !enforced {
+		return []error{}
+	}
+	config := findOpenSSLConfig(rootPath)
+	if config == "" {
+		return []error{}
+	}
+	c, err := parseOpenSSLConfig(rootfs.FS(rootPath), config)
+	if err != nil {
+		return []error{&CheckError{Check: CheckOpenSSLProviders, Err: fmt.Errorf("failed to parse %s: %v", config, err), Severity: severity}}
+	}
+	active := c.activeProviders()
+	if !slices.Contains(active, "fips") || !slices.Contains(active, "default") {
+		return []error{}
+	}
+	return []error{&CheckError{
+		Check:    CheckOpenSSLProviders,
+		Err:      fmt.Errorf("%s activates the default provider alongside the FIPS provider (active providers: %s)", config, strings.Join(active, ", ")),
+		Severity: severity,
+	}}
+}
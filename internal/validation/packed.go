@@ -0,0 +1,77 @@
+package validation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+
+	"github.com/flightctl/fips-validator/internal/executor"
+	"github.com/flightctl/fips-validator/pkg/elfinfo"
+)
+
+// unpackedFile is a binary decompressed to a temporary directory, which is
+// removed when the file is closed.
+type unpackedFile struct {
+	*os.File
+	dir string
+}
+
+func (f *unpackedFile) Close() error {
+	err := f.File.Close()
+	os.RemoveAll(f.dir)
+	return err
+}
+
+// unpackUPX decompresses the UPX-packed binary read from r with "upx -d" and
+// returns the unpacked binary and its ELF info.
+func unpackUPX(ctx context.Context, r io.ReaderAt) (readerAtFile, *elfinfo.ElfInfo, error) {
+	if !executor.Available("upx") {
+		return nil, nil, errors.New("upx is not installed")
+	}
+	dir, err := os.MkdirTemp("", "fips-validator-upx-")
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create temporary directory: %v", err)
+	}
+	packed, unpacked := filepath.Join(dir, "packed"), filepath.Join(dir, "unpacked")
+	if err := copyToFile(packed, io.NewSectionReader(r, 0, math.MaxInt64)); err != nil {
+		os.RemoveAll(dir)
+		return nil, nil, err
+	}
+	_, stderr, rc, err := executor.Execute(ctx, "", "upx", "-d", "-q", "-o", unpacked, packed)
+	if err == nil && rc != 0 {
+		err = fmt.Errorf("upx -d failed, exit code %d: %s", rc, string(stderr))
+	}
+	if err != nil {
+		os.RemoveAll(dir)
+		return nil, nil, err
+	}
+
+	f, err := os.Open(unpacked)
+	if err != nil {
+		os.RemoveAll(dir)
+		return nil, nil, err
+	}
+	uf := &unpackedFile{File: f, dir: dir}
+	ei, err := elfinfo.ReadFrom(f)
+	if err != nil {
+		uf.Close()
+		return nil, nil, fmt.Errorf("failed to read unpacked binary: %v", err)
+	}
+	return uf, ei, nil
+}
+
+func copyToFile(path string, r io.Reader) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, r); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,43 @@
+package validation
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// validateLibcryptoPermissions warns about libcrypto libraries and the FIPS
+// provider module within rootPath that are writable by group or others, or
+// owned by a user other than root, as an unprivileged user could then replace
+// the FIPS-validated code.
+func validateLibcryptoPermissions(rootPath string, cryptoLibs []string) []error {
+	var errs []error
+	files := cryptoLibs
+	if module := findFipsModule(rootPath); module != "" {
+		files = append(files[:len(files):len(files)], module)
+	}
+	for _, file := range files {
+		fi, err := os.Lstat(filepath.Join(rootPath, file))
+		if err != nil {
+			errs = append(errs, &CheckError{Check: CheckLibcryptoPermissions, Err: err, Severity: SeverityWarning})
+			continue
+		}
+		if perm := fi.Mode().Perm(); perm&0o022 != 0 {
+			errs = append(errs, &CheckError{
+				Check:    CheckLibcryptoPermissions,
+				Err:      fmt.Errorf("%s is writable by group or others (mode %04o)", file, perm),
+				Hint:     fmt.Sprintf("chmod go-w %s", file),
+				Severity: SeverityWarning,
+			})
+		}
+		if uid, ok := fileOwner(fi); ok && uid != 0 {
+			errs = append(errs, &CheckError{
+				Check:    CheckLibcryptoPermissions,
+				Err:      fmt.Errorf("%s is owned by UID %d instead of root", file, uid),
+				Hint:     fmt.Sprintf("chown root %s", file),
+				Severity: SeverityWarning,
+			})
+		}
+	}
+	return errs
+}
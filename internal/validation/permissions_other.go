@@ -0,0 +1,10 @@
+//go:build !unix
+
+package validation
+
+import "io/fs"
+
+// fileOwner reports no owner, as only Unix systems record file owners by UID.
+func fileOwner(fs.FileInfo) (uint32, bool) {
+	return 0, false
+}
@@ -0,0 +1,18 @@
+//go:build unix
+
+package validation
+
+import (
+	"io/fs"
+	"syscall"
+)
+
+// fileOwner returns the UID of the owner of the file described by fi, and
+// false if its file system doesn't report one.
+func fileOwner(fi fs.FileInfo) (uint32, bool) {
+	st, ok := fi.Sys().(*syscall.Stat_t)
+	if !ok {
+		return 0, false
+	}
+	return st.Uid, true
+}
@@ -0,0 +1,486 @@
+package validation
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"debug/elf"
+	_ "embed"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+	"gopkg.in/yaml.v3"
+
+	"github.com/flightctl/fips-validator/pkg/elfinfo"
+)
+
+// Policy configures the checks performed during validation.
+type Policy struct {
+	// IgnoredSections lists the ELF sections whose symbols are ignored when
+	// looking for crypto usage and for required symbols. Symbols in .bss
+	// only reserve space for data, so they don't indicate that the code
+	// they're named after is present.
+	IgnoredSections []string `yaml:"ignoredSections"`
+	// SymbolSource selects the symbol tables searched for crypto usage and
+	// for required symbols.
+	SymbolSource SymbolSource `yaml:"symbolSource"`
+	// Hardening configures optional checks for hardening features that
+	// FIPS doesn't require but security baselines often do.
+	Hardening HardeningPolicy `yaml:"hardening"`
+	// OpenSSL configures the checks of the OpenSSL installation in image
+	// and dir modes.
+	OpenSSL OpenSSLPolicy `yaml:"openssl"`
+	// Boot configures the checks of how bootable images and directories
+	// enable FIPS mode at boot.
+	Boot BootPolicy `yaml:"boot"`
+	// VCS configures checks of the version control information Go embeds
+	// in binaries.
+	VCS VCSPolicy `yaml:"vcs"`
+	// DebugInfo configures where separate debuginfo files of stripped
+	// binaries are looked up.
+	DebugInfo DebugInfoPolicy `yaml:"debugInfo"`
+	// StaticExemptions lists vetted statically-linked binaries that don't
+	// fail the dynamic linking check.
+	StaticExemptions []StaticExemption `yaml:"staticExemptions"`
+	// DeniedBuildTags lists the build tags that Go binaries must not be
+	// built with, e.g. no_openssl, which disables the OpenSSL backend.
+	DeniedBuildTags []string `yaml:"deniedBuildTags"`
+	// BannedModules lists Go modules that Go binaries must not depend on,
+	// e.g. crypto libraries that bypass OpenSSL.
+	BannedModules []BannedModule `yaml:"bannedModules"`
+	// PureGoCrypto configures the check for Go binaries that use the
+	// golang-fips/openssl backend alongside pure-Go crypto modules.
+	PureGoCrypto PureGoCryptoPolicy `yaml:"pureGoCrypto"`
+	// Strict fails binaries whose validation is inconclusive, e.g. fully
+	// stripped binaries whose crypto usage can't be determined, instead of
+	// skipping them with a warning.
+	Strict bool `yaml:"strict"`
+	// FailFast stops validating a binary at the first check that fails it,
+	// skipping the remaining checks and, if possible, reading the build
+	// info of Go binaries. Only the first failure is reported.
+	FailFast bool `yaml:"failFast"`
+	// Rules are the Go version rules to validate against, or nil for the
+	// built-in rules. If CompareRules is set, too, Go binaries are also
+	// evaluated against those, see BinaryResult.CompareStatus. Rules are
+	// loaded from their own files, not from the policy.
+	Rules        *Rules `yaml:"-"`
+	CompareRules *Rules `yaml:"-"`
+}
+
+// HardeningPolicy enables hardening checks, which are reported as warnings.
+type HardeningPolicy struct {
+	// RequireFullRelro warns about crypto-using binaries that aren't built
+	// with full RELRO.
+	RequireFullRelro bool `yaml:"requireFullRelro"`
+	// RequireControlFlowProtection warns about crypto-using binaries that
+	// don't advertise IBT and shadow stack (x86_64) or BTI (aarch64)
+	// support in their GNU property notes.
+	RequireControlFlowProtection bool `yaml:"requireControlFlowProtection"`
+	// LibcryptoPermissions warns about libcrypto libraries and the FIPS
+	// provider module that are group- or world-writable or not owned by
+	// root, in image and dir modes.
+	LibcryptoPermissions bool `yaml:"libcryptoPermissions"`
+}
+
+// OpenSSLPolicy enables optional checks of the OpenSSL installation.
+type OpenSSLPolicy struct {
+	// VerifyFipsModuleMAC recomputes the module-mac recorded in
+	// fipsmodule.cnf and fails if it doesn't match the FIPS provider module.
+	VerifyFipsModuleMAC bool `yaml:"verifyFipsModuleMac"`
+	// InProcess reads the symbols of libcrypto in-process instead of
+	// running nm from binutils. nm is also not used if it isn't installed.
+	InProcess bool `yaml:"inProcess"`
+	// FipsProviderVersion is a semver constraint, e.g. ">= 3.0.7, < 3.1",
+	// that the version of the FIPS provider module must satisfy. If empty,
+	// the version isn't checked.
+	FipsProviderVersion string `yaml:"fipsProviderVersion"`
+	// DefaultProvider sets how an openssl.cnf that activates the default
+	// provider alongside the FIPS provider is reported.
+	DefaultProvider Enforcement `yaml:"defaultProvider"`
+	// VerifyLdCache warns if /etc/ld.so.cache is stale or resolves a
+	// libcrypto SONAME to a library that isn't FIPS-capable while a
+	// FIPS-capable one is installed.
+	VerifyLdCache bool `yaml:"verifyLdCache"`
+	// SymbolMatch sets whether a libcrypto is FIPS-capable only if it
+	// defines one of the FIPS mode functions, or already if it has a
+	// symbol of that name.
+	SymbolMatch SymbolMatch `yaml:"symbolMatch"`
+	// ApprovedHashes lists the hex-encoded SHA-256 digests of the approved
+	// libcrypto and FIPS provider module builds. If set, any libcrypto or
+	// fips.so whose digest isn't listed fails validation, even if it is
+	// FIPS-capable.
+	ApprovedHashes []string `yaml:"approvedHashes"`
+}
+
+// BootPolicy configures the checks of FIPS mode enablement at boot.
+type BootPolicy struct {
+	// FipsMode sets how bootable root filesystems whose initramfs lacks
+	// dracut's fips module, or whose kernel command line lacks fips=1, are
+	// reported.
+	FipsMode Enforcement `yaml:"fipsMode"`
+}
+
+// VCSPolicy configures checks of a Go binary's version control information.
+type VCSPolicy struct {
+	// Modified sets how binaries built from a modified working tree
+	// (vcs.modified=true) are reported.
+	Modified Enforcement `yaml:"modified"`
+}
+
+// DebugInfoPolicy configures the lookup of separate debuginfo files, whose
+// symbol tables are used for stripped binaries that link to one with a
+// .gnu_debuglink section.
+type DebugInfoPolicy struct {
+	// Directories lists the global debug directories within the validated
+	// root filesystem, like gdb's debug-file-directory. Debuginfo files
+	// are also looked up next to the binary and in its .debug directory.
+	Directories []string `yaml:"directories"`
+}
+
+// PureGoCryptoPolicy configures the check for pure-Go crypto modules in Go
+// binaries that use the golang-fips/openssl backend.
+type PureGoCryptoPolicy struct {
+	// Modules are the module paths, or glob patterns as accepted by
+	// path.Match, of Go modules that implement crypto in pure Go, e.g.
+	// "github.com/cloudflare/circl".
+	Modules []string `yaml:"modules"`
+	// Enforcement sets how binaries that link one of the modules are
+	// reported.
+	Enforcement Enforcement `yaml:"enforcement"`
+}
+
+// StaticExemption exempts statically-linked binaries from the dynamic linking
+// check. A binary matches if its path matches Path and its contents match
+// SHA256; empty fields match any binary, but at least one must be set.
+type StaticExemption struct {
+	// Path is a glob pattern, as accepted by path.Match, for the path of
+	// the binary within the validated target, e.g. "/usr/sbin/busybox".
+	Path string `yaml:"path"`
+	// SHA256 is the hex-encoded SHA-256 digest of the binary.
+	SHA256 string `yaml:"sha256"`
+	// Reason documents why the binary is exempted.
+	Reason string `yaml:"reason"`
+}
+
+// validate checks that the exemption matches some binaries, but not all.
+func (e *StaticExemption) validate() error {
+	if e.Path == "" && e.SHA256 == "" {
+		return errors.New("path or sha256 must be set")
+	}
+	if e.Path != "" {
+		if _, err := path.Match(e.Path, ""); err != nil {
+			return fmt.Errorf("path: invalid pattern %q: %v", e.Path, err)
+		}
+	}
+	if e.SHA256 != "" {
+		if b, err := hex.DecodeString(e.SHA256); err != nil || len(b) != sha256.Size {
+			return fmt.Errorf("sha256: invalid digest %q", e.SHA256)
+		}
+	}
+	return nil
+}
+
+// String describes the exemption for the report.
+func (e *StaticExemption) String() string {
+	var parts []string
+	if e.Path != "" {
+		parts = append(parts, "path "+e.Path)
+	}
+	if e.SHA256 != "" {
+		parts = append(parts, "sha256 "+e.SHA256)
+	}
+	s := strings.Join(parts, ", ")
+	if e.Reason != "" {
+		s += ": " + e.Reason
+	}
+	return s
+}
+
+// Enforcement sets how violations of an optional check are reported.
+type Enforcement string
+
+const (
+	EnforcementAllow Enforcement = "allow"
+	EnforcementWarn  Enforcement = "warn"
+	EnforcementFail  Enforcement = "fail"
+)
+
+// severity returns the severity of a finding reported with enforcement e, or
+// false if violations are allowed.
+func (e Enforcement) severity() (Severity, bool) {
+	switch e {
+	case EnforcementWarn:
+		return SeverityWarning, true
+	case EnforcementFail:
+		return SeverityError, true
+	}
+	return "", false
+}
+
+func (e Enforcement) validate() error {
+	switch e {
+	case EnforcementAllow, EnforcementWarn, EnforcementFail:
+		return nil
+	}
+	return fmt.Errorf("unknown value %q (must be allow, warn, or fail)", e)
+}
+
+// SymbolMatch sets how the FIPS mode functions are matched against the dynamic
+// symbols of a libcrypto.
+type SymbolMatch string
+
+const (
+	// SymbolMatchDefined requires a function of that name that the library
+	// defines, as opposed to one it imports from another library.
+	SymbolMatchDefined SymbolMatch = "defined"
+	// SymbolMatchPresent accepts any symbol of that name, including
+	// undefined ones and objects, for libcrypto builds that export the
+	// functions unusually. It can mistake a library that merely references
+	// a FIPS mode function, e.g. a wrapper around another libcrypto, for a
+	// FIPS-capable one.
+	SymbolMatchPresent SymbolMatch = "present"
+)
+
+func (m SymbolMatch) validate() error {
+	switch m {
+	case SymbolMatchDefined, SymbolMatchPresent:
+		return nil
+	}
+	return fmt.Errorf("unknown value %q (must be defined or present)", m)
+}
+
+// SymbolSource selects which of a binary's symbol tables are searched.
+type SymbolSource string
+
+const (
+	// SymbolSourceAuto searches .symtab, or .dynsym if the binary has no
+	// .symtab because it was stripped.
+	SymbolSourceAuto   SymbolSource = "auto"
+	SymbolSourceSymtab SymbolSource = "symtab"
+	SymbolSourceDynsym SymbolSource = "dynsym"
+	// SymbolSourceBoth searches the union of .symtab and .dynsym.
+	SymbolSourceBoth SymbolSource = "both"
+)
+
+// ParseSymbolSource parses the name of a symbol source.
+func ParseSymbolSource(s string) (SymbolSource, error) {
+	switch src := SymbolSource(s); src {
+	case SymbolSourceAuto, SymbolSourceSymtab, SymbolSourceDynsym, SymbolSourceBoth:
+		return src, nil
+	}
+	return "", fmt.Errorf("unknown symbol source %q (must be auto, symtab, dynsym, or both)", s)
+}
+
+// defaultPolicy is the YAML of the policy used if none is configured. Policy
+// files are applied on top of it.
+//
+//go:embed default-policy.yaml
+var defaultPolicy []byte
+
+// DefaultPolicy returns the policy used if none is configured.
+func DefaultPolicy() *Policy {
+	p := &Policy{}
+	if err := decodePolicy(defaultPolicy, p); err != nil {
+		panic(fmt.Errorf("validation: can't parse default policy: %w", err))
+	}
+	return p
+}
+
+// LoadPolicy reads a YAML policy file. Settings missing from the file keep
+// their default values.
+func LoadPolicy(path string) (*Policy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read policy file: %v", err)
+	}
+	p, err := ParsePolicy(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse policy file %s: %v", path, err)
+	}
+	return p, nil
+}
+
+// ParsePolicy parses a YAML policy and applies it on top of the default
+// policy. Settings missing from data keep their default values, and settings
+// in data replace the default values, field by field. Lists tagged with
+// !append, e.g. "deniedBuildTags: !append [no_fips]", are appended to the
+// default list instead of replacing it.
+func ParsePolicy(data []byte) (*Policy, error) {
+	var overlay yaml.Node
+	if err := yaml.Unmarshal(data, &overlay); err != nil {
+		return nil, err
+	}
+	var base yaml.Node
+	if err := yaml.Unmarshal(defaultPolicy, &base); err != nil {
+		panic(fmt.Errorf("validation: can't parse default policy: %w", err))
+	}
+	appended, err := appendLists(&base, &overlay, "")
+	if err != nil {
+		return nil, err
+	}
+	if appended {
+		// Errors in the merged policy refer to lines of the re-encoded
+		// YAML, so only re-encode it if necessary.
+		if data, err = yaml.Marshal(&overlay); err != nil {
+			return nil, err
+		}
+	}
+
+	p := DefaultPolicy()
+	if err := decodePolicy(data, p); err != nil {
+		return nil, err
+	}
+	if err := p.validate(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// decodePolicy decodes a YAML policy into p, rejecting unknown settings.
+func decodePolicy(data []byte, p *Policy) error {
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(p); err != nil && !errors.Is(err, io.EOF) {
+		return err
+	}
+	return nil
+}
+
+// appendLists resolves the lists tagged with !append in the overlay node by
+// prepending the items of the same list in the base node, and returns whether
+// there were any. key is the path of the nodes within the policy, for errors.
+func appendLists(base, overlay *yaml.Node, key string) (bool, error) {
+	if overlay.Kind == yaml.DocumentNode {
+		if len(overlay.Content) == 0 {
+			return false, nil
+		}
+		if base != nil && base.Kind == yaml.DocumentNode && len(base.Content) > 0 {
+			base = base.Content[0]
+		}
+		return appendLists(base, overlay.Content[0], key)
+	}
+	if overlay.Tag == "!append" {
+		if overlay.Kind != yaml.SequenceNode {
+			return false, fmt.Errorf("line %d: %s: !append requires a list", overlay.Line, key)
+		}
+		if base != nil && base.Kind == yaml.SequenceNode {
+			overlay.Content = append(slices.Clone(base.Content), overlay.Content...)
+		}
+		overlay.Tag = "!!seq"
+		return true, nil
+	}
+	if overlay.Kind != yaml.MappingNode {
+		return false, nil
+	}
+	appended := false
+	for i := 0; i+1 < len(overlay.Content); i += 2 {
+		name, value := overlay.Content[i].Value, overlay.Content[i+1]
+		if key != "" {
+			name = key + "." + name
+		}
+		ok, err := appendLists(mappingValue(base, overlay.Content[i].Value), value, name)
+		if err != nil {
+			return false, err
+		}
+		appended = appended || ok
+	}
+	return appended, nil
+}
+
+// mappingValue returns the value of key in the mapping node m, or nil if m
+// isn't a mapping or doesn't have the key.
+func mappingValue(m *yaml.Node, key string) *yaml.Node {
+	if m == nil || m.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		if m.Content[i].Value == key {
+			return m.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// validate checks that all enumerated settings have known values.
+func (p *Policy) validate() error {
+	if _, err := ParseSymbolSource(string(p.SymbolSource)); err != nil {
+		return fmt.Errorf("symbolSource: %v", err)
+	}
+	if c := p.OpenSSL.FipsProviderVersion; c != "" {
+		if _, err := semver.NewConstraint(c); err != nil {
+			return fmt.Errorf("openssl.fipsProviderVersion: invalid constraint %q: %v", c, err)
+		}
+	}
+	if err := p.OpenSSL.DefaultProvider.validate(); err != nil {
+		return fmt.Errorf("openssl.defaultProvider: %v", err)
+	}
+	if err := p.OpenSSL.SymbolMatch.validate(); err != nil {
+		return fmt.Errorf("openssl.symbolMatch: %v", err)
+	}
+	for i, h := range p.OpenSSL.ApprovedHashes {
+		if b, err := hex.DecodeString(h); err != nil || len(b) != sha256.Size {
+			return fmt.Errorf("openssl.approvedHashes[%d]: invalid digest %q", i, h)
+		}
+	}
+	if err := p.Boot.FipsMode.validate(); err != nil {
+		return fmt.Errorf("boot.fipsMode: %v", err)
+	}
+	if err := p.VCS.Modified.validate(); err != nil {
+		return fmt.Errorf("vcs.modified: %v", err)
+	}
+	for i, m := range p.PureGoCrypto.Modules {
+		if _, err := path.Match(m, ""); err != nil {
+			return fmt.Errorf("pureGoCrypto.modules[%d]: invalid pattern %q: %v", i, m, err)
+		}
+	}
+	if err := p.PureGoCrypto.Enforcement.validate(); err != nil {
+		return fmt.Errorf("pureGoCrypto.enforcement: %v", err)
+	}
+	for i := range p.StaticExemptions {
+		if err := p.StaticExemptions[i].validate(); err != nil {
+			return fmt.Errorf("staticExemptions[%d]: %v", i, err)
+		}
+	}
+	for i := range p.BannedModules {
+		if err := p.BannedModules[i].validate(); err != nil {
+			return fmt.Errorf("bannedModules[%d]: %v", i, err)
+		}
+	}
+	return nil
+}
+
+// rules returns the Go version rules to validate against.
+func (p *Policy) rules() *Rules {
+	if p.Rules != nil {
+		return p.Rules
+	}
+	return DefaultRules()
+}
+
+// symbols returns the symbols of info from the tables selected by the policy's
+// symbol source.
+func (p *Policy) symbols(info *elfinfo.ElfInfo) []elf.Symbol {
+	switch p.SymbolSource {
+	case SymbolSourceSymtab:
+		return info.Symbols
+	case SymbolSourceDynsym:
+		return info.DynamicSymbols
+	case SymbolSourceBoth:
+		return slices.Concat(info.Symbols, info.DynamicSymbols)
+	}
+	if len(info.Symbols) > 0 {
+		return info.Symbols
+	}
+	return info.DynamicSymbols
+}
+
+// ignoresSection returns whether symbols in the given section are ignored.
+func (p *Policy) ignoresSection(section string) bool {
+	return slices.Contains(p.IgnoredSections, section)
+}
@@ -0,0 +1,157 @@
+package validation
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestParsePolicyOverlay(t *testing.T) {
+	defaults := DefaultPolicy()
+	hash := strings.Repeat("ab", 32)
+
+	tests := []struct {
+		name   string
+		policy string
+		check  func(t *testing.T, p *Policy)
+	}{
+		{
+			name:   "empty",
+			policy: "",
+			check: func(t *testing.T, p *Policy) {
+				if !slices.Equal(p.DeniedBuildTags, defaults.DeniedBuildTags) || p.SymbolSource != defaults.SymbolSource {
+					t.Errorf("empty policy changed the defaults: %+v", p)
+				}
+			},
+		},
+		{
+			name:   "scalar override",
+			policy: "symbolSource: both\nstrict: true\n",
+			check: func(t *testing.T, p *Policy) {
+				if p.SymbolSource != "both" || !p.Strict {
+					t.Errorf("symbolSource = %q, strict = %v, want both, true", p.SymbolSource, p.Strict)
+				}
+				if !slices.Equal(p.IgnoredSections, defaults.IgnoredSections) {
+					t.Errorf("ignoredSections = %v, want the default %v", p.IgnoredSections, defaults.IgnoredSections)
+				}
+			},
+		},
+		{
+			name:   "list replace",
+			policy: "deniedBuildTags: [no_fips]\n",
+			check: func(t *testing.T, p *Policy) {
+				if want := []string{"no_fips"}; !slices.Equal(p.DeniedBuildTags, want) {
+					t.Errorf("deniedBuildTags = %v, want %v", p.DeniedBuildTags, want)
+				}
+			},
+		},
+		{
+			name:   "empty list replace",
+			policy: "deniedBuildTags: []\n",
+			check: func(t *testing.T, p *Policy) {
+				if len(p.DeniedBuildTags) != 0 {
+					t.Errorf("deniedBuildTags = %v, want none", p.DeniedBuildTags)
+				}
+			},
+		},
+		{
+			name:   "list append",
+			policy: "deniedBuildTags: !append [no_fips]\n",
+			check: func(t *testing.T, p *Policy) {
+				want := append(slices.Clone(defaults.DeniedBuildTags), "no_fips")
+				if !slices.Equal(p.DeniedBuildTags, want) {
+					t.Errorf("deniedBuildTags = %v, want %v", p.DeniedBuildTags, want)
+				}
+			},
+		},
+		{
+			name:   "list append without default",
+			policy: "staticExemptions: !append [{sha256: " + hash + "}]\n",
+			check: func(t *testing.T, p *Policy) {
+				if len(p.StaticExemptions) != 1 || p.StaticExemptions[0].SHA256 != hash {
+					t.Errorf("staticExemptions = %+v, want one for %s", p.StaticExemptions, hash)
+				}
+			},
+		},
+		{
+			name:   "nested scalar override",
+			policy: "openssl:\n  inProcess: true\n",
+			check: func(t *testing.T, p *Policy) {
+				if !p.OpenSSL.InProcess {
+					t.Error("openssl.inProcess = false, want true")
+				}
+				// The other settings of the same section keep their
+				// defaults.
+				if p.OpenSSL.DefaultProvider != defaults.OpenSSL.DefaultProvider {
+					t.Errorf("openssl = %+v, want the other settings from %+v", p.OpenSSL, defaults.OpenSSL)
+				}
+			},
+		},
+		{
+			name:   "nested list append",
+			policy: "debugInfo:\n  directories: !append [/opt/debug]\nvcs:\n  modified: fail\n",
+			check: func(t *testing.T, p *Policy) {
+				want := append(slices.Clone(defaults.DebugInfo.Directories), "/opt/debug")
+				if !slices.Equal(p.DebugInfo.Directories, want) {
+					t.Errorf("debugInfo.directories = %v, want %v", p.DebugInfo.Directories, want)
+				}
+				if p.VCS.Modified != EnforcementFail {
+					t.Errorf("vcs.modified = %q, want fail", p.VCS.Modified)
+				}
+			},
+		},
+		{
+			name:   "nested list replace",
+			policy: "debugInfo:\n  directories: [/opt/debug]\n",
+			check: func(t *testing.T, p *Policy) {
+				if want := []string{"/opt/debug"}; !slices.Equal(p.DebugInfo.Directories, want) {
+					t.Errorf("debugInfo.directories = %v, want %v", p.DebugInfo.Directories, want)
+				}
+				if p.VCS.Modified != defaults.VCS.Modified {
+					t.Errorf("vcs.modified = %q, want the default %q", p.VCS.Modified, defaults.VCS.Modified)
+				}
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := ParsePolicy([]byte(tt.policy))
+			if err != nil {
+				t.Fatalf("ParsePolicy: %v", err)
+			}
+			tt.check(t, p)
+		})
+	}
+}
+
+func TestParsePolicyOverlayErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy string
+		want   string
+	}{
+		{name: "unknown key", policy: "deniedTags: [no_fips]\n", want: "field deniedTags not found"},
+		{name: "unknown nested key", policy: "openssl:\n  inprocess: true\n", want: "field inprocess not found"},
+		{name: "unknown key with append", policy: "deniedBuildTags: !append [no_fips]\nbogus: 1\n", want: "field bogus not found"},
+		{name: "append to scalar setting", policy: "symbolSource: !append [both]\n", want: "cannot unmarshal !!seq"},
+		{name: "append of scalar", policy: "deniedBuildTags: !append no_fips\n", want: "deniedBuildTags: !append requires a list"},
+		{name: "invalid value", policy: "vcs:\n  modified: sometimes\n", want: "vcs.modified"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParsePolicy([]byte(tt.policy))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("ParsePolicy(%q) error = %v, want error containing %q", tt.policy, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestParsePolicyDoesNotModifyDefaults(t *testing.T) {
+	if _, err := ParsePolicy([]byte("deniedBuildTags: !append [no_fips]\n")); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := DefaultPolicy().DeniedBuildTags, []string{"no_openssl"}; !slices.Equal(got, want) {
+		t.Errorf("default deniedBuildTags = %v after appending, want %v", got, want)
+	}
+}
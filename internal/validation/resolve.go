@@ -0,0 +1,117 @@
+package validation
+
+import (
+	"bytes"
+	"io"
+	"io/fs"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/flightctl/fips-validator/pkg/elfinfo"
+)
+
+// golangFIPSLibcryptoNames are the SONAMEs that golang-fips/openssl tries to
+// dlopen() at startup, in order of preference.
+var golangFIPSLibcryptoNames = []string{"libcrypto.so.3", "libcrypto.so.1.1"}
+
+// resolveLibcrypto returns the path of the libcrypto that the dynamic loader
+// would load for the binary at path within fsys, or "" if the binary doesn't
+// load libcrypto or it can't be found.
+func resolveLibcrypto(fsys fs.FS, path string, info *elfinfo.ElfInfo, policy *Policy) string {
+	sonames := slices.DeleteFunc(slices.Clone(info.Needed), func(soname string) bool {
+		return !cryptoLibRegex.MatchString(soname)
+	})
+	if len(sonames) == 0 && hasDefinedSymbol(info, policy, golangFIPSDlopenSymbol) {
+		// golang-fips binaries dlopen() libcrypto instead of linking it.
+		sonames = golangFIPSLibcryptoNames
+	} else if len(sonames) == 0 && RuntimeCryptoShim(filepath.Base(path)) != "" {
+		sonames = runtimeLibcryptoNames
+	}
+
+	for _, soname := range sonames {
+		if lib := resolveLibrary(fsys, path, info, soname); lib != "" {
+			return lib
+		}
+	}
+	return ""
+}
+
+// resolveLibrary returns the path within fsys of the shared library with
+// the given SONAME as the dynamic loader would find it for the binary at path,
+// or "" if it can't be found. Like ld.so, it searches the binary's DT_RPATH
+// (unless it has a DT_RUNPATH), its DT_RUNPATH, and then the default library
+// directories.
+func resolveLibrary(fsys fs.FS, path string, info *elfinfo.ElfInfo, soname string) string {
+	if strings.Contains(soname, "/") {
+		if isRegularFile(fsys, soname) {
+			return soname
+		}
+		return ""
+	}
+
+	var dirs []string
+	if len(info.Runpath) == 0 {
+		dirs = append(dirs, info.Rpath...)
+	}
+	dirs = append(dirs, info.Runpath...)
+	dirs = append(dirs, defaultLibraryPaths(fsys, info)...)
+
+	origin := filepath.Dir(path)
+	for _, dir := range dirs {
+		dir = strings.ReplaceAll(dir, "${ORIGIN}", origin)
+		dir = strings.ReplaceAll(dir, "$ORIGIN", origin)
+		candidate := filepath.Join(dir, soname)
+		if isRegularFile(fsys, candidate) {
+			return candidate
+		}
+	}
+	return ""
+}
+
+// fsName converts an absolute path within a file system into an fs.FS name.
+func fsName(path string) string {
+	if name := strings.TrimPrefix(filepath.Clean(path), "/"); name != "" {
+		return name
+	}
+	return "."
+}
+
+func isRegularFile(fsys fs.FS, path string) bool {
+	fi, err := fs.Stat(fsys, fsName(path))
+	return err == nil && fi.Mode().IsRegular()
+}
+
+// readerAtFile is a file that supports random access, as required for reading
+// ELF files.
+type readerAtFile interface {
+	io.ReaderAt
+	io.Closer
+}
+
+// openFile opens the file at path within fsys for random access. Files that
+// don't implement io.ReaderAt are read into memory. Reads are counted in the
+// I/O statistics.
+func openFile(fsys fs.FS, path string) (readerAtFile, error) {
+	f, err := fsys.Open(fsName(path))
+	if err != nil {
+		return nil, err
+	}
+	filesOpened.Add(1)
+	if ra, ok := f.(readerAtFile); ok {
+		return countingFile{ra}, nil
+	}
+	defer f.Close()
+	data, err := io.ReadAll(f)
+	bytesRead.Add(int64(len(data)))
+	if err != nil {
+		return nil, err
+	}
+	return nopCloser{bytes.NewReader(data)}, nil
+}
+
+type nopCloser struct {
+	io.ReaderAt
+}
+
+func (nopCloser) Close() error { return nil }
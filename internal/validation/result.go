@@ -0,0 +1,239 @@
+package validation
+
+import (
+	"errors"
+	"fmt"
+)
+
+// IDs of the checks performed on binaries and on the OpenSSL installation.
+const (
+	CheckDynamicLinking       = "dynamic-linking"
+	CheckCgoEnabled           = "cgo-enabled"
+	CheckCgoInit              = "cgo-init"
+	CheckGoVersion            = "go-version"
+	CheckGoSymbols            = "go-symbols"
+	CheckGoBuildTags          = "go-build-tags"
+	CheckGoDebug              = "go-godebug"
+	CheckGoFIPSEnforcement    = "go-fips-enforcement"
+	CheckGoBannedModules      = "go-banned-modules"
+	CheckGoOpenSSLModule      = "go-openssl-module"
+	CheckGoPureCrypto         = "go-pure-crypto"
+	CheckOpenSSLLinkage       = "openssl-linkage"
+	CheckHardcodedLibcrypto   = "hardcoded-libcrypto"
+	CheckStaticOpenSSL        = "static-openssl"
+	CheckLibcryptoPresent     = "libcrypto-present"
+	CheckLibcryptoFIPS        = "libcrypto-fips-capable"
+	CheckLibcryptoLoadable    = "libcrypto-loadable"
+	CheckFullRelro            = "full-relro"
+	CheckControlFlow          = "control-flow-protection"
+	CheckEntryPoint           = "entry-point"
+	CheckFipsModuleMAC        = "fips-module-mac"
+	CheckFipsProviderVersion  = "fips-provider-version"
+	CheckApprovedBuild        = "approved-build"
+	CheckVCSModified          = "vcs-modified"
+	CheckLibcryptoPermissions = "libcrypto-permissions"
+	CheckOpenSSLProviders     = "openssl-providers"
+	CheckLdCache              = "ld-cache"
+	CheckFipsBoot             = "fips-boot"
+	CheckLibc                 = "libc"
+	CheckSymbolsAvailable     = "symbols-available"
+	CheckNotPacked            = "not-packed"
+	CheckPrivilegedCrypto     = "privileged-crypto"
+	CheckRuntimeCrypto        = "runtime-crypto"
+)
+
+// Status is the outcome of validating a binary.
+type Status string
+
+const (
+	StatusPassed  Status = "passed"
+	StatusFailed  Status = "failed"
+	StatusSkipped Status = "skipped"
+)
+
+// SkipReason is a machine-readable code explaining why a binary was skipped.
+type SkipReason string
+
+const (
+	SkipShellScript SkipReason = "shell-script"
+	SkipReadError   SkipReason = "read-error"
+	SkipNotElf      SkipReason = "not-elf-executable"
+	SkipNoCrypto    SkipReason = "no-crypto"
+	// SkipNonElfPlatform is used for executables of other platforms, such
+	// as Windows (PE) or macOS (Mach-O). The result's detail names the format.
+	SkipNonElfPlatform SkipReason = "non-elf-platform"
+	// SkipNoSymbols is used for binaries without any symbols, whose crypto
+	// usage can't be determined. They fail instead with Policy.Strict.
+	SkipNoSymbols SkipReason = "no-symbols"
+	// SkipArch is used for binaries of architectures excluded from the scan.
+	// The result's detail names the platform, e.g. "linux/arm64".
+	SkipArch SkipReason = "arch"
+	// SkipHardlink is used for hard links of a binary that was already
+	// validated under another path. The result's detail names that path.
+	SkipHardlink SkipReason = "hardlink"
+	// SkipPacked is used for binaries compressed with an executable packer
+	// that couldn't be unpacked. They fail instead with Policy.Strict. The
+	// result's detail names the packer, e.g. "UPX".
+	SkipPacked SkipReason = "packed"
+	// SkipTooLarge is used for files larger than the maximum file size of a
+	// scan. The result's detail is the file's size.
+	SkipTooLarge SkipReason = "too-large"
+	// SkipNotBuilt is used for binaries listed for validation that don't
+	// exist, e.g. the targets of packages that haven't been built yet.
+	SkipNotBuilt SkipReason = "not-built"
+)
+
+// Severity is the severity of a finding. Only errors make validation fail.
+type Severity string
+
+const (
+	SeverityError   Severity = "error"
+	SeverityWarning Severity = "warning"
+	// SeverityInfo is used for findings that explain the environment
+	// rather than point out a problem.
+	SeverityInfo Severity = "info"
+)
+
+// Finding is a problem that a check found in a binary.
+type Finding struct {
+	Check    string   `json:"check"`
+	Severity Severity `json:"severity"`
+	Message  string   `json:"message"`
+	// Hint suggests how to fix the problem.
+	Hint string `json:"hint,omitempty"`
+}
+
+// BinaryResult is the result of validating a single binary.
+type BinaryResult struct {
+	Path   string     `json:"path"`
+	Status Status     `json:"status"`
+	Reason SkipReason `json:"reason,omitempty"`
+	Detail string     `json:"detail,omitempty"`
+	// CompareStatus is the status of the binary under the policy's
+	// CompareRules, if set and the binary was validated.
+	CompareStatus Status `json:"compareStatus,omitempty"`
+	// SHA256 is the hex-encoded SHA-256 digest of the file, if requested,
+	// e.g. for manifests.
+	SHA256 string `json:"sha256,omitempty"`
+	// Packer is the executable packer that compressed the binary, e.g.
+	// "UPX". Packed binaries are validated after unpacking them, if possible.
+	Packer string `json:"packer,omitempty"`
+	// Libcrypto is the path of the libcrypto the binary loads at runtime.
+	Libcrypto string `json:"libcrypto,omitempty"`
+	// BuildMode is the -buildmode Go binaries were built with, e.g. "exe",
+	// "pie", "c-shared", or "plugin".
+	BuildMode string `json:"buildMode,omitempty"`
+	// Platform is the GOOS/GOARCH Go binaries were built for, e.g.
+	// "linux/arm64".
+	Platform string `json:"platform,omitempty"`
+	// VCS is the version control information of Go binaries. It is nil for
+	// other binaries.
+	VCS *VCSInfo `json:"vcs,omitempty"`
+	// Runtime is the managed runtime, e.g. ".NET", whose crypto shim the
+	// library is, see RuntimeCryptoShim.
+	Runtime string `json:"runtime,omitempty"`
+	// GoBuildID is the build ID the Go toolchain recorded in the binary,
+	// for correlating it with a CI build.
+	GoBuildID string `json:"goBuildID,omitempty"`
+	// DebugInfo is the path of the separate debuginfo file whose symbols
+	// were used for a stripped binary. DynamicSymbolsOnly is set if the
+	// binary is stripped and no debuginfo file was found, so that it could
+	// only be evaluated on its dynamic symbols.
+	DebugInfo string `json:"debugInfo,omitempty"`
+	// Setuid and Setgid are set if the binary has the setuid or setgid
+	// bit, so that it runs with the privileges of its owner or group.
+	Setuid bool `json:"setuid,omitempty"`
+	Setgid bool `json:"setgid,omitempty"`
+	// StaticExemption describes the policy exemption that allowed the
+	// binary to be statically linked.
+	StaticExemption    string    `json:"staticExemption,omitempty"`
+	DynamicSymbolsOnly bool      `json:"dynamicSymbolsOnly,omitempty"`
+	Findings           []Finding `json:"findings,omitempty"`
+}
+
+// VCSInfo is the version control information embedded in a Go binary. Fields
+// are empty, and Modified is nil, if the binary was built with -buildvcs=false
+// or outside of a repository.
+type VCSInfo struct {
+	System   string `json:"system,omitempty"`
+	Revision string `json:"revision,omitempty"`
+	Time     string `json:"time,omitempty"`
+	Modified *bool  `json:"modified,omitempty"`
+}
+
+func (r *BinaryResult) skip(reason SkipReason, detail string) *BinaryResult {
+	r.Status = StatusSkipped
+	r.Reason = reason
+	r.Detail = detail
+	return r
+}
+
+// inconclusive reports ce for a binary whose validation is inconclusive. The
+// binary is skipped with the warning, or fails with Policy.Strict.
+func (r *BinaryResult) inconclusive(ce *CheckError, reason SkipReason, detail string, policy *Policy) *BinaryResult {
+	if policy.Strict {
+		ce.Severity = SeverityError
+		r.Status = StatusFailed
+		r.Findings = append(r.Findings, newFinding(ce))
+		return r
+	}
+	r.Findings = append(r.Findings, newFinding(ce))
+	return r.skip(reason, detail)
+}
+
+// CheckError is an error reported by the check with the given ID. Hint
+// optionally overrides the check's generic remediation hint. Errors without a
+// Severity are reported with SeverityError.
+type CheckError struct {
+	Check    string
+	Err      error
+	Hint     string
+	Severity Severity
+}
+
+func (e *CheckError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *CheckError) Unwrap() error {
+	return e.Err
+}
+
+// GoVersionTooOldError is reported for Go binaries built with a Go version
+// that predates FIPS support in the Go toolchain.
+type GoVersionTooOldError struct {
+	Version string
+	Minimum string
+}
+
+func (e *GoVersionTooOldError) Error() string {
+	return fmt.Sprintf("uses Go version %s, which is too old for FIPS validation (requires >= %s)", e.Version, e.Minimum)
+}
+
+// GoVersionTooNewError is reported for Go binaries built with a Go version
+// that is newer than the rules of this release of fips-validator.
+type GoVersionTooNewError struct {
+	Version string
+}
+
+func (e *GoVersionTooNewError) Error() string {
+	return fmt.Sprintf("uses Go version %s, which is newer than the versions supported by this release of fips-validator", e.Version)
+}
+
+func checkErrorf(check string, format string, a ...interface{}) error {
+	return &CheckError{Check: check, Err: fmt.Errorf(format, a...)}
+}
+
+// newFinding converts a check error into a finding. Errors that haven't been
+// attributed to a check are reported without a check ID.
+func newFinding(err error) Finding {
+	var ce *CheckError
+	if errors.As(err, &ce) {
+		severity := ce.Severity
+		if severity == "" {
+			severity = SeverityError
+		}
+		return Finding{Check: ce.Check, Severity: severity, Message: ce.Error(), Hint: hintFor(ce)}
+	}
+	return Finding{Severity: SeverityError, Message: err.Error()}
+}
@@ -0,0 +1,177 @@
+package validation
+
+import (
+	"bytes"
+	"debug/buildinfo"
+	_ "embed"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+	"gopkg.in/yaml.v3"
+)
+
+// Rules are the rules for Go binaries that depend on the Go version they were
+// built with: the symbols they must define and how they must enforce FIPS
+// mode. They change with Go releases, so other rules can be loaded from a
+// file to see how a change affects binaries before adopting it.
+type Rules struct {
+	// Version names the rules in comparisons, e.g. "2025-03".
+	Version string `yaml:"version"`
+	// MinGoVersion is the oldest Go version covered by the rules. Binaries
+	// built with older versions predate FIPS support in the Go toolchain,
+	// while binaries built with versions not covered by any rule are newer
+	// than the rules know about.
+	MinGoVersion string `yaml:"minGoVersion"`
+	// GoVersions are the rules for ranges of Go versions. The first rule
+	// whose versions match a binary's Go version applies.
+	GoVersions []GoVersionRule `yaml:"goVersions"`
+
+	minGoVersion *semver.Version
+}
+
+// GoVersionRule is the rule for Go binaries built with some Go versions.
+type GoVersionRule struct {
+	// Versions is a semver constraint, e.g. ">= 1.23".
+	Versions string `yaml:"versions"`
+	// RequiredSymbols lists symbols the binaries must define.
+	RequiredSymbols []string `yaml:"requiredSymbols"`
+	// FIPSEnforcement lists the build settings that make the binaries
+	// refuse to run unless the system is in FIPS mode. One of them must be
+	// present.
+	FIPSEnforcement []FIPSEnforcement `yaml:"fipsEnforcement"`
+
+	versions *semver.Constraints
+}
+
+// FIPSEnforcement is a build setting that makes a Go binary refuse to run
+// unless the system is in FIPS mode, or to use crypto outside of FIPS mode: a
+// GOEXPERIMENT, a build tag, or a default GODEBUG setting, e.g. fips140=only
+// set by a //go:debug directive, which Go 1.24 and later understand.
+type FIPSEnforcement struct {
+	GoExperiment string `yaml:"goExperiment"`
+	BuildTag     string `yaml:"buildTag"`
+	GoDebug      string `yaml:"godebug"`
+}
+
+func (e FIPSEnforcement) String() string {
+	switch {
+	case e.GoExperiment != "":
+		return "GOEXPERIMENT=" + e.GoExperiment
+	case e.BuildTag != "":
+		return "-tags " + e.BuildTag
+	}
+	return "//go:debug " + e.GoDebug
+}
+
+func (e FIPSEnforcement) present(bi *buildinfo.BuildInfo) bool {
+	switch {
+	case e.GoExperiment != "":
+		return hasGoExperiment(bi, e.GoExperiment)
+	case e.BuildTag != "":
+		return slices.Contains(getBuildTags(bi), e.BuildTag)
+	}
+	return slices.Contains(strings.Split(getBuildSetting(bi, "DefaultGODEBUG"), ","), e.GoDebug)
+}
+
+//go:embed default-rules.yaml
+var defaultRulesData []byte
+
+var defaultRules = func() *Rules {
+	r, err := ParseRules(defaultRulesData)
+	if err != nil {
+		panic(fmt.Errorf("validation: can't parse default rules: %w", err))
+	}
+	return r
+}()
+
+// DefaultRules returns the rules built into this release of fips-validator.
+func DefaultRules() *Rules {
+	return defaultRules
+}
+
+// LoadRules reads a YAML rules file.
+func LoadRules(path string) (*Rules, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read rules file: %v", err)
+	}
+	r, err := ParseRules(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse rules file %s: %v", path, err)
+	}
+	return r, nil
+}
+
+// ParseRules parses YAML rules. Unlike policies, rules aren't applied on top
+// of the built-in rules, but replace them.
+func ParseRules(data []byte) (*Rules, error) {
+	r := &Rules{}
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(r); err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+	if err := r.validate(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *Rules) validate() error {
+	if r.Version == "" {
+		return errors.New("version must be set")
+	}
+	v, err := semver.NewVersion(r.MinGoVersion)
+	if err != nil {
+		return fmt.Errorf("minGoVersion: invalid version %q: %v", r.MinGoVersion, err)
+	}
+	r.minGoVersion = v
+	if len(r.GoVersions) == 0 {
+		return errors.New("goVersions must not be empty")
+	}
+	for i := range r.GoVersions {
+		if err := r.GoVersions[i].validate(); err != nil {
+			return fmt.Errorf("goVersions[%d]: %v", i, err)
+		}
+	}
+	return nil
+}
+
+func (gr *GoVersionRule) validate() error {
+	c, err := semver.NewConstraint(gr.Versions)
+	if err != nil {
+		return fmt.Errorf("versions: invalid constraint %q: %v", gr.Versions, err)
+	}
+	gr.versions = c
+	for i, e := range gr.FIPSEnforcement {
+		set := 0
+		for _, v := range []string{e.GoExperiment, e.BuildTag, e.GoDebug} {
+			if v != "" {
+				set++
+			}
+		}
+		if set != 1 {
+			return fmt.Errorf("fipsEnforcement[%d]: exactly one of goExperiment, buildTag, and godebug must be set", i)
+		}
+		if k, v, ok := strings.Cut(e.GoDebug, "="); e.GoDebug != "" && (!ok || k == "" || v == "") {
+			return fmt.Errorf("fipsEnforcement[%d]: godebug: invalid setting %q (must be key=value)", i, e.GoDebug)
+		}
+	}
+	return nil
+}
+
+// forGoVersion returns the rule for binaries built with goVersion, or nil if
+// no rule covers it.
+func (r *Rules) forGoVersion(goVersion *semver.Version) *GoVersionRule {
+	for i := range r.GoVersions {
+		if r.GoVersions[i].versions.Check(goVersion) {
+			return &r.GoVersions[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+package validation
+
+import (
+	"debug/buildinfo"
+	"runtime/debug"
+	"strings"
+	"testing"
+)
+
+// goBinaryBuildInfo returns the build info of a Go binary with the given build
+// settings.
+func goBinaryBuildInfo(goVersion string, settings ...string) *buildinfo.BuildInfo {
+	bi := &buildinfo.BuildInfo{GoVersion: goVersion}
+	for _, s := range settings {
+		k, v, _ := strings.Cut(s, "=")
+		bi.Settings = append(bi.Settings, debug.BuildSetting{Key: k, Value: v})
+	}
+	return bi
+}
+
+func TestDefaultRulesFIPSEnforcement(t *testing.T) {
+	tests := []struct {
+		name string
+		bi   *buildinfo.BuildInfo
+		want bool
+	}{
+		{name: "1.23 strictfipsruntime", bi: goBinaryBuildInfo("go1.23.4", "GOEXPERIMENT=strictfipsruntime"), want: true},
+		{name: "1.23 requirefips", bi: goBinaryBuildInfo("go1.23.4", "-tags=requirefips"), want: true},
+		{name: "1.23 no GOEXPERIMENT", bi: goBinaryBuildInfo("go1.23.4"), want: false},
+		{name: "1.23 other GOEXPERIMENT", bi: goBinaryBuildInfo("go1.23.4", "GOEXPERIMENT=boringcrypto"), want: false},
+		// The fips140 GODEBUG setting only exists since Go 1.24.
+		{name: "1.23 fips140=only", bi: goBinaryBuildInfo("go1.23.4", "DefaultGODEBUG=fips140=only"), want: false},
+		{name: "1.24 strictfipsruntime", bi: goBinaryBuildInfo("go1.24.2", "GOEXPERIMENT=strictfipsruntime"), want: true},
+		{name: "1.24 fips140=only", bi: goBinaryBuildInfo("go1.24.2", "DefaultGODEBUG=fips140=only"), want: true},
+		{name: "1.27 fips140=only among others", bi: goBinaryBuildInfo("go1.27.1", "DefaultGODEBUG=containermaxprocs=0,fips140=only,tlssha1=1"), want: true},
+		{name: "1.24 fips140=on", bi: goBinaryBuildInfo("go1.24.2", "DefaultGODEBUG=fips140=on"), want: false},
+		{name: "1.24 no GOEXPERIMENT", bi: goBinaryBuildInfo("go1.24.2"), want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v, err := parseGoVersion(tt.bi.GoVersion)
+			if err != nil {
+				t.Fatal(err)
+			}
+			errs := validateGoFIPSEnforcement(tt.bi, DefaultPolicy(), v, t.Logf)
+			if got := len(errs) == 0; got != tt.want {
+				t.Errorf("validateGoFIPSEnforcement() = %v, want passing %v", errs, tt.want)
+			}
+		})
+	}
+}
+
+func TestDefaultRulesVersionSpecific(t *testing.T) {
+	v123, _ := parseGoVersion("go1.23.4")
+	v124, _ := parseGoVersion("go1.24.0")
+	r123, r124 := DefaultRules().forGoVersion(v123), DefaultRules().forGoVersion(v124)
+	if r123 == nil || r124 == nil || r123 == r124 {
+		t.Fatalf("rules for Go 1.23 and 1.24 = %v, %v, want two different rules", r123, r124)
+	}
+	for _, e := range r123.FIPSEnforcement {
+		if e.GoDebug != "" {
+			t.Errorf("rule for Go 1.23 accepts %s, which Go 1.23 doesn't support", e)
+		}
+	}
+}
+
+func TestParseRulesFIPSEnforcement(t *testing.T) {
+	rules := func(enforcement string) []byte {
+		return []byte("version: test\nminGoVersion: 1.23.0\ngoVersions:\n  - versions: \">= 1.23\"\n    fipsEnforcement:\n      - " + enforcement + "\n")
+	}
+	for _, e := range []string{"goExperiment: strictfipsruntime", "buildTag: requirefips", "godebug: fips140=only"} {
+		if _, err := ParseRules(rules(e)); err != nil {
+			t.Errorf("ParseRules(%s): %v", e, err)
+		}
+	}
+	for enforcement, want := range map[string]string{
+		"{goExperiment: strictfipsruntime, godebug: fips140=only}": "exactly one of",
+		"{}":                 "exactly one of",
+		"godebug: fips140":   "must be key=value",
+		"godebug: =only":     "must be key=value",
+		"godebug: fips140=":  "must be key=value",
+		"gofips140: v1.0.0":  "field gofips140 not found",
+		"buildTag: \"\"":     "exactly one of",
+		"goExperiment: \"\"": "exactly one of",
+	} {
+		if _, err := ParseRules(rules(enforcement)); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("ParseRules(%s) error = %v, want error containing %q", enforcement, err, want)
+		}
+	}
+}
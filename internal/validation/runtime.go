@@ -0,0 +1,63 @@
+package validation
+
+import (
+	"fmt"
+	"io/fs"
+	"path"
+	"slices"
+
+	"github.com/flightctl/fips-validator/pkg/elfinfo"
+)
+
+// runtimeCryptoShims maps the file names of the native libraries through which
+// managed runtimes do crypto on Linux to the runtime. .NET's
+// System.Security.Cryptography calls its OpenSSL shim, which links the
+// system's libssl and libcrypto or, in portable builds, dlopen()s them by
+// SONAME.
+var runtimeCryptoShims = map[string]string{
+	"libSystem.Security.Cryptography.Native.OpenSsl.so": ".NET",
+}
+
+// runtimeLibcryptoNames are the SONAMEs of libcrypto that portable crypto
+// shims of managed runtimes try to dlopen(), in order of preference.
+var runtimeLibcryptoNames = []string{"libcrypto.so.3", "libcrypto.so.1.1"}
+
+// RuntimeCryptoShim returns the managed runtime, e.g. ".NET", whose crypto
+// shim the library with the given file name is, or "" if it isn't one. Crypto
+// shims are validated like shared libraries that use crypto, whatever their
+// symbols.
+func RuntimeCryptoShim(name string) string {
+	return runtimeCryptoShims[name]
+}
+
+// validateRuntimeCrypto checks that the crypto shim of a managed runtime at
+// shim within fsys loads the system's libcrypto, which must be FIPS-capable
+// (see validateOpenSSLLinkage), rather than a libcrypto bundled with the
+// runtime or application, which the system's FIPS configuration doesn't
+// cover.
+func validateRuntimeCrypto(fsys fs.FS, shim string, info *elfinfo.ElfInfo, libcrypto string) []error {
+	runtime := RuntimeCryptoShim(path.Base(shim))
+	if runtime == "" {
+		return []error{}
+	}
+	if libcrypto != "" && !slices.Contains(defaultLibraryPaths(fsys, info), path.Dir(libcrypto)) {
+		return []error{checkErrorf(CheckRuntimeCrypto, "%s crypto shim loads libcrypto from %s instead of the system's", runtime, libcrypto)}
+	}
+	// Portable shims load libcrypto by SONAME, but a copy next to the shim
+	// shows that the runtime was meant to use its own.
+	entries, _ := fs.ReadDir(fsys, fsName(path.Dir(shim)))
+	for _, e := range entries {
+		if cryptoLibRegex.MatchString(e.Name()) {
+			return []error{checkErrorf(CheckRuntimeCrypto, "%s crypto shim comes with its own libcrypto %s", runtime, path.Join(path.Dir(shim), e.Name()))}
+		}
+	}
+	if libcrypto != "" {
+		return []error{}
+	}
+	return []error{&CheckError{
+		Check:    CheckRuntimeCrypto,
+		Err:      fmt.Errorf("%s crypto shim doesn't find a libcrypto; its crypto depends on the libcrypto of the system it runs on", runtime),
+		Hint:     "validate the image or directory the runtime is installed in",
+		Severity: SeverityInfo,
+	}}
+}
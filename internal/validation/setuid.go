@@ -0,0 +1,58 @@
+package validation
+
+import (
+	"fmt"
+	"io/fs"
+)
+
+// readModeBits records in result whether the binary at path within fsys has
+// the setuid or setgid bit set.
+func readModeBits(fsys fs.FS, path string, result *BinaryResult) {
+	fi, err := fs.Stat(fsys, fsName(path))
+	if err != nil {
+		return
+	}
+	result.Setuid = fi.Mode()&fs.ModeSetuid != 0
+	result.Setgid = fi.Mode()&fs.ModeSetgid != 0
+}
+
+// Privilege describes the setuid and setgid bits of a binary, or returns ""
+// if neither is set.
+func (r *BinaryResult) Privilege() string {
+	switch {
+	case r.Setuid && r.Setgid:
+		return "setuid and setgid"
+	case r.Setuid:
+		return "setuid"
+	case r.Setgid:
+		return "setgid"
+	}
+	return ""
+}
+
+// validatePrivileged calls out setuid and setgid binaries that use crypto,
+// which run with the privileges of their owner or group: one failing
+// validation gets an additional error, as its non-FIPS crypto is exposed to
+// every user, and one passing it a note, as it warrants extra scrutiny.
+func validatePrivileged(result *BinaryResult) []error {
+	privilege := result.Privilege()
+	if privilege == "" {
+		return []error{}
+	}
+	if result.Status == StatusFailed {
+		return []error{&CheckError{
+			Check: CheckPrivilegedCrypto,
+			Err:   fmt.Errorf("%s binary fails FIPS validation and runs with elevated privileges for every user", privilege),
+		}}
+	}
+	bits := privilege + " bit"
+	if result.Setuid && result.Setgid {
+		bits += "s"
+	}
+	return []error{&CheckError{
+		Check:    CheckPrivilegedCrypto,
+		Err:      fmt.Errorf("%s binary uses crypto, which warrants extra scrutiny", privilege),
+		Hint:     "review which crypto the binary uses and whether it needs the " + bits,
+		Severity: SeverityInfo,
+	}}
+}
@@ -0,0 +1,65 @@
+package validation
+
+import (
+	"debug/elf"
+	"io"
+	"regexp"
+	"slices"
+
+	"github.com/flightctl/fips-validator/pkg/elfinfo"
+)
+
+// opensslVersionText matches OpenSSL's version string (OPENSSL_VERSION_TEXT),
+// e.g. "OpenSSL 3.0.7 1 Nov 2022" or "OpenSSL 1.0.2k-fips  26 Jan 2017", which
+// libcrypto embeds for OpenSSL_version().
+var opensslVersionText = regexp.MustCompile(`OpenSSL ([0-9]+\.[0-9]+\.[0-9]+[a-z]*)(-fips)? +[0-9]{1,2} [A-Z][a-z]{2} [0-9]{4}`)
+
+// opensslFunctions are functions that every libcrypto defines, so that a
+// binary defining one of them contains libcrypto's code.
+var opensslFunctions = []string{"OPENSSL_init_crypto", "OpenSSL_version", "SSLeay", "CRYPTO_malloc", "EVP_DigestInit_ex"}
+
+// opensslProviderInit is the entry point of OpenSSL 3 providers, which
+// implement their own crypto without linking libcrypto.
+var opensslProviderInit = []string{"OSSL_provider_init"}
+
+// bundlesOpenSSL returns the evidence that the C or C++ binary read from r
+// contains OpenSSL's code, e.g. because it was statically linked against
+// libcrypto.a, or "" if there is none: a definition of one of
+// opensslFunctions, or OpenSSL's version string. Binaries that link libcrypto,
+// libcrypto itself, and OpenSSL's providers, e.g. the FIPS provider fips.so,
+// are never reported. Go binaries are left to the Go checks.
+func bundlesOpenSSL(r io.ReaderAt, info *elfinfo.ElfInfo, debugFunc func(string, ...interface{})) string {
+	if info.GoBuildID != "" || slices.Contains(info.Sections, ".go.buildinfo") || cryptoLibRegex.MatchString(info.Soname) {
+		return ""
+	}
+	if slices.ContainsFunc(info.Needed, cryptoLibRegex.MatchString) || definesAnyFunction(info, opensslProviderInit) {
+		return ""
+	}
+	for _, syms := range [][]elf.Symbol{info.Symbols, info.DynamicSymbols} {
+		for _, sym := range syms {
+			if sym.Section != elf.SHN_UNDEF && elf.ST_TYPE(sym.Info) == elf.STT_FUNC && slices.Contains(opensslFunctions, sym.Name) {
+				return "defines " + sym.Name
+			}
+		}
+	}
+	version, err := elfinfo.FindString(r, opensslVersionText)
+	if err != nil {
+		debugFunc("failed to search read-only data for OpenSSL's version: %v", err)
+		return ""
+	}
+	if version != "" {
+		return "contains \"" + version + "\""
+	}
+	return ""
+}
+
+// validateNoStaticOpenSSL fails C and C++ binaries that contain OpenSSL's code
+// instead of loading the system's libcrypto, so that their crypto doesn't use
+// the FIPS-validated module. Unlike CheckDynamicLinking, this also applies to
+// dynamically-linked binaries linked against libcrypto.a.
+func validateNoStaticOpenSSL(r io.ReaderAt, info *elfinfo.ElfInfo, debugFunc func(string, ...interface{})) []error {
+	if evidence := bundlesOpenSSL(r, info, debugFunc); evidence != "" {
+		return []error{checkErrorf(CheckStaticOpenSSL, "statically links OpenSSL (%s)", evidence)}
+	}
+	return []error{}
+}
@@ -0,0 +1,88 @@
+package validation
+
+import (
+	"bytes"
+	"debug/elf"
+	"testing"
+
+	"github.com/flightctl/fips-validator/pkg/elfinfo"
+)
+
+// function returns a symbol of a function defined in the text section.
+func function(name string) elf.Symbol {
+	return elf.Symbol{Name: name, Info: elf.ST_INFO(elf.STB_GLOBAL, elf.STT_FUNC), Section: 14}
+}
+
+func TestBundlesOpenSSL(t *testing.T) {
+	undefined := elf.Symbol{Name: "OPENSSL_init_crypto", Info: elf.ST_INFO(elf.STB_GLOBAL, elf.STT_FUNC), Section: elf.SHN_UNDEF}
+	tests := []struct {
+		name string
+		info *elfinfo.ElfInfo
+		want string
+	}{
+		{
+			name: "static libcrypto",
+			info: &elfinfo.ElfInfo{Symbols: []elf.Symbol{function("main"), function("OPENSSL_init_crypto")}},
+			want: "defines OPENSSL_init_crypto",
+		},
+		{
+			name: "exported OpenSSL function",
+			info: &elfinfo.ElfInfo{DynamicSymbols: []elf.Symbol{function("EVP_DigestInit_ex")}},
+			want: "defines EVP_DigestInit_ex",
+		},
+		{
+			name: "undefined reference",
+			info: &elfinfo.ElfInfo{DynamicSymbols: []elf.Symbol{undefined}},
+			want: "",
+		},
+		{
+			name: "links libcrypto",
+			info: &elfinfo.ElfInfo{Needed: []string{"libc.so.6", "libcrypto.so.3"}, Symbols: []elf.Symbol{function("OPENSSL_init_crypto")}},
+			want: "",
+		},
+		{
+			name: "libcrypto",
+			info: &elfinfo.ElfInfo{Soname: "libcrypto.so.3", DynamicSymbols: []elf.Symbol{function("OPENSSL_init_crypto")}},
+			want: "",
+		},
+		{
+			name: "provider",
+			info: &elfinfo.ElfInfo{DynamicSymbols: []elf.Symbol{function("OSSL_provider_init"), function("OPENSSL_init_crypto")}},
+			want: "",
+		},
+		{
+			name: "Go binary",
+			info: &elfinfo.ElfInfo{GoBuildID: "abc/def", Symbols: []elf.Symbol{function("OPENSSL_init_crypto")}},
+			want: "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// The reader isn't an ELF file, so no version string is found
+			// in it.
+			got := bundlesOpenSSL(bytes.NewReader(nil), tt.info, func(string, ...interface{}) {})
+			if got != tt.want {
+				t.Errorf("bundlesOpenSSL() = %q, want %q", got, tt.want)
+			}
+			errs := validateNoStaticOpenSSL(bytes.NewReader(nil), tt.info, func(string, ...interface{}) {})
+			if (len(errs) > 0) != (tt.want != "") {
+				t.Errorf("validateNoStaticOpenSSL() = %v, want failure %v", errs, tt.want != "")
+			}
+		})
+	}
+}
+
+func TestOpenSSLVersionText(t *testing.T) {
+	for text, want := range map[string]string{
+		"\x00OpenSSL 3.0.7 1 Nov 2022\x00":         "OpenSSL 3.0.7 1 Nov 2022",
+		"\x00OpenSSL 1.0.2k-fips  26 Jan 2017\x00": "OpenSSL 1.0.2k-fips  26 Jan 2017",
+		"\x00OpenSSL 1.1.1w  11 Sep 2023\x00":      "OpenSSL 1.1.1w  11 Sep 2023",
+		"\x00OpenSSL 3.0 is required\x00":          "",
+		"\x00built with OpenSSL 3.0.7\x00":         "",
+		"\x00OpenSSL 3.0.7 unknown date 2022\x00":  "",
+	} {
+		if got := opensslVersionText.FindString(text); got != want {
+			t.Errorf("opensslVersionText.FindString(%q) = %q, want %q", text, got, want)
+		}
+	}
+}
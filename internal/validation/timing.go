@@ -0,0 +1,64 @@
+package validation
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// Phases of validating a binary that are timed like checks, as they may take
+// longer than the checks themselves on large binaries.
+const (
+	PhaseReadELF       = "(read ELF info)"
+	PhaseReadBuildInfo = "(read Go build info)"
+	PhaseCryptoUsage   = "(detect crypto usage)"
+)
+
+// CheckTime is the time spent in a check, or a phase of validating binaries,
+// by all validations of the process so far. Slow scans of large corpora can be
+// attributed to the checks that dominate them, e.g. to guide optimizations.
+type CheckTime struct {
+	// Check is the check's ID, or one of the phases, e.g. PhaseReadELF.
+	Check string
+	// Calls is the number of binaries the check ran for, Total the time
+	// spent in it.
+	Calls int64
+	Total time.Duration
+}
+
+var (
+	checkTimesMu sync.Mutex
+	checkTimes   = map[string]*CheckTime{}
+)
+
+// addCheckTime adds the time elapsed since start to the time spent in check.
+func addCheckTime(check string, start time.Time) {
+	d := time.Since(start)
+	checkTimesMu.Lock()
+	defer checkTimesMu.Unlock()
+	t, ok := checkTimes[check]
+	if !ok {
+		t = &CheckTime{Check: check}
+		checkTimes[check] = t
+	}
+	t.Calls++
+	t.Total += d
+}
+
+// ReadCheckTimes returns the time spent in each check and phase by all
+// validations of the process so far, the slowest first.
+func ReadCheckTimes() []CheckTime {
+	checkTimesMu.Lock()
+	defer checkTimesMu.Unlock()
+	times := make([]CheckTime, 0, len(checkTimes))
+	for _, t := range checkTimes {
+		times = append(times, *t)
+	}
+	sort.Slice(times, func(i, j int) bool {
+		if times[i].Total != times[j].Total {
+			return times[i].Total > times[j].Total
+		}
+		return times[i].Check < times[j].Check
+	})
+	return times
+}
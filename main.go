@@ -1,30 +1,104 @@
 package main
 
 import (
+	"bytes"
 	"context"
 	"errors"
 	"flag"
 	"fmt"
+	"io"
 	"os"
+	"os/exec"
 	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
 
+	"github.com/Masterminds/semver/v3"
 	"github.com/fatih/color"
+	"golang.org/x/term"
 
+	"github.com/flightctl/fips-validator/internal/archive"
 	"github.com/flightctl/fips-validator/internal/executor"
+	"github.com/flightctl/fips-validator/internal/report"
+	"github.com/flightctl/fips-validator/internal/rpm"
 	"github.com/flightctl/fips-validator/internal/scanner"
 	"github.com/flightctl/fips-validator/internal/validation"
+	"github.com/flightctl/fips-validator/pkg/elfinfo"
 )
 
 var (
-	debugEnabled bool
-	noColor      bool
-	help         bool
+	configFile      string
+	debugEnabled    bool
+	noColor         bool
+	outputFormat    string
+	jsonCompact     bool
+	formatVersion   int
+	outputFile      string
+	groupBy         string
+	interactive     bool
+	archiveDepth    int
+	languagePkgs    bool
+	noHints         bool
+	requireCov      bool
+	symbolSource    string
+	rootDir         string
+	inProcess       bool
+	strictProviders bool
+	requireFipsBoot bool
+	strict          bool
+	failFast        bool
+	opensslOnly     bool
+	noOpenSSL       bool
+	providerVersion string
+	rulesFile       string
+	compareRules    string
+	sharedObjs      bool
+	jobs            int
+	targetJobs      int
+	maxFailures     int
+	silentOnSuccess bool
+	summaryOnly     bool
+	noPull          bool
+	oneFileSystem   bool
+	sinceGit        string
+	help            bool
+
+	onlyArch       archList
+	excludeArch    archList
+	policyFlag     policyValue
+	policy         *validation.Policy
+	maxExtractSize = byteSize(10 << 30)
+	maxFileSize    byteSize
+)
+
+// tableWidth is the width of the terminal that binary results are printed to
+// as aligned columns, or zero if they are printed in the plain format.
+var tableWidth int
+
+// out receives all human-readable output. It is discarded when a machine
+// output format is selected so that stdout only contains the report.
+var out io.Writer = color.Output
+
+var (
+	info    = printfFunc(color.New(color.Bold))
+	success = printfFunc(color.New(color.Bold, color.FgGreen))
+	failure = printfFunc(color.New(color.Bold, color.FgRed))
 )
 
+func printfFunc(c *color.Color) func(format string, a ...interface{}) {
+	return func(format string, a ...interface{}) {
+		c.Fprintf(out, format, a...)
+	}
+}
+
+// finfo and fsuccess are like info and success, but print to w instead of
+// out, e.g. to the buffered output of an image validated concurrently with
+// others.
 var (
-	info    = color.New(color.Bold).PrintfFunc()
-	success = color.New(color.Bold, color.FgGreen).PrintfFunc()
-	failure = color.New(color.Bold, color.FgRed).PrintfFunc()
+	finfo    = color.New(color.Bold).Fprintf
+	fsuccess = color.New(color.Bold, color.FgGreen).Fprintf
 )
 
 func debug(format string, a ...interface{}) {
@@ -40,101 +114,969 @@ func usage(err error) {
 		fmt.Fprintf(fd, "Error: %v\n\n", err)
 	}
 
-	fmt.Fprintf(fd, `%[1]s validates that an RPM package, OCI image, or binary is capable of running in FIPS mode.
+	fmt.Fprintf(fd, `%[1]s validates that an RPM package, OCI image, ostree commit, archive, squashfs image, directory tree, or binary is capable of running in FIPS mode.
 
 Usage:
-  %[1]s [flags] binary <path_to_executable>
+  %[1]s [flags] binary <path_to_executable_or_name>
+  %[1]s [flags] binary --from-file <path>
   %[1]s [flags] rpm <path_to_rpm_file>
   podman unshare -- %[1]s [flags] image <oci_image_ref>
+  podman unshare -- %[1]s [flags] image --all [--filter <pattern>] [--checkpoint <path>]
+  podman unshare -- %[1]s [flags] image --from-file <path> [--checkpoint <path>]
+  %[1]s [flags] dir <path_to_root_filesystem>
+  %[1]s [flags] ostree <path_to_repo> <ref_or_commit>
+  %[1]s [flags] tar <path_to_archive>
+  %[1]s [flags] zip <path_to_zip_archive>
+  %[1]s [flags] squashfs <path_to_squashfs_image>
+  %[1]s [flags] auto <target>
+  %[1]s [flags] verify --manifest <path> <mode> <target>...
+  %[1]s [flags] diff <mode> <old_target> <new_target>
+  %[1]s explain [<check_id>]
+  %[1]s capabilities [--output json]
+  %[1]s selftest
 
 Flags:
-  --debug      Enable debug output
-  --no-color   Disable colored output
-  --help       Show this help message
-`, filepath.Base(os.Args[0]))
+  --config <path>  Read flag values from a YAML config file
+                   (default: %[2]s in the current directory, if present)
+  --policy <path>  Read the validation policy from a YAML policy file
+  --rules <path>   Validate Go binaries against the Go version rules in a
+                   YAML rules file instead of the built-in rules
+  --compare-rules <path>
+                   Also evaluate Go binaries against the rules in another
+                   rules file and list the binaries whose verdict differs
+  --debug          Enable debug output
+  --no-color       Disable colored output
+  --output <fmt>   Output format, one of "text" (default), "json", "manifest"
+                   for JSON with the SHA-256 digest of each file, to check
+                   against with "verify", or "attestation" for an in-toto
+                   statement
+  --output-file <path>
+                   Write the JSON output or attestation to a file instead of
+                   stdout; the file is replaced atomically once the report is
+                   complete
+  --json-compact   Print JSON output on a single line instead of pretty-printed
+  --format-version <n>
+                   Write JSON output, and the report passed to --on-complete,
+                   in version <n> of the report's schema (default: latest)
+  --group-by check For text and JSON output, list the binaries that failed by
+                   check instead of each binary with its findings
+  --no-hints       Don't suggest how to fix failed checks
+  --interactive    On a terminal, browse the failures after validation, with
+                   their findings collapsible, instead of listing them
+  --symbol-source <src>
+                   Symbol tables to search for crypto usage and required
+                   symbols: "auto" (.symtab, or .dynsym if stripped), "symtab",
+                   "dynsym", or "both" (overrides the policy's symbolSource)
+  --require-coverage
+                   Fail if no binary using crypto was validated or, for images
+                   and directories, no libcrypto was found
+  --root <dir>     For binary mode, the root filesystem the binary belongs to,
+                   which libraries are resolved in (default: /)
+  --require-fips-provider-version <constraint>
+                   For images, directories, and ostree commits, fail unless the
+                   version of the OpenSSL FIPS provider satisfies the semver
+                   constraint, e.g. ">= 3.0.7, < 3.1"
+  --in-process     Read libcrypto's symbols in-process instead of running nm;
+                   this is also done if binutils isn't installed
+  --strict         Fail binaries whose validation is inconclusive, e.g. fully
+                   stripped binaries whose crypto usage can't be determined
+                   (sets the policy's strict)
+  --fail-fast      Stop validating a binary at the first check that fails it,
+                   for faster yes/no answers; only that failure is reported
+                   (sets the policy's failFast)
+  --strict-openssl-providers
+                   Fail instead of warning if openssl.cnf activates the default
+                   provider alongside the FIPS provider (overrides the policy's
+                   openssl.defaultProvider)
+  --require-fips-boot
+                   Fail instead of warning if a bootable image or directory
+                   doesn't enable FIPS mode at boot with dracut's fips module
+                   and fips=1 (overrides the policy's boot.fipsMode)
+  --openssl-only   For images, directories, archives, and ostree commits, only
+                   validate the OpenSSL installation and skip the binaries
+  --no-openssl     Only validate the binaries and skip the OpenSSL installation
+  --shared-objects Also validate shared libraries; when scanning a target, files
+                   named *.so or *.so.* are validated even if not executable
+  --jobs <n>       Validate up to n binaries concurrently (default: 1); with
+                   more than one job, binaries are listed in no particular order
+  --target-jobs <n>
+                   With image --all or --from-file, validate up to n images
+                   concurrently (default: 1); images are still listed in order
+  --max-failures <n>
+                   Stop validating after n binaries failed (default: 0,
+                   unlimited)
+  --only-arch <platform>
+                   When scanning a target, only validate binaries of the given
+                   platform, e.g. linux/amd64; can be repeated or given as a
+                   comma-separated list
+  --exclude-arch <platform>
+                   When scanning a target, skip binaries of the given platform,
+                   e.g. linux/arm64 for QEMU user-mode emulation helpers; can
+                   be repeated or given as a comma-separated list
+  --no-pull        For images, fail if the image isn't present locally instead
+                   of pulling it, e.g. in air-gapped environments
+  --max-archive-depth <n>
+                   Extract and validate archives (.tar, .tar.gz, .zip) nested up
+                   to n levels deep inside the target (default: 0, disabled)
+  --language-packages
+                   With --max-archive-depth, also extract Python wheels and
+                   eggs (.whl, .egg) and Java archives (.jar) and validate the
+                   shared libraries they bundle
+  --max-extract-size <size>
+                   Abort when unpacking the target and its nested archives
+                   writes more than size bytes, e.g. 512M, counted for each
+                   target separately (default: 10G, 0 for unlimited)
+  --max-file-size <size>
+                   When scanning a target, skip executables larger than size
+                   bytes, e.g. 1G, instead of parsing them (default: 0,
+                   unlimited)
+  --one-file-system
+                   When scanning a target, don't descend into directories on
+                   other file systems, e.g. mounts below "dir /"
+  --since-git <ref>
+                   In dir mode, only validate the executables that changed
+                   since the git ref, e.g. HEAD~1, including untracked files
+                   that aren't ignored; the directory must be in a git
+                   repository
+  --silent-on-success
+                   Don't print anything if validation succeeds, e.g. for
+                   monitoring jobs; on failure, print the full output
+  --summary-only   For text output, don't print the result of each binary,
+                   only the counts by status, the failures by check, and the
+                   verdict
+  --on-complete <command>
+                   After validation, run command with the shell and the JSON
+                   report on its stdin, whatever the verdict; its failure is
+                   reported, but doesn't change the exit code
+  --cpuprofile <path>
+                   Write a CPU profile of the validation to path, for
+                   analysis with "go tool pprof"
+  --memprofile <path>
+                   Write a memory profile to path after the validation
+  --help           Show this help message
+
+Flags given on the command line override values from the config file.
 
-	os.Exit(rc)
+Go binaries must enforce FIPS mode with one of the settings the Go version
+rules list for their Go version, e.g. GOEXPERIMENT=strictfipsruntime or, with
+Go 1.24 and later, //go:debug fips140=only; unlike in earlier releases, this
+also applies to binaries built without any GOEXPERIMENT.
+`, filepath.Base(os.Args[0]), defaultConfigFile)
+
+	exit(rc)
 }
 
 func main() {
+	flag.StringVar(&configFile, "config", "", "Read flag values from a YAML config file")
+	flag.Var(&policyFlag, "policy", "Read the validation policy from a YAML policy file")
+	flag.StringVar(&rulesFile, "rules", "", "Read the Go version rules from a YAML rules file")
+	flag.StringVar(&compareRules, "compare-rules", "", "Compare the verdicts against the Go version rules in a YAML rules file")
 	flag.BoolVar(&debugEnabled, "debug", false, "Enable debug output")
 	flag.BoolVar(&noColor, "no-color", false, "Disable colored output")
+	flag.StringVar(&outputFormat, "output", "text", "Output format (text, json, manifest, or attestation)")
+	flag.StringVar(&outputFile, "output-file", "", "Write the JSON report or attestation to a file instead of stdout")
+	flag.BoolVar(&jsonCompact, "json-compact", false, "Print JSON output on a single line")
+	flag.IntVar(&formatVersion, "format-version", 0, "Version of the JSON report's schema (default: latest)")
+	flag.StringVar(&groupBy, "group-by", "", "Group the results by check (check)")
+	flag.BoolVar(&interactive, "interactive", false, "Browse the failures interactively on a terminal")
+	flag.BoolVar(&noHints, "no-hints", false, "Don't suggest how to fix failed checks")
+	flag.BoolVar(&requireCov, "require-coverage", false, "Fail if nothing was actually validated")
+	flag.StringVar(&symbolSource, "symbol-source", "", "Symbol tables to search (auto, symtab, dynsym, or both)")
+	flag.StringVar(&rootDir, "root", "", "Root filesystem the binary belongs to")
+	flag.StringVar(&providerVersion, "require-fips-provider-version", "", "Required version of the OpenSSL FIPS provider")
+	flag.BoolVar(&inProcess, "in-process", false, "Read libcrypto's symbols in-process instead of running nm")
+	flag.BoolVar(&strict, "strict", false, "Fail binaries whose validation is inconclusive")
+	flag.BoolVar(&failFast, "fail-fast", false, "Stop validating a binary at the first failed check")
+	flag.BoolVar(&strictProviders, "strict-openssl-providers", false, "Fail if openssl.cnf activates the default provider alongside the FIPS provider")
+	flag.BoolVar(&requireFipsBoot, "require-fips-boot", false, "Fail if a bootable target doesn't enable FIPS mode at boot")
+	flag.BoolVar(&opensslOnly, "openssl-only", false, "Only validate the OpenSSL installation, not the binaries")
+	flag.BoolVar(&noOpenSSL, "no-openssl", false, "Only validate the binaries, not the OpenSSL installation")
+	flag.BoolVar(&sharedObjs, "shared-objects", false, "Also validate shared libraries")
+	flag.IntVar(&jobs, "jobs", 1, "Number of binaries to validate concurrently")
+	flag.IntVar(&targetJobs, "target-jobs", 1, "Number of images to validate concurrently with image --all or --from-file")
+	flag.IntVar(&maxFailures, "max-failures", 0, "Stop after this many failed binaries")
+	flag.Var(&onlyArch, "only-arch", "Only validate binaries of this platform, e.g. linux/amd64")
+	flag.Var(&excludeArch, "exclude-arch", "Skip binaries of this platform, e.g. linux/arm64")
+	flag.BoolVar(&noPull, "no-pull", false, "Fail instead of pulling images that aren't present locally")
+	flag.IntVar(&archiveDepth, "max-archive-depth", 0, "Maximum depth of nested archives to validate")
+	flag.BoolVar(&languagePkgs, "language-packages", false, "Also validate the shared libraries in nested Python wheels and eggs and Java archives")
+	flag.Var(&maxExtractSize, "max-extract-size", "Maximum number of bytes to extract")
+	flag.Var(&maxFileSize, "max-file-size", "Skip executables larger than this when scanning a target")
+	flag.BoolVar(&oneFileSystem, "one-file-system", false, "Don't descend into directories on other file systems when scanning a target")
+	flag.StringVar(&sinceGit, "since-git", "", "In dir mode, only validate the executables changed since a git ref")
+	flag.BoolVar(&silentOnSuccess, "silent-on-success", false, "Don't print anything if validation succeeds")
+	flag.BoolVar(&summaryOnly, "summary-only", false, "Only print the summary and the verdict, not the result of each binary")
+	flag.StringVar(&onComplete, "on-complete", "", "Run a shell command with the JSON report on stdin after validation")
+	flag.StringVar(&cpuProfile, "cpuprofile", "", "Write a CPU profile to a file")
+	flag.StringVar(&memProfile, "memprofile", "", "Write a memory profile to a file")
 	flag.BoolVar(&help, "help", false, "Show help")
 	flag.Parse()
 
 	if help {
 		usage(nil)
 	}
+	if err := loadConfig(flag.CommandLine, configFile); err != nil {
+		usage(err)
+	}
 
 	color.NoColor = noColor
+	if archiveDepth < 0 {
+		usage(fmt.Errorf("--max-archive-depth must not be negative"))
+	}
+	if languagePkgs && archiveDepth == 0 {
+		usage(fmt.Errorf("--language-packages requires --max-archive-depth"))
+	}
+	if jobs < 1 {
+		usage(fmt.Errorf("--jobs must be at least 1"))
+	}
+	if targetJobs < 1 {
+		usage(fmt.Errorf("--target-jobs must be at least 1"))
+	}
+	if maxFailures < 0 {
+		usage(fmt.Errorf("--max-failures must not be negative"))
+	}
+	if debugEnabled {
+		traceCommands()
+	}
+	var err error
+	if policy, err = policyFlag.load(); err != nil {
+		usage(err)
+	}
+	if inProcess {
+		policy.OpenSSL.InProcess = true
+	}
+	if strict {
+		policy.Strict = true
+	}
+	if failFast {
+		policy.FailFast = true
+	}
+	if rulesFile != "" {
+		if policy.Rules, err = validation.LoadRules(rulesFile); err != nil {
+			usage(err)
+		}
+	}
+	if compareRules != "" {
+		if policy.CompareRules, err = validation.LoadRules(compareRules); err != nil {
+			usage(err)
+		}
+		if policy.FailFast {
+			usage(fmt.Errorf("--compare-rules and --fail-fast are mutually exclusive"))
+		}
+	}
+	if strictProviders {
+		policy.OpenSSL.DefaultProvider = validation.EnforcementFail
+	}
+	if requireFipsBoot {
+		policy.Boot.FipsMode = validation.EnforcementFail
+	}
+	if providerVersion != "" {
+		if _, err := semver.NewConstraint(providerVersion); err != nil {
+			usage(fmt.Errorf("--require-fips-provider-version: invalid constraint %q: %v", providerVersion, err))
+		}
+		policy.OpenSSL.FipsProviderVersion = providerVersion
+	}
+	if symbolSource != "" {
+		if policy.SymbolSource, err = validation.ParseSymbolSource(symbolSource); err != nil {
+			usage(fmt.Errorf("--symbol-source: %v", err))
+		}
+	}
+	if !slices.Contains(outputFormats, outputFormat) {
+		usage(fmt.Errorf("unknown output format %q", outputFormat))
+	}
+	if groupBy != "" {
+		if groupBy != "check" {
+			usage(fmt.Errorf("--group-by: unknown grouping %q", groupBy))
+		}
+		if outputFormat != "text" && outputFormat != "json" {
+			usage(fmt.Errorf("--group-by requires --output text or json"))
+		}
+	}
+	if formatVersion != 0 {
+		if err := report.ValidFormatVersion(formatVersion); err != nil {
+			usage(fmt.Errorf("--format-version: %v", err))
+		}
+		if outputFormat != "json" && outputFormat != "manifest" && onComplete == "" {
+			usage(fmt.Errorf("--format-version requires --output json or manifest, or --on-complete"))
+		}
+		if groupBy != "" {
+			usage(fmt.Errorf("--format-version and --group-by are mutually exclusive"))
+		}
+	} else {
+		formatVersion = report.FormatVersion
+	}
+	if outputFile != "" && outputFormat == "text" {
+		usage(fmt.Errorf("--output-file requires --output json, manifest, or attestation"))
+	}
+	if interactive && groupBy != "" {
+		usage(fmt.Errorf("--interactive and --group-by are mutually exclusive"))
+	}
+	if summaryOnly {
+		if outputFormat != "text" {
+			usage(fmt.Errorf("--summary-only requires --output text"))
+		}
+		if groupBy != "" || interactive {
+			usage(fmt.Errorf("--summary-only, --group-by, and --interactive are mutually exclusive"))
+		}
+	}
+	if interactive && !interactiveTerminal() {
+		debug("not printing text to a terminal, ignoring --interactive")
+		interactive = false
+	}
+	// With --summary-only, the output is discarded until the summary.
+	if outputFormat != "text" || summaryOnly {
+		out = io.Discard
+	}
+	if !color.NoColor && term.IsTerminal(int(os.Stdout.Fd())) {
+		if width, _, err := term.GetSize(int(os.Stdout.Fd())); err == nil {
+			tableWidth = width
+		}
+	}
+	// Hold back all output until the verdict is known, so that nothing is
+	// printed if validation succeeds.
+	var heldOutput *bytes.Buffer
+	if silentOnSuccess && out != io.Discard {
+		heldOutput = &bytes.Buffer{}
+		out = heldOutput
+	}
 
 	args := flag.Args()
-	if len(args) != 2 {
+	if len(args) >= 1 && args[0] == "explain" {
+		if len(args) > 2 {
+			usage(fmt.Errorf("incorrect number of arguments"))
+		}
+		if err := explain(args[1:]); err != nil {
+			usage(err)
+		}
+		os.Exit(0)
+	}
+	if len(args) >= 1 && args[0] == "capabilities" {
+		if err := printCapabilities(args[1:]); err != nil {
+			usage(err)
+		}
+		os.Exit(0)
+	}
+	if len(args) >= 1 && args[0] == "selftest" {
+		if outputFormat != "text" {
+			usage(fmt.Errorf("selftest only supports text output"))
+		}
+		rc, err := runSelftest(args[1:])
+		if err != nil {
+			usage(err)
+		}
+		os.Exit(rc)
+	}
+	var diffOld []string
+	if len(args) > 0 && args[0] == "diff" {
+		if args, diffOld, err = parseDiffArgs(args[1:]); err != nil {
+			usage(err)
+		}
+		if outputFormat != "text" && outputFormat != "json" {
+			usage(fmt.Errorf("diff only supports text and json output"))
+		}
+		if groupBy != "" || onComplete != "" || interactive || summaryOnly {
+			usage(fmt.Errorf("diff doesn't support --group-by, --on-complete, --interactive, or --summary-only"))
+		}
+	}
+	if len(args) > 0 && args[0] == "verify" {
+		if manifest, args, err = parseVerifyFlags(args[1:]); err != nil {
+			usage(err)
+		}
+		if outputFormat != "text" {
+			usage(fmt.Errorf("verify only supports text output"))
+		}
+		if summaryOnly {
+			usage(fmt.Errorf("verify doesn't support --summary-only"))
+		}
+	}
+	wantArgs := 2
+	if len(args) > 0 && args[0] == "ostree" {
+		wantArgs = 3
+	}
+	var batch *imageBatch
+	var binaryList string
+	if len(args) > 1 && args[0] == "image" && strings.HasPrefix(args[1], "-") {
+		if batch, err = parseImageFlags(args[1:]); err != nil {
+			usage(err)
+		}
+		if batch.checkpoint != "" && outputFormat == "attestation" {
+			usage(fmt.Errorf("image: --checkpoint doesn't support --output attestation"))
+		}
+	} else if len(args) > 1 && args[0] == "binary" && strings.HasPrefix(args[1], "-") {
+		if binaryList, err = parseBinaryFlags(args[1:]); err != nil {
+			usage(err)
+		}
+	} else if len(args) != wantArgs {
 		usage(fmt.Errorf("incorrect number of arguments"))
 	}
 	mode := args[0]
 	target := args[1]
 
-	valid := true
-	var err error
-	switch mode {
-	case "binary":
-		valid, err = validateBinary(target)
-	case "rpm":
-		valid, err = validateRpmPackage(target)
-	case "image":
-		valid, err = validateOciImage(target)
+	if mode == "auto" {
+		if mode, err = detectMode(target); err != nil {
+			usage(err)
+		}
+		info("Detected target type %q\n", mode)
+	}
+
+	if opensslOnly && noOpenSSL {
+		usage(fmt.Errorf("--openssl-only and --no-openssl are mutually exclusive"))
+	}
+	if opensslOnly && (mode == "binary" || mode == "rpm") {
+		usage(fmt.Errorf("--openssl-only is not supported in %s mode", mode))
+	}
+	if sinceGit != "" && mode != "dir" {
+		usage(fmt.Errorf("--since-git is only supported in dir mode"))
+	}
+	if strings.HasPrefix(sinceGit, "-") {
+		usage(fmt.Errorf("--since-git: invalid git ref %q", sinceGit))
+	}
+
+	if err := startProfiling(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v", err)
+		exit(1)
+	}
+
+	if err := checkTools(mode); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v", err)
+		exit(1)
+	}
+
+	tools := probeTools(usedTools(mode))
+
+	start := time.Now()
+	var oldTarget *report.Target
+	if diffOld != nil {
+		if oldTarget, err = validateTarget(mode, diffOld); err != nil {
+			releaseOutput(heldOutput)
+			fmt.Fprintf(os.Stderr, "Error: %v", err.Error())
+			exit(1)
+		}
+		fmt.Fprintln(out)
+	}
+	var targets []*report.Target
+	switch {
+	case mode == "binary" && binaryList != "":
+		targets, err = single(validateBinaryList(binaryList))
+	case mode == "image" && batch != nil:
+		targets, err = validateAllImages(batch)
 	default:
-		usage(fmt.Errorf("unknown mode %q", mode))
+		targets, err = single(validateTarget(mode, args[1:]))
+	}
+	stats := validation.ReadIOStats()
+	debug("validation took %s, opened %d files and read %d bytes (%.1f MiB) from them",
+		time.Since(start).Round(time.Millisecond), stats.FilesOpened, stats.BytesRead, float64(stats.BytesRead)/(1<<20))
+	if times := validation.ReadCheckTimes(); debugEnabled && len(times) > 0 {
+		debug("time spent per check, for all binaries:")
+		for _, t := range times {
+			debug("  %-28s %10s in %d binaries", t.Check, t.Total.Round(time.Microsecond), t.Calls)
+		}
 	}
 
 	if err != nil {
+		releaseOutput(heldOutput)
 		fmt.Fprintf(os.Stderr, "Error: %v", err.Error())
-		os.Exit(1)
+		exit(1)
+	}
+	if requireCov {
+		for _, t := range targets {
+			checkCoverage(t)
+		}
+	}
+	result := report.New(targets...)
+	result.Tools = tools
+	result.Commands = executedCommands
+	if oldTarget != nil {
+		d := report.ComparePosture(oldTarget, targets[0])
+		if d.Regressions == 0 && silentOnSuccess {
+			exit(0)
+		}
+		releaseOutput(heldOutput)
+		exit(printPostureDiff(d))
+	}
+	runCompletionHook(result)
+	if outputFile != "" {
+		err := writeFileAtomic(outputFile, func(w io.Writer) error { return writeReport(w, result) })
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: --output-file: %v\n", err)
+			exit(1)
+		}
+	}
+	if manifest != nil {
+		drift := report.Compare(manifest, result)
+		if len(drift) == 0 && silentOnSuccess {
+			exit(0)
+		}
+		releaseOutput(heldOutput)
+		exit(printDrift(drift))
+	}
+	valid := result.Valid
+	if valid && silentOnSuccess {
+		exit(0)
+	}
+	releaseOutput(heldOutput)
+	switch {
+	case summaryOnly:
+		out = color.Output
+		report.PrintSummary(out, result)
+		fmt.Fprintln(out)
+	case groupBy == "check" && outputFormat == "text":
+		report.PrintGroupedByCheck(out, result)
+	case interactive:
+		if err := browseFailures(result); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		}
+		fmt.Fprintf(out, "\nBinaries: %d failed, %d passed, %d skipped\n", result.Summary.Failed, result.Summary.Passed, result.Summary.Skipped)
+	case batch != nil && batch.checkpoint != "" && outputFormat == "text":
+		// The images skipped according to the checkpoint aren't listed,
+		// so summarize all of them. The remediations of a failed batch
+		// follow after a blank line anyway.
+		fmt.Fprintln(out)
+		report.PrintSummary(out, result)
+		if valid {
+			fmt.Fprintln(out)
+		}
+	case outputFile != "":
+		// Written along with the completion hook.
+	case outputFormat != "text":
+		if err := writeReport(os.Stdout, result); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: failed to write report: %v", err)
+			exit(1)
+		}
+	}
+	if policy.CompareRules != nil {
+		printRulesComparison(result, policy)
 	}
 	if !valid {
+		// A single binary's findings already come with hints.
+		if (mode != "binary" || binaryList != "") && !summaryOnly {
+			report.PrintPrivilegedFailures(out, result)
+			report.PrintRemediations(out, result.Remediations)
+		}
 		failure("Validation failed\n")
-		os.Exit(1)
+		exit(1)
 	}
 	success("Validation successful\n")
-	os.Exit(0)
+	exit(0)
+}
+
+// releaseOutput prints the output held back for --silent-on-success, if any,
+// and prints all further output directly.
+func releaseOutput(held *bytes.Buffer) {
+	if held == nil {
+		return
+	}
+	out = color.Output
+	_, _ = held.WriteTo(out)
+}
+
+// explain prints the documentation of the check with the ID given in args or,
+// if args is empty, lists all checks.
+func explain(args []string) error {
+	if len(args) == 0 {
+		for _, ci := range validation.Checks() {
+			fmt.Printf("%-24s %s\n", ci.ID, ci.Title)
+		}
+		return nil
+	}
+
+	ci, ok := validation.LookupCheck(args[0])
+	if !ok {
+		return fmt.Errorf("unknown check %q, run \"%s explain\" to list all checks", args[0], filepath.Base(os.Args[0]))
+	}
+	color.New(color.Bold).Printf("%s: %s\n\n", ci.ID, ci.Title)
+	fmt.Printf("%s %s\n\n", ci.Description, ci.Rationale)
+	fmt.Printf("If this check fails: %s\n\n", ci.Failure)
+	fmt.Printf("How to fix: %s.\n", ci.Remediation)
+	return nil
 }
 
-func validateBinary(binaryPath string) (bool, error) {
+func validateBinary(binaryPath string) (*report.Target, error) {
+	binaryPath, err := resolveBinary(binaryPath)
+	if err != nil {
+		return nil, err
+	}
 	path, err := filepath.Abs(binaryPath)
 	if err != nil {
-		return false, fmt.Errorf("failed to get absolute path: %v", err)
+		return nil, fmt.Errorf("failed to get absolute path: %v", err)
 	}
 	info("Validating binary %q:\n", path)
 
-	return validation.ValidateBinary(context.TODO(), "/", path, debug), nil
+	result, err := checkBinary(path)
+	if err != nil {
+		return nil, err
+	}
+	t := newTarget("binary", path, nil, []*validation.BinaryResult{result})
+	if wantSubjects() {
+		subject, err := fileSubject(path)
+		if err != nil {
+			return nil, err
+		}
+		t.Subjects = []report.Subject{subject}
+	}
+	return t, nil
 }
 
-func validateRpmPackage(packagePath string) (bool, error) {
+// checkBinary validates the binary at the absolute path and prints its
+// result.
+func checkBinary(path string) (*validation.BinaryResult, error) {
+	// Libraries are resolved in the root filesystem the binary belongs to.
+	rootPath, innerPath := "/", path
+	if rootDir != "" {
+		var err error
+		if rootPath, err = filepath.Abs(rootDir); err != nil {
+			return nil, fmt.Errorf("failed to get absolute path: %v", err)
+		}
+		rel, err := filepath.Rel(rootPath, path)
+		if err != nil || !filepath.IsLocal(rel) {
+			return nil, fmt.Errorf("%s is not inside the root filesystem %s", path, rootPath)
+		}
+		innerPath = "/" + rel
+		debug("Validating %s within root filesystem %s", innerPath, rootPath)
+	}
+
+	validate := validation.ValidateBinary
+	if sharedObjs {
+		validate = validation.ValidateSharedObject
+	}
+	result := validate(context.TODO(), rootPath, innerPath, policy, debug)
+	if hashFiles() {
+		subject, err := fileSubject(path)
+		if err != nil {
+			return nil, err
+		}
+		result.SHA256 = subject.Digest["sha256"]
+	}
+	printBinaryResult(out, result)
+	return result, nil
+}
+
+// validateTarget validates the target given by args in mode: a path or
+// reference, or for ostree commits, the repository and the ref or commit.
+func validateTarget(mode string, args []string) (*report.Target, error) {
+	target := args[0]
+	switch mode {
+	case "binary":
+		return validateBinary(target)
+	case "rpm":
+		return validateRpmPackage(target)
+	case "image":
+		return validateOciImage(out, target)
+	case "dir":
+		return validateDirTree(target)
+	case "ostree":
+		return validateOstreeCommit(target, args[1])
+	case "tar", "zip":
+		return validateArchive(target, mode)
+	case "squashfs":
+		return validateSquashfs(target)
+	}
+	usage(fmt.Errorf("unknown mode %q", mode))
+	return nil, nil
+}
+
+// single turns the result of validating a single target into a list of
+// targets.
+func single(t *report.Target, err error) ([]*report.Target, error) {
+	if err != nil {
+		return nil, err
+	}
+	return []*report.Target{t}, nil
+}
+
+// resolveBinary returns the path of the binary given to binary mode. Names
+// without a path separator that don't refer to a file in the current directory
+// are looked up like "go install" installs binaries, in $GOBIN and
+// $GOPATH/bin, and then in $PATH.
+func resolveBinary(name string) (string, error) {
+	if strings.ContainsRune(name, filepath.Separator) {
+		return name, nil
+	}
+	if _, err := os.Stat(name); err == nil {
+		return name, nil
+	}
+
+	var dirs []string
+	if gobin := os.Getenv("GOBIN"); gobin != "" {
+		dirs = append(dirs, gobin)
+	}
+	gopath := os.Getenv("GOPATH")
+	if gopath == "" {
+		if home, err := os.UserHomeDir(); err == nil {
+			gopath = filepath.Join(home, "go")
+		}
+	}
+	for _, dir := range filepath.SplitList(gopath) {
+		dirs = append(dirs, filepath.Join(dir, "bin"))
+	}
+	for _, dir := range dirs {
+		p := filepath.Join(dir, name)
+		if fi, err := os.Stat(p); err == nil && fi.Mode().IsRegular() {
+			debug("Resolved %s to %s", name, p)
+			return p, nil
+		}
+	}
+	if p, err := exec.LookPath(name); err == nil {
+		debug("Resolved %s to %s", name, p)
+		return p, nil
+	}
+	return "", fmt.Errorf("binary %q not found in the current directory, $GOBIN, $GOPATH/bin, or $PATH", name)
+}
+
+// detectMode returns the mode target is validated in by the auto mode: files
+// are validated by type, directories as root filesystems, and anything that
+// doesn't exist locally is assumed to be an image reference.
+func detectMode(target string) (string, error) {
+	fi, err := os.Stat(target)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		return "image", nil
+	case err != nil:
+		return "", err
+	case fi.IsDir():
+		return "dir", nil
+	case strings.HasSuffix(target, ".rpm"):
+		return "rpm", nil
+	case archive.IsZip(target):
+		return "zip", nil
+	case archive.IsArchive(target):
+		return "tar", nil
+	case isSquashfs(target):
+		return "squashfs", nil
+	}
+	if format, _ := elfinfo.DetectFormat(target); format == elfinfo.FormatELF {
+		return "binary", nil
+	}
+	return "", fmt.Errorf("can't determine the type of %s, specify the mode explicitly", target)
+}
+
+// validateArchive extracts a .tar, .tar.gz, or .zip archive and validates the
+// executables it contains, like an RPM package. In zip mode, the archive is
+// read as a zip archive whatever its name. If a zip archive doesn't record the
+// files' permissions, all ELF files are validated, as none is executable.
+func validateArchive(archivePath, mode string) (*report.Target, error) {
+	path, err := filepath.Abs(archivePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get absolute path: %v", err)
+	}
+	isZip := mode == "zip" || archive.IsZip(path)
+	if !isZip && !archive.IsArchive(path) {
+		return nil, fmt.Errorf("%s is not a .tar, .tar.gz, .tgz, or .zip archive", path)
+	}
+	info("Validating archive %q:\n", path)
+	opts := scanOptions()
+
+	tempDir, err := os.MkdirTemp("", "fips-validator-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+	debug("Using temporary directory %s\n", tempDir)
+
+	fmt.Fprintf(out, "• extracting archive... ")
+	hasModes := true
+	if isZip {
+		hasModes, err = archive.ExtractZip(path, tempDir, opts.ExtractLimit)
+	} else {
+		err = archive.Extract(path, tempDir, opts.ExtractLimit)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to extract archive: %v", err)
+	}
+	success("done\n")
+	if !hasModes {
+		info("• the archive doesn't record file permissions, validating all ELF files\n")
+		opts.AllELFFiles = true
+	}
+
+	// Archives usually contain applications rather than whole root
+	// filesystems, so their OpenSSL installation is only validated on
+	// request.
+	var openssl *validation.OpenSSLResult
+	if opensslOnly {
+		if openssl, err = validateOpenSSL(out, tempDir); err != nil {
+			return nil, err
+		}
+	}
+	results, stoppedEarly, err := scanDirTreeWith(out, tempDir, opts)
+	if err != nil {
+		return nil, err
+	}
+	t := newTarget(mode, path, openssl, results)
+	t.StoppedEarly = stoppedEarly
+	t.AllELFFiles = !hasModes
+	if wantSubjects() {
+		subject, err := fileSubject(path)
+		if err != nil {
+			return nil, err
+		}
+		t.Subjects = []report.Subject{subject}
+	}
+	return t, nil
+}
+
+func validateRpmPackage(packagePath string) (*report.Target, error) {
 	path, err := filepath.Abs(packagePath)
 	if err != nil {
-		return false, fmt.Errorf("failed to get absolute path: %v", err)
+		return nil, fmt.Errorf("failed to get absolute path: %v", err)
 	}
 	info("Validating RPM package %q:\n", path)
 
 	tempDir, err := os.MkdirTemp("", "fips-validator-")
 	if err != nil {
-		return false, fmt.Errorf("failed to create temporary directory: %v", err)
+		return nil, fmt.Errorf("failed to create temporary directory: %v", err)
 	}
 	defer os.RemoveAll(tempDir)
 	debug("Using temporary directory %s\n", tempDir)
 
-	if err := unpackRPM(packagePath, tempDir); err != nil {
-		return false, fmt.Errorf("failed to unpack RPM package: %v", err)
+	opts := scanOptions()
+	if err := unpackRPM(context.TODO(), path, tempDir, opts.ExtractLimit); err != nil {
+		return nil, fmt.Errorf("failed to unpack RPM package: %v", err)
+	}
+	if err := verifyUnpackedRPM(path, tempDir); err != nil {
+		return nil, err
+	}
+	results, stoppedEarly, err := scanDirTreeWith(out, tempDir, opts)
+	if err != nil {
+		return nil, err
+	}
+	t := newTarget("rpm", path, nil, results)
+	t.StoppedEarly = stoppedEarly
+	if wantSubjects() {
+		subject, err := fileSubject(path)
+		if err != nil {
+			return nil, err
+		}
+		t.Subjects = []report.Subject{subject}
+	}
+	return t, nil
+}
+
+// scanOptions returns the options to scan a target with. Each call returns a
+// fresh --max-extract-size limit, so that it applies to each target on its
+// own rather than to all targets of a run.
+func scanOptions() scanner.Options {
+	return scanner.Options{
+		MaxArchiveDepth:  archiveDepth,
+		ExtractLimit:     archive.NewLimit(int64(maxExtractSize)),
+		Policy:           policy,
+		SharedObjects:    sharedObjs,
+		Jobs:             jobs,
+		MaxFailures:      maxFailures,
+		Arch:             scanner.ArchFilter{Only: onlyArch, Exclude: excludeArch},
+		MaxFileSize:      int64(maxFileSize),
+		Hash:             hashFiles(),
+		OneFileSystem:    oneFileSystem,
+		LanguagePackages: languagePkgs,
+	}
+}
+
+// scanDirTree validates all executables in the directory tree at rootPath and
+// reports whether the scan stopped early because --max-failures was reached.
+// Nothing is validated if --openssl-only is set.
+func scanDirTree(w io.Writer, rootPath string) ([]*validation.BinaryResult, bool, error) {
+	return scanDirTreeWith(w, rootPath, scanOptions())
+}
+
+// scanDirTreeWith is like scanDirTree, but scans with the given options.
+func scanDirTreeWith(w io.Writer, rootPath string, opts scanner.Options) ([]*validation.BinaryResult, bool, error) {
+	if opensslOnly {
+		return nil, false, nil
+	}
+	var results []*validation.BinaryResult
+	resultc, errc := scanner.StreamDirTree(context.TODO(), rootPath, opts, debug)
+	for result := range resultc {
+		printBinaryResult(w, result)
+		results = append(results, result)
+	}
+	err := <-errc
+	if n := report.NewSummary(results).SkippedByReason[string(validation.SkipTooLarge)]; n > 0 {
+		finfo(w, "• skipped %d executables larger than --max-file-size, which were not validated\n", n)
 	}
-	return scanner.ScanDirTree(context.TODO(), tempDir, debug), nil
+	if errors.Is(err, scanner.ErrMaxFailuresReached) {
+		finfo(w, "• stopped after %d failed binaries, remaining binaries were not validated\n", maxFailures)
+		return results, true, nil
+	}
+	return results, false, err
 }
 
-func unpackRPM(packagePath, destDir string) error {
-	fmt.Printf("• unpacking RPM... ")
-	_, stderr, rc, err := executor.Execute(context.TODO(), destDir, "sh", "-c", fmt.Sprintf("rpm2cpio %s | cpio -idmv", packagePath))
+func printBinaryResult(w io.Writer, result *validation.BinaryResult) {
+	if noHints {
+		for i := range result.Findings {
+			result.Findings[i].Hint = ""
+		}
+	}
+	// Grouped and interactively browsed results are shown once all
+	// binaries are validated.
+	if groupBy == "check" || interactive {
+		return
+	}
+	if tableWidth > 0 {
+		report.PrintBinaryResultRow(w, result, tableWidth)
+	} else {
+		report.PrintBinaryResult(w, result)
+	}
+}
+
+// newTarget returns the report for a target, which is valid if the OpenSSL
+// validation (if any) and all binaries passed.
+func newTarget(mode, name string, openssl *validation.OpenSSLResult, results []*validation.BinaryResult) *report.Target {
+	t := &report.Target{
+		Mode:     mode,
+		Name:     name,
+		Valid:    true,
+		OpenSSL:  openssl,
+		Summary:  report.NewSummary(results),
+		Binaries: results,
+	}
+	if openssl != nil {
+		t.Valid = openssl.Valid
+		t.OpenSSLValid = &openssl.Valid
+		n := len(openssl.Libraries)
+		t.Summary.Libcrypto = &n
+	}
+	for _, r := range results {
+		if r.Status == validation.StatusFailed {
+			t.Valid = false
+		}
+	}
+	return t
+}
+
+// unpackRPM extracts the payload of an RPM package into destDir. The cpio
+// stream produced by rpm2cpio is piped into cpio through limit, so that
+// extraction is aborted once the payload grows beyond the size limit.
+func unpackRPM(ctx context.Context, packagePath, destDir string, limit *archive.Limit) error {
+	fmt.Fprintf(out, "• unpacking RPM... ")
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	pr, pw := io.Pipe()
+	rpm2cpioErr := make(chan error, 1)
+	go func() {
+		stderr, rc, err := executor.ExecuteWithIO(ctx, "", nil, limit.Writer(pw), "rpm2cpio", packagePath)
+		if err != nil {
+			err = commandError("failed to run rpm2cpio", err)
+		} else if rc != 0 {
+			err = fmt.Errorf("rpm2cpio failed, exit code %d: %s", rc, string(stderr))
+		}
+		pw.CloseWithError(err)
+		rpm2cpioErr <- err
+	}()
+
+	stderr, rc, err := executor.ExecuteWithIO(ctx, destDir, pr, nil, "cpio", "-idmv")
+	// Unblock rpm2cpio in case cpio exited before consuming all its output.
+	pr.CloseWithError(io.ErrClosedPipe)
+	producerErr := <-rpm2cpioErr
+
+	if err := limit.Err(); err != nil {
+		return err
+	}
 	if err != nil {
-		return errors.New(string(stderr))
+		return commandError("failed to run cpio", err)
+	}
+	if producerErr != nil {
+		return producerErr
 	}
 	if rc != 0 {
 		return fmt.Errorf("failed to unpack RPM, exit code %d: %s", rc, string(stderr))
@@ -143,73 +1085,285 @@ func unpackRPM(packagePath, destDir string) error {
 	return nil
 }
 
-func validateOciImage(imageRef string) (bool, error) {
-	info("Validating OCI image %q:\n", imageRef)
+// verifyUnpackedRPM checks that all executables listed in the header of the
+// RPM package at packagePath have been unpacked to destDir, so that a
+// truncated or corrupt payload can't make the scan silently miss binaries.
+func verifyUnpackedRPM(packagePath, destDir string) error {
+	fmt.Fprintf(out, "• verifying unpacked files... ")
+	files, err := rpm.ReadFiles(packagePath)
+	if err != nil {
+		return fmt.Errorf("failed to read RPM header: %v", err)
+	}
+
+	var missing []string
+	for _, f := range files {
+		if f.Ghost || !f.IsExecutable() {
+			continue
+		}
+		if fi, err := os.Lstat(filepath.Join(destDir, f.Path)); err != nil || !fi.Mode().IsRegular() {
+			missing = append(missing, f.Path)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("RPM payload is incomplete, executables listed in the header were not unpacked: %s", strings.Join(missing, ", "))
+	}
+	success("done\n")
+	return nil
+}
+
+// validateOciImage validates an OCI image like a directory tree, printing the
+// progress to w.
+func validateOciImage(w io.Writer, imageRef string) (*report.Target, error) {
+	finfo(w, "Validating OCI image %q:\n", imageRef)
 
-	tempDir, err := mountOciImage(imageRef)
+	tempDir, err := mountOciImage(w, imageRef)
 	if err != nil {
-		return false, err
+		return nil, err
 	}
-	defer unmountOciImage(imageRef)
+	defer unmountOciImage(w, imageRef)
 	debug("Using temporary directory: %s", tempDir)
 
-	allValid := true
-	if !validation.ValidateOpenSSL(context.TODO(), tempDir) {
-		allValid = false
+	openssl, err := validateOpenSSL(w, tempDir)
+	if err != nil {
+		return nil, err
+	}
+	results, stoppedEarly, err := scanDirTree(w, tempDir)
+	if err != nil {
+		return nil, err
+	}
+	t := newTarget("image", imageRef, openssl, results)
+	t.StoppedEarly = stoppedEarly
+	if wantSubjects() {
+		subject, err := imageSubject(imageRef)
+		if err != nil {
+			return nil, err
+		}
+		t.Subjects = []report.Subject{subject}
+	}
+	return t, nil
+}
+
+// validateDirTree validates a root filesystem that has already been unpacked
+// or mounted, e.g. a read-only mount of a device image. The directory is only
+// read, never written to.
+func validateDirTree(dirPath string) (*report.Target, error) {
+	path, err := filepath.Abs(dirPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get absolute path: %v", err)
+	}
+	if fi, err := os.Stat(path); err != nil {
+		return nil, err
+	} else if !fi.IsDir() {
+		return nil, fmt.Errorf("%s is not a directory", path)
+	}
+	opts := scanOptions()
+	if sinceGit != "" && !opensslOnly {
+		if opts.Files, err = changedFiles(path, sinceGit); err != nil {
+			return nil, err
+		}
+	}
+	info("Validating directory %q:\n", path)
+
+	openssl, err := validateOpenSSL(out, path)
+	if err != nil {
+		return nil, err
+	}
+	if opts.Files != nil {
+		info("• validating only the executables among %d files changed since %s\n", len(opts.Files), sinceGit)
+	}
+	results, stoppedEarly, err := scanDirTreeWith(out, path, opts)
+	if err != nil {
+		return nil, err
+	}
+	t := newTarget("dir", path, openssl, results)
+	t.StoppedEarly = stoppedEarly
+	t.SinceGit = sinceGit
+	if wantSubjects() {
+		if t.Subjects, err = binarySubjects(path, results); err != nil {
+			return nil, err
+		}
+	}
+	return t, nil
+}
+
+// validateOpenSSL validates the OpenSSL installation of the root filesystem at
+// rootPath and prints the result to w. It returns nil if --no-openssl is set.
+func validateOpenSSL(w io.Writer, rootPath string) (*validation.OpenSSLResult, error) {
+	if noOpenSSL {
+		return nil, nil
+	}
+	result, err := validation.ValidateOpenSSL(context.TODO(), rootPath, policy)
+	if err != nil {
+		return nil, err
+	}
+	if noHints {
+		for i := range result.Findings {
+			result.Findings[i].Hint = ""
+		}
+	}
+	report.PrintOpenSSLResult(w, result)
+	return result, nil
+}
+
+// checkCoverage fails a target if its validation didn't cover anything, i.e.
+// all binaries were skipped or, if libcrypto was looked for, none was found.
+func checkCoverage(t *report.Target) {
+	var errs []string
+	switch {
+	case opensslOnly:
+	case t.Summary.Binaries == 0:
+		errs = append(errs, "no executables found")
+	case t.Summary.Passed+t.Summary.Failed == 0:
+		errs = append(errs, fmt.Sprintf("no binaries using crypto found, all %d executables were skipped", t.Summary.Skipped))
+	}
+	if t.Summary.Libcrypto != nil && *t.Summary.Libcrypto == 0 {
+		errs = append(errs, "no libcrypto found")
+	}
+	if len(errs) == 0 {
+		return
+	}
+
+	fmt.Fprintf(out, "• checking coverage... ")
+	failure("failed\n")
+	for _, e := range errs {
+		fmt.Fprintf(out, "  %s %s\n", color.New(color.Bold, color.FgRed).Sprint("✘"), e)
+	}
+	t.Errors = append(t.Errors, errs...)
+	t.Valid = false
+}
+
+// validateOstreeCommit checks out the given ref or commit of the ostree
+// repository at repoPath into a temporary directory and validates it like a
+// directory tree.
+func validateOstreeCommit(repoPath, ref string) (*report.Target, error) {
+	repo, err := filepath.Abs(repoPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get absolute path: %v", err)
+	}
+	info("Validating ostree commit %q of repository %q:\n", ref, repo)
+
+	tempDir, err := os.MkdirTemp("", "fips-validator-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+	debug("Using temporary directory %s\n", tempDir)
+
+	// ostree refuses to check out into an existing directory.
+	rootPath := filepath.Join(tempDir, "rootfs")
+	if err := checkoutOstreeCommit(repo, ref, rootPath); err != nil {
+		return nil, err
+	}
+
+	openssl, err := validateOpenSSL(out, rootPath)
+	if err != nil {
+		return nil, err
 	}
-	if !scanner.ScanDirTree(context.TODO(), tempDir, debug) {
-		allValid = false
+	results, stoppedEarly, err := scanDirTree(out, rootPath)
+	if err != nil {
+		return nil, err
 	}
-	return allValid, nil
+	t := newTarget("ostree", repo+":"+ref, openssl, results)
+	t.StoppedEarly = stoppedEarly
+	if wantSubjects() {
+		subject, err := ostreeSubject(repo, ref)
+		if err != nil {
+			return nil, err
+		}
+		t.Subjects = []report.Subject{subject}
+	}
+	return t, nil
+}
+
+func checkoutOstreeCommit(repo, ref, destDir string) error {
+	fmt.Fprintf(out, "• checking out ostree commit... ")
+	_, stderr, rc, err := executor.Execute(context.TODO(), "", "ostree", "checkout", "--repo="+repo, "--user-mode", ref, destDir)
+	if err != nil {
+		return commandError("failed to check out ostree commit", err)
+	}
+	if rc != 0 {
+		return fmt.Errorf("failed to check out ostree commit, exit code %d: %s", rc, string(stderr))
+	}
+	success("done\n")
+	return nil
 }
 
-func mountOciImage(imageRef string) (string, error) {
-	fmt.Printf("• checking OCI image exists locally... ")
+// podmanMountMu serializes mounting and unmounting images, which modify the
+// mount state of podman's storage and aren't safe to run concurrently.
+var podmanMountMu sync.Mutex
+
+func mountOciImage(w io.Writer, imageRef string) (string, error) {
+	fmt.Fprintf(w, "• checking OCI image exists locally... ")
 	_, _, rc, err := executor.Execute(context.TODO(), "", "podman", "image", "exists", imageRef)
 	if err != nil {
-		return "", fmt.Errorf("failed to check whether image exists: %v", err)
+		return "", commandError("failed to check whether image exists", err)
 	}
 	if rc == 0 {
-		success("found\n")
+		fsuccess(w, "found\n")
 	} else {
-		info("not found\n")
+		finfo(w, "not found\n")
+		if noPull {
+			return "", fmt.Errorf("image %s not present locally and --no-pull set", imageRef)
+		}
 
-		fmt.Printf("• pulling image... ")
+		fmt.Fprintf(w, "• pulling image... ")
 		_, stderr, rc, err := executor.Execute(context.TODO(), "", "podman", "pull", imageRef)
 		if err != nil {
-			return "", fmt.Errorf("failed to pull image: %s", err)
+			return "", commandError("failed to pull image", err)
 		}
 		if rc != 0 {
 			return "", fmt.Errorf("failed to pull image, exit code %d: %s", rc, string(stderr))
 		}
-		success("done\n")
+		fsuccess(w, "done\n")
 	}
 
-	fmt.Printf("• mounting OCI image... ")
+	fmt.Fprintf(w, "• mounting OCI image... ")
 	cmdArgs := []string{"image", "mount", imageRef}
+	podmanMountMu.Lock()
 	stdout, stderr, rc, err := executor.Execute(context.TODO(), "", "podman", cmdArgs...)
+	podmanMountMu.Unlock()
 	if err != nil {
 		return "", fmt.Errorf("failed to mount image: %s", string(stderr))
 	}
 	if rc != 0 {
 		return "", fmt.Errorf("failed to mount image, exit code %d: %s", rc, string(stderr))
 	}
-	success("done\n")
+	mountPath, err := parseMountPath(stdout)
+	if err != nil {
+		// The image was mounted, so don't leave it mounted.
+		_ = unmountOciImage(io.Discard, imageRef)
+		return "", err
+	}
+	fsuccess(w, "done\n")
+	return mountPath, nil
+}
 
-	mountPath := string(stdout)
-	mountPath = mountPath[:len(mountPath)-1] // Remove trailing newline
+// parseMountPath returns the mount point printed by "podman image mount": the
+// last non-empty line of its output, as some podman versions print warnings
+// to stdout before it.
+func parseMountPath(stdout []byte) (string, error) {
+	lines := strings.Split(strings.TrimSpace(string(stdout)), "\n")
+	mountPath := strings.TrimSpace(lines[len(lines)-1])
+	if mountPath == "" {
+		return "", fmt.Errorf("failed to mount image: podman printed no mount point")
+	}
+	if !filepath.IsAbs(mountPath) {
+		return "", fmt.Errorf("failed to mount image: unexpected output of podman: %q", mountPath)
+	}
 	return mountPath, nil
 }
 
-func unmountOciImage(imageRef string) error {
-	fmt.Printf("• unmounting OCI image... ")
+func unmountOciImage(w io.Writer, imageRef string) error {
+	fmt.Fprintf(w, "• unmounting OCI image... ")
+	podmanMountMu.Lock()
 	_, stderr, rc, err := executor.Execute(context.TODO(), "", "podman", "image", "unmount", imageRef)
+	podmanMountMu.Unlock()
 	if err != nil {
-		return fmt.Errorf("failed to unmount image: %v", err)
+		return commandError("failed to unmount image", err)
 	}
 	if rc != 0 {
 		return fmt.Errorf("failed to unmount image, exit code %d: %s", rc, string(stderr))
 	}
-	success("done\n")
+	fsuccess(w, "done\n")
 	return nil
 }
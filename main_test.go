@@ -0,0 +1,234 @@
+package main
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"maps"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/flightctl/fips-validator/internal/executor"
+	"github.com/flightctl/fips-validator/internal/scanner"
+	"github.com/flightctl/fips-validator/internal/validation"
+)
+
+// writeTarGz writes a .tar.gz archive containing a single file of size bytes.
+func writeTarGz(t *testing.T, path string, size int) {
+	t.Helper()
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	zw := gzip.NewWriter(f)
+	tw := tar.NewWriter(zw)
+	data := strings.Repeat("x", size)
+	if err := tw.WriteHeader(&tar.Header{Name: "data.txt", Mode: 0o644, Size: int64(size), Typeflag: tar.TypeReg}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := io.WriteString(tw, data); err != nil {
+		t.Fatal(err)
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestMaxExtractSizeIsPerTarget(t *testing.T) {
+	oldDepth, oldMax := archiveDepth, maxExtractSize
+	t.Cleanup(func() { archiveDepth, maxExtractSize = oldDepth, oldMax })
+	archiveDepth = 1
+	maxExtractSize = 1000
+
+	// Each target is a directory holding an archive of the given size.
+	target := func(size int) string {
+		dir := t.TempDir()
+		writeTarGz(t, filepath.Join(dir, "data.tar.gz"), size)
+		return dir
+	}
+	scan := func(dir string) error {
+		_, err := scanner.ScanDirTree(context.Background(), dir, scanOptions(), debug, nil)
+		return err
+	}
+
+	// Together, the small archives exceed the limit, but each of them is
+	// under it.
+	for i, dir := range []string{target(600), target(600)} {
+		if err := scan(dir); err != nil {
+			t.Errorf("target %d: %v", i, err)
+		}
+	}
+	if err := scan(target(2000)); err == nil {
+		t.Error("scanning a target over the limit succeeded, want size limit error")
+	}
+	// A target exceeding the limit doesn't affect the ones after it.
+	if err := scan(target(600)); err != nil {
+		t.Errorf("target after exceeding the limit: %v", err)
+	}
+}
+
+// copyTree copies the directory tree at src to dst.
+func copyTree(t *testing.T, src, dst string) {
+	t.Helper()
+	err := filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(src, path)
+		target := filepath.Join(dst, rel)
+		if d.IsDir() {
+			return os.MkdirAll(target, 0o755)
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(target, data, 0o755)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// snapshotTree returns the path, mode, size, and modification time of every
+// file in the directory tree at root, to detect whether it changed.
+func snapshotTree(t *testing.T, root string) map[string]string {
+	t.Helper()
+	snapshot := map[string]string{}
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		fi, err := d.Info()
+		if err != nil {
+			return err
+		}
+		snapshot[path] = fmt.Sprintf("%v %d %v", fi.Mode(), fi.Size(), fi.ModTime())
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return snapshot
+}
+
+// setReadOnly removes the write permissions from the directory tree at root
+// and restores them when the test finishes, so that it can be removed.
+func setReadOnly(t *testing.T, root string) {
+	t.Helper()
+	chmodTree := func(dirMode, fileMode os.FileMode) error {
+		return filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.IsDir() {
+				return os.Chmod(path, dirMode)
+			}
+			return os.Chmod(path, fileMode)
+		})
+	}
+	t.Cleanup(func() { chmodTree(0o755, 0o755) })
+	if err := chmodTree(0o555, 0o555); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestValidateReadOnlyDirTree(t *testing.T) {
+	oldOut, oldPolicy, oldSinceGit, oldTrace := out, policy, sinceGit, executor.Trace
+	t.Cleanup(func() { out, policy, sinceGit, executor.Trace = oldOut, oldPolicy, oldSinceGit, oldTrace })
+	out = io.Discard
+	policy = validation.DefaultPolicy()
+
+	var mu sync.Mutex
+	var commands []executor.Command
+	executor.Trace = func(c executor.Command) {
+		mu.Lock()
+		defer mu.Unlock()
+		commands = append(commands, c)
+	}
+
+	root := filepath.Join(t.TempDir(), "rootfs")
+	copyTree(t, "internal/selftest/rootfs", root)
+	gitAvailable := false
+	if _, err := exec.LookPath("git"); err == nil {
+		// A repository without commits, so that all files are untracked
+		// and changed since the empty tree.
+		if err := exec.Command("git", "init", "-q", root).Run(); err != nil {
+			t.Fatal(err)
+		}
+		gitAvailable = true
+	}
+	setReadOnly(t, root)
+	if os.Geteuid() == 0 {
+		t.Log("running as root, which can write to the tree regardless of its permissions")
+	}
+	before := snapshotTree(t, root)
+
+	validate := func(name string) {
+		commands = nil
+		target, err := validateDirTree(root)
+		if err != nil {
+			t.Fatalf("%s: validateDirTree: %v", name, err)
+		}
+		if len(target.Binaries) == 0 {
+			t.Errorf("%s: no binaries validated", name)
+		}
+		for _, c := range commands {
+			if c.Dir != "" && (c.Dir == root || strings.HasPrefix(c.Dir, root+string(filepath.Separator))) {
+				t.Errorf("%s: %s run in the target %s", name, c, c.Dir)
+			}
+		}
+		if after := snapshotTree(t, root); !maps.Equal(before, after) {
+			t.Errorf("%s: validating the tree modified it", name)
+		}
+	}
+	validate("dir")
+	if gitAvailable {
+		sinceGit = "4b825dc642cb6eb9a060e54bf8d69288fbee4904" // the empty tree
+		validate("dir --since-git")
+	}
+}
+
+func TestParseMountPath(t *testing.T) {
+	tests := []struct {
+		name    string
+		stdout  string
+		want    string
+		wantErr bool
+	}{
+		{name: "empty", stdout: "", wantErr: true},
+		{name: "newline only", stdout: "\n", wantErr: true},
+		{name: "path", stdout: "/var/lib/containers/storage/overlay/abc/merged\n", want: "/var/lib/containers/storage/overlay/abc/merged"},
+		{name: "no trailing newline", stdout: "/mnt/image", want: "/mnt/image"},
+		{name: "warning before path", stdout: "WARN[0000] some warning\n/mnt/image\n", want: "/mnt/image"},
+		{name: "trailing blank line", stdout: "/mnt/image\n\n", want: "/mnt/image"},
+		{name: "surrounding whitespace", stdout: "  /mnt/image \r\n", want: "/mnt/image"},
+		{name: "relative path", stdout: "mnt/image\n", wantErr: true},
+		{name: "warning only", stdout: "WARN[0000] some warning\n", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseMountPath([]byte(tt.stdout))
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("parseMountPath(%q) = %q, want error", tt.stdout, got)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("parseMountPath(%q) = %q, %v, want %q", tt.stdout, got, err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/flightctl/fips-validator/internal/report"
+)
+
+// writeReport writes r to w in the machine-readable output format selected
+// with --output, grouped by check with --group-by.
+func writeReport(w io.Writer, r *report.Report) error {
+	switch {
+	case groupBy == "check":
+		return report.WriteGroupedJSON(w, r, jsonCompact)
+	case outputFormat == "attestation":
+		return report.WriteAttestation(w, r, jsonCompact)
+	}
+	return report.WriteJSONVersion(w, r, formatVersion, jsonCompact)
+}
+
+// writeFileAtomic creates or replaces the file at path with the output of
+// write. The output is written to a temporary file in the same directory,
+// which is renamed to path once it's complete, so that readers of path never
+// see a partial file. If write or any step fails, the temporary file is
+// removed and path is left as it was. A replaced file keeps its permissions.
+func writeFileAtomic(path string, write func(w io.Writer) error) error {
+	perm := fs.FileMode(0o644)
+	if fi, err := os.Stat(path); err == nil {
+		if !fi.Mode().IsRegular() {
+			return fmt.Errorf("%s is not a regular file", path)
+		}
+		perm = fi.Mode().Perm()
+	}
+	f, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %v", path, err)
+	}
+	tmp := f.Name()
+	err = write(f)
+	if err == nil {
+		err = f.Chmod(perm)
+	}
+	if err == nil {
+		err = f.Sync()
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(tmp, path)
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to write %s: %v", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,67 @@
+package elfinfo
+
+import (
+	"debug/elf"
+	"encoding/binary"
+	"io"
+)
+
+// Archs lists the architectures in Go's naming that ElfInfo.Arch reports.
+// Binaries of other architectures are reported by their ELF machine name.
+var Archs = []string{"amd64", "386", "arm64", "arm", "ppc64le", "ppc64", "s390x", "riscv64", "loong64", "mips64le", "mips64", "mipsle", "mips"}
+
+// ReadArch reads just enough of the ELF file read from r to return its
+// architecture, see ElfInfo.Arch.
+func ReadArch(r io.ReaderAt) (string, error) {
+	f, err := elf.NewFile(r)
+	if err != nil {
+		return "", err
+	}
+	return getArch(f), nil
+}
+
+// getArch returns the Go name (GOARCH) of the architecture of an ELF file, or
+// the ELF machine name, e.g. "EM_SPARCV9", for architectures Go doesn't
+// support.
+func getArch(file *elf.File) string {
+	is64 := file.Class == elf.ELFCLASS64
+	le := file.ByteOrder == binary.LittleEndian
+	switch file.Machine {
+	case elf.EM_X86_64:
+		return "amd64"
+	case elf.EM_386:
+		return "386"
+	case elf.EM_AARCH64:
+		return "arm64"
+	case elf.EM_ARM:
+		return "arm"
+	case elf.EM_PPC64:
+		if le {
+			return "ppc64le"
+		}
+		return "ppc64"
+	case elf.EM_S390:
+		if is64 {
+			return "s390x"
+		}
+	case elf.EM_RISCV:
+		if is64 {
+			return "riscv64"
+		}
+	case elf.EM_LOONGARCH:
+		if is64 {
+			return "loong64"
+		}
+	case elf.EM_MIPS:
+		switch {
+		case is64 && le:
+			return "mips64le"
+		case is64:
+			return "mips64"
+		case le:
+			return "mipsle"
+		}
+		return "mips"
+	}
+	return file.Machine.String()
+}
@@ -1,52 +1,314 @@
 package elfinfo
 
 import (
+	"bytes"
 	"debug/elf"
+	"io"
+	"os"
+	"path"
+	"strings"
+)
+
+// Relro describes how much of a binary's relocation data the dynamic loader
+// makes read-only after relocating it.
+type Relro string
+
+const (
+	RelroNone    Relro = "none"
+	RelroPartial Relro = "partial"
+	RelroFull    Relro = "full"
 )
 
 type ElfInfo struct {
-	IsElf    bool
+	// IsElf is set for ELF executables and shared objects. Other ELF files,
+	// e.g. relocatable objects, are not read.
+	IsElf bool
+	// IsSharedObject is set for shared libraries, as opposed to executables.
+	IsSharedObject bool
+	// Soname is the DT_SONAME of a shared library, e.g. "libcrypto.so.3",
+	// which binaries name in their DT_NEEDED entries, or "" if it has none.
+	Soname   string
 	IsStatic bool
+	// Machine is the ELF machine type. Arch is the architecture in Go's
+	// naming (GOARCH), e.g. "amd64" or "ppc64le".
+	Machine  elf.Machine
+	Arch     string
 	Sections []string
-	Symbols  []elf.Symbol
+	// Symbols holds the full symbol table (.symtab), which is removed when
+	// a binary is stripped. DynamicSymbols holds the symbols used for
+	// dynamic linking (.dynsym), which are always present.
+	Symbols        []elf.Symbol
+	DynamicSymbols []elf.Symbol
+	// Needed lists the DT_NEEDED entries, i.e. the SONAMEs of the shared
+	// libraries the dynamic loader loads for this file.
+	Needed []string
+	// Rpath and Runpath list the library search paths from the DT_RPATH and
+	// DT_RUNPATH entries.
+	Rpath   []string
+	Runpath []string
+	Relro   Relro
+	// Entry is the entry point address. EntrySection is the name of the
+	// executable PROGBITS section containing it, or "" if there is none.
+	Entry        uint64
+	EntrySection string
+	// DebugLink is the file name of the separate debuginfo file recorded in
+	// the .gnu_debuglink section, or "" if there is none. DebugLinkCRC is
+	// the CRC-32 of that file's contents.
+	DebugLink    string
+	DebugLinkCRC uint32
+	// IsDebugInfo is set for separate debuginfo files, whose code sections
+	// have been stripped of their contents.
+	IsDebugInfo bool
+	// Interpreter is the path of the dynamic loader requested with
+	// PT_INTERP, e.g. "/lib64/ld-linux-x86-64.so.2", or "" if there is none.
+	Interpreter string
+	// Features lists the control-flow protection features advertised in
+	// the GNU property notes, e.g. IBT and SHSTK on x86_64 or BTI on aarch64.
+	Features []Feature
+	// GoBuildID is the build ID the Go toolchain recorded in the
+	// .note.go.buildid section, e.g. "<action ID>/<content ID>", or "" if
+	// there is none.
+	GoBuildID string
+	// Packer is the packer that compressed the binary, e.g. UPX, or
+	// PackerNone. The other fields of packed binaries describe the packer's
+	// decompression stub.
+	Packer Packer
+}
+
+// Libc names the C library flavor a binary was linked against.
+type Libc string
+
+const (
+	LibcGlibc Libc = "glibc"
+	LibcMusl  Libc = "musl"
+)
+
+// Libc returns the C library flavor of a dynamically-linked binary as
+// determined by its dynamic loader, or "" if it can't be determined.
+func (info *ElfInfo) Libc() Libc {
+	name := path.Base(info.Interpreter)
+	switch {
+	case info.Interpreter == "":
+		return ""
+	case strings.HasPrefix(name, "ld-musl-"):
+		return LibcMusl
+	case strings.HasPrefix(name, "ld-linux"), strings.HasPrefix(name, "ld64.so."), strings.HasPrefix(name, "ld.so."):
+		return LibcGlibc
+	}
+	return ""
 }
 
+// ReadFile reads the ELF info of the file at path.
 func ReadFile(path string) (*ElfInfo, error) {
-	exe, err := elf.Open(path)
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ReadFrom(f)
+}
+
+// ReadFrom reads the ELF info of the file read from r, e.g. a file that was
+// already opened.
+func ReadFrom(r io.ReaderAt) (*ElfInfo, error) {
+	exe, err := elf.NewFile(r)
 	if err != nil {
 		return nil, err
 	}
-	defer exe.Close()
 
 	info := &ElfInfo{}
 	switch exe.Type {
 	case elf.ET_EXEC:
-		info.IsElf = true
-		info.IsStatic = isStatic(exe)
-		info.Sections = getSectionNames(exe)
-		info.Symbols, _ = exe.Symbols()
+		readExecutableInfo(exe, info)
 	case elf.ET_DYN: // Either a binary or a shared object.
 		pie, err := isPie(exe)
-		if err != nil || !pie {
+		if err != nil {
 			return info, err
 		}
-		info.IsElf = true
-		info.IsStatic = isStatic(exe)
-		info.Sections = getSectionNames(exe)
-		info.Symbols, _ = exe.Symbols()
+		readExecutableInfo(exe, info)
+		if !pie {
+			info.IsSharedObject = true
+			info.Soname = getSoname(exe)
+			// Shared objects never have a PT_INTERP program.
+			info.IsStatic = !hasProg(exe, elf.PT_DYNAMIC)
+		}
+	}
+	if info.IsElf {
+		info.Packer = detectPacker(r, exe)
 	}
 	return info, nil
 }
 
+func readExecutableInfo(exe *elf.File, info *ElfInfo) {
+	info.IsElf = true
+	info.IsStatic = isStatic(exe)
+	info.Machine = exe.Machine
+	info.Arch = getArch(exe)
+	info.Sections = getSectionNames(exe)
+	info.Symbols, _ = exe.Symbols()
+	info.DynamicSymbols, _ = exe.DynamicSymbols()
+	info.Needed, _ = exe.DynString(elf.DT_NEEDED)
+	info.Rpath = getSearchPaths(exe, elf.DT_RPATH)
+	info.Runpath = getSearchPaths(exe, elf.DT_RUNPATH)
+	info.Relro = getRelro(exe)
+	info.Entry = exe.Entry
+	info.EntrySection = getEntrySection(exe)
+	info.DebugLink, info.DebugLinkCRC = getDebugLink(exe)
+	info.IsDebugInfo = isDebugInfo(exe)
+	info.Interpreter = getInterpreter(exe)
+	info.Features = getFeatures(exe)
+	info.GoBuildID = getGoBuildID(exe)
+}
+
+// getSoname returns the DT_SONAME entry of the dynamic section.
+func getSoname(file *elf.File) string {
+	vals, err := file.DynString(elf.DT_SONAME)
+	if err != nil || len(vals) == 0 {
+		return ""
+	}
+	return vals[0]
+}
+
+// ntGoBuildID is the type of the note in which the Go linker records the build
+// ID.
+const ntGoBuildID = 4
+
+// getGoBuildID returns the build ID in the .note.go.buildid section: a single
+// note named "Go" whose descriptor is the build ID.
+func getGoBuildID(file *elf.File) string {
+	s := file.Section(".note.go.buildid")
+	if s == nil || s.Type != elf.SHT_NOTE {
+		return ""
+	}
+	data, err := s.Data()
+	if err != nil || len(data) < 12 {
+		return ""
+	}
+	bo := file.ByteOrder
+	namesz, descsz, ntype := int(bo.Uint32(data)), int(bo.Uint32(data[4:])), bo.Uint32(data[8:])
+	descOff := 12 + alignUp(namesz, 4)
+	if ntype != ntGoBuildID || namesz < 0 || descsz < 0 || descOff+descsz > len(data) {
+		return ""
+	}
+	if string(bytes.TrimRight(data[12:12+namesz], "\x00")) != "Go" {
+		return ""
+	}
+	return string(data[descOff : descOff+descsz])
+}
+
+// getInterpreter returns the path in the PT_INTERP program header.
+func getInterpreter(file *elf.File) string {
+	for _, p := range file.Progs {
+		if p.Type != elf.PT_INTERP {
+			continue
+		}
+		data, err := io.ReadAll(p.Open())
+		if err != nil {
+			return ""
+		}
+		return string(bytes.TrimRight(data, "\x00"))
+	}
+	return ""
+}
+
+// isDebugInfo returns whether an ELF file is a debuginfo file created with
+// "objcopy --only-keep-debug": it has executable sections, but none of them
+// has contents.
+func isDebugInfo(file *elf.File) bool {
+	found := false
+	for _, s := range file.Sections {
+		if s.Flags&elf.SHF_EXECINSTR == 0 {
+			continue
+		}
+		if s.Type != elf.SHT_NOBITS {
+			return false
+		}
+		found = true
+	}
+	return found
+}
+
+// AddDebugSymbols adds the symbol table of the separate debuginfo file read
+// from r to the symbols of info. Section indices of the added symbols are
+// mapped to the sections of info by name; symbols in sections that info
+// doesn't have are left out.
+func (info *ElfInfo) AddDebugSymbols(r io.ReaderAt) error {
+	debug, err := elf.NewFile(r)
+	if err != nil {
+		return err
+	}
+	syms, err := debug.Symbols()
+	if err != nil {
+		return err
+	}
+
+	sections := make(map[string]elf.SectionIndex, len(info.Sections))
+	for i, name := range info.Sections {
+		sections[name] = elf.SectionIndex(i)
+	}
+	for _, sym := range syms {
+		if sym.Section != elf.SHN_UNDEF && sym.Section < elf.SHN_LORESERVE {
+			if int(sym.Section) >= len(debug.Sections) {
+				continue
+			}
+			i, ok := sections[debug.Sections[sym.Section].Name]
+			if !ok {
+				continue
+			}
+			sym.Section = i
+		}
+		info.Symbols = append(info.Symbols, sym)
+	}
+	return nil
+}
+
+// getDebugLink returns the file name and CRC-32 recorded in the .gnu_debuglink
+// section: a NUL-terminated file name, padded to 4 bytes, followed by the CRC.
+func getDebugLink(file *elf.File) (string, uint32) {
+	s := file.Section(".gnu_debuglink")
+	if s == nil {
+		return "", 0
+	}
+	data, err := s.Data()
+	if err != nil {
+		return "", 0
+	}
+	name, _, ok := bytes.Cut(data, []byte{0})
+	crcOffset := (len(name) + 1 + 3) &^ 3
+	if !ok || len(name) == 0 || len(data) < crcOffset+4 {
+		return "", 0
+	}
+	return string(name), file.ByteOrder.Uint32(data[crcOffset:])
+}
+
+// getEntrySection returns the name of the executable section that contains
+// the entry point, or "" if it doesn't point into one.
+func getEntrySection(file *elf.File) string {
+	for _, s := range file.Sections {
+		if s.Type != elf.SHT_PROGBITS || s.Flags&elf.SHF_EXECINSTR == 0 {
+			continue
+		}
+		if file.Entry >= s.Addr && file.Entry < s.Addr+s.Size {
+			return s.Name
+		}
+	}
+	return ""
+}
+
 // isStatic returns whether an ELF executable is a statically-linked binary.
 func isStatic(exe *elf.File) bool {
-	for _, p := range exe.Progs {
-		// Static binaries do not have a PT_INTERP program.
-		if p.Type == elf.PT_INTERP {
-			return false
+	// Static binaries do not have a PT_INTERP program.
+	return !hasProg(exe, elf.PT_INTERP)
+}
+
+// hasProg returns whether an ELF file has a program header of the given type.
+func hasProg(file *elf.File, typ elf.ProgType) bool {
+	for _, p := range file.Progs {
+		if p.Type == typ {
+			return true
 		}
 	}
-	return true
+	return false
 }
 
 // isPie returns whether an ELF executable is a position-independent executable.
@@ -70,3 +332,49 @@ func getSectionNames(file *elf.File) []string {
 	}
 	return sectionNames
 }
+
+// getSearchPaths returns the colon-separated library search paths listed for
+// the given tag in the dynamic section.
+func getSearchPaths(file *elf.File, tag elf.DynTag) []string {
+	vals, err := file.DynString(tag)
+	if err != nil {
+		return nil
+	}
+	var paths []string
+	for _, v := range vals {
+		for _, p := range strings.Split(v, ":") {
+			if p != "" {
+				paths = append(paths, p)
+			}
+		}
+	}
+	return paths
+}
+
+// getRelro returns the RELRO level of an ELF executable. Full RELRO requires a
+// PT_GNU_RELRO segment and all symbols to be bound at startup (BIND_NOW);
+// otherwise, the GOT remains writable.
+func getRelro(file *elf.File) Relro {
+	if !hasProg(file, elf.PT_GNU_RELRO) {
+		return RelroNone
+	}
+
+	if vals, err := file.DynValue(elf.DT_BIND_NOW); err == nil && len(vals) > 0 {
+		return RelroFull
+	}
+	if vals, err := file.DynValue(elf.DT_FLAGS); err == nil {
+		for _, f := range vals {
+			if elf.DynFlag(f)&elf.DF_BIND_NOW != 0 {
+				return RelroFull
+			}
+		}
+	}
+	if vals, err := file.DynValue(elf.DT_FLAGS_1); err == nil {
+		for _, f := range vals {
+			if elf.DynFlag1(f)&elf.DF_1_NOW != 0 {
+				return RelroFull
+			}
+		}
+	}
+	return RelroPartial
+}
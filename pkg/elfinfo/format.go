@@ -0,0 +1,70 @@
+package elfinfo
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"os"
+)
+
+// Format is an executable file format identified by its magic bytes.
+type Format string
+
+const (
+	FormatUnknown Format = ""
+	FormatELF     Format = "ELF"
+	FormatScript  Format = "script"
+	FormatPE      Format = "PE"
+	FormatMachO   Format = "Mach-O"
+)
+
+// DetectFormat identifies the executable format of the file at path.
+func DetectFormat(path string) (Format, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return FormatUnknown, err
+	}
+	defer f.Close()
+	return DetectFormatFrom(f)
+}
+
+// DetectFormatFrom identifies the executable format of the file read from r.
+func DetectFormatFrom(r io.Reader) (Format, error) {
+	magic := make([]byte, 8)
+	n, err := io.ReadFull(r, magic)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) {
+		if errors.Is(err, io.EOF) {
+			return FormatUnknown, nil
+		}
+		return FormatUnknown, err
+	}
+	return detectFormat(magic[:n]), nil
+}
+
+func detectFormat(magic []byte) Format {
+	switch {
+	case bytes.HasPrefix(magic, []byte("\x7fELF")):
+		return FormatELF
+	case bytes.HasPrefix(magic, []byte("#!")):
+		return FormatScript
+	case bytes.HasPrefix(magic, []byte("MZ")):
+		return FormatPE
+	case len(magic) >= 8 && isMachO(magic):
+		return FormatMachO
+	}
+	return FormatUnknown
+}
+
+func isMachO(magic []byte) bool {
+	switch binary.BigEndian.Uint32(magic) {
+	case 0xfeedface, 0xfeedfacf, 0xcefaedfe, 0xcffaedfe:
+		return true
+	case 0xcafebabe:
+		// Universal binaries share their magic with Java class files, but
+		// are followed by a small number of architectures, whereas class
+		// files are followed by a version number of at least 45.
+		return binary.BigEndian.Uint32(magic[4:]) < 45
+	}
+	return false
+}
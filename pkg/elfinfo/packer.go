@@ -0,0 +1,44 @@
+package elfinfo
+
+import (
+	"bytes"
+	"debug/elf"
+	"errors"
+	"io"
+)
+
+// Packer is an executable packer that compressed a binary. The binary's
+// sections and symbols are those of the packer's decompression stub, not
+// those of the packed program.
+type Packer string
+
+const (
+	PackerNone Packer = ""
+	PackerUPX  Packer = "UPX"
+)
+
+// upxMagic is the magic of the l_info header that UPX writes after the
+// program headers of packed ELF files.
+var upxMagic = []byte("UPX!")
+
+// upxHeaderSize is the size of the start of a file that is searched for the
+// UPX magic: UPX places its header right after the program headers.
+const upxHeaderSize = 4096
+
+// detectPacker returns the packer that compressed the ELF file read from r.
+// Packed files are recognized by the UPX magic near the start of the file,
+// and by having no section headers, which UPX removes.
+func detectPacker(r io.ReaderAt, file *elf.File) Packer {
+	if len(file.Sections) > 0 {
+		return PackerNone
+	}
+	buf := make([]byte, upxHeaderSize)
+	n, err := r.ReadAt(buf, 0)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return PackerNone
+	}
+	if bytes.Contains(buf[:n], upxMagic) {
+		return PackerUPX
+	}
+	return PackerNone
+}
@@ -0,0 +1,134 @@
+package elfinfo
+
+import (
+	"bytes"
+	"debug/elf"
+	"io"
+)
+
+// Feature is a control-flow protection feature that a binary advertises in
+// its GNU property notes. The dynamic loader only enables a feature for a
+// process if the executable and all libraries it loads advertise it.
+type Feature string
+
+const (
+	// FeatureIBT and FeatureSHSTK are Intel CET indirect branch tracking
+	// and shadow stack support on x86 and x86_64.
+	FeatureIBT   Feature = "GNU_PROPERTY_X86_FEATURE_1_IBT"
+	FeatureSHSTK Feature = "GNU_PROPERTY_X86_FEATURE_1_SHSTK"
+	// FeatureBTI and FeaturePAC are branch target identification and
+	// pointer authentication support on aarch64.
+	FeatureBTI Feature = "GNU_PROPERTY_AARCH64_FEATURE_1_BTI"
+	FeaturePAC Feature = "GNU_PROPERTY_AARCH64_FEATURE_1_PAC"
+)
+
+const (
+	ntGNUPropertyType0 = 5
+
+	gnuPropertyX86Feature1And     = 0xc0000002
+	gnuPropertyAArch64Feature1And = 0xc0000000
+)
+
+// featureBits maps the bits of the feature properties of each architecture to
+// features.
+var featureBits = map[elf.Machine]struct {
+	property uint32
+	bits     []Feature
+}{
+	elf.EM_X86_64:  {gnuPropertyX86Feature1And, []Feature{FeatureIBT, FeatureSHSTK}},
+	elf.EM_386:     {gnuPropertyX86Feature1And, []Feature{FeatureIBT, FeatureSHSTK}},
+	elf.EM_AARCH64: {gnuPropertyAArch64Feature1And, []Feature{FeatureBTI, FeaturePAC}},
+}
+
+// RequiredFeatures returns the control-flow protection features that hardened
+// binaries of the given machine type are expected to advertise, or nil if the
+// architecture has none.
+func RequiredFeatures(machine elf.Machine) []Feature {
+	switch machine {
+	case elf.EM_X86_64:
+		return []Feature{FeatureIBT, FeatureSHSTK}
+	case elf.EM_AARCH64:
+		return []Feature{FeatureBTI}
+	}
+	return nil
+}
+
+// getFeatures returns the control-flow protection features advertised in the
+// PT_GNU_PROPERTY segment or, for files without one, in the .note.gnu.property
+// section.
+func getFeatures(file *elf.File) []Feature {
+	fb, ok := featureBits[file.Machine]
+	if !ok {
+		return nil
+	}
+
+	var r io.ReadSeeker
+	for _, p := range file.Progs {
+		if p.Type == elf.PT_GNU_PROPERTY {
+			r = p.Open()
+			break
+		}
+	}
+	if r == nil {
+		s := file.Section(".note.gnu.property")
+		if s == nil || s.Type != elf.SHT_NOTE {
+			return nil
+		}
+		r = s.Open()
+	}
+	data, err := io.ReadAll(io.LimitReader(r, 1<<16))
+	if err != nil {
+		return nil
+	}
+
+	bits := noteProperty(file, data, fb.property)
+	var features []Feature
+	for i, f := range fb.bits {
+		if bits&(1<<i) != 0 {
+			features = append(features, f)
+		}
+	}
+	return features
+}
+
+// noteProperty returns the 32-bit value of the property of type typ in the
+// NT_GNU_PROPERTY_TYPE_0 notes in data, or 0 if there is none. Parsing stops
+// at the first malformed note.
+func noteProperty(file *elf.File, data []byte, typ uint32) uint32 {
+	// Property descriptors are aligned to 8 bytes in 64-bit files, and to
+	// 4 bytes in 32-bit files.
+	align := 4
+	if file.Class == elf.ELFCLASS64 {
+		align = 8
+	}
+	bo := file.ByteOrder
+	for len(data) >= 12 {
+		namesz, descsz, ntype := int(bo.Uint32(data)), int(bo.Uint32(data[4:])), bo.Uint32(data[8:])
+		descOff := 12 + alignUp(namesz, 4)
+		if namesz < 0 || descsz < 0 || descOff+descsz > len(data) {
+			break
+		}
+		name := data[12 : 12+namesz]
+		desc := data[descOff : descOff+descsz]
+		data = data[min(len(data), descOff+alignUp(descsz, align)):]
+		if ntype != ntGNUPropertyType0 || !bytes.Equal(name, []byte("GNU\x00")) {
+			continue
+		}
+
+		for len(desc) >= 8 {
+			prType, prSize := bo.Uint32(desc), int(bo.Uint32(desc[4:]))
+			if prSize < 0 || 8+prSize > len(desc) {
+				break
+			}
+			if prType == typ && prSize >= 4 {
+				return bo.Uint32(desc[8:])
+			}
+			desc = desc[min(len(desc), 8+alignUp(prSize, align)):]
+		}
+	}
+	return 0
+}
+
+func alignUp(n, align int) int {
+	return (n + align - 1) &^ (align - 1)
+}
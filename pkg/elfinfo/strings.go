@@ -0,0 +1,136 @@
+package elfinfo
+
+import (
+	"bytes"
+	"debug/elf"
+	"io"
+	"path"
+	"regexp"
+)
+
+const (
+	// stringsChunkSize is the size of the chunks the read-only data of a
+	// binary is searched in, so that large Go binaries aren't read into
+	// memory at once.
+	stringsChunkSize = 1 << 20
+	// maxPathLen is the longest path FindPaths finds, and the longest
+	// match FindString finds.
+	maxPathLen = 4096
+)
+
+// FindPaths returns the distinct absolute paths of files named name, or name
+// followed by a version suffix, e.g. "/usr/lib64/libcrypto.so.3" for
+// "libcrypto.so", that are embedded in the read-only data (.rodata) of the
+// ELF file read from r, in the order they first occur. It returns nil if the
+// file has no uncompressed .rodata section.
+//
+// Go doesn't terminate its string constants, so a path can directly follow
+// another string. Paths are therefore taken to start at the first "/" and
+// must be at least in one directory below the root.
+func FindPaths(r io.ReaderAt, name string) ([]string, error) {
+	var paths []string
+	seen := map[string]bool{}
+	err := scanRodata(r, func(window []byte, from, to int) bool {
+		for i := from; i < to; {
+			j := bytes.Index(window[i:to], []byte(name))
+			if j < 0 {
+				break
+			}
+			if p := pathAt(window, i+j, len(name)); p != "" && !seen[p] {
+				seen[p] = true
+				paths = append(paths, p)
+			}
+			i += j + len(name)
+		}
+		return true
+	})
+	return paths, err
+}
+
+// FindString returns the first match of re in the read-only data (.rodata) of
+// the ELF file read from r, or "" if there is none. Matches must not be longer
+// than 4096 bytes.
+func FindString(r io.ReaderAt, re *regexp.Regexp) (string, error) {
+	var match string
+	err := scanRodata(r, func(window []byte, from, to int) bool {
+		for _, loc := range re.FindAllIndex(window[from:], -1) {
+			if from+loc[0] >= to {
+				break
+			}
+			if loc[1]-loc[0] <= maxPathLen {
+				match = string(window[from+loc[0] : from+loc[1]])
+				return false
+			}
+		}
+		return true
+	})
+	return match, err
+}
+
+// scanRodata calls fn with the chunks of the uncompressed .rodata section of
+// the ELF file read from r, until fn returns false. Each chunk is
+// window[from:to], and window also holds up to maxPathLen bytes before and
+// after it, so that strings crossing the chunk's boundaries are complete.
+func scanRodata(r io.ReaderAt, fn func(window []byte, from, to int) bool) error {
+	file, err := elf.NewFile(r)
+	if err != nil {
+		return err
+	}
+	sec := file.Section(".rodata")
+	if sec == nil || sec.Type == elf.SHT_NOBITS || sec.Flags&elf.SHF_COMPRESSED != 0 {
+		return nil
+	}
+
+	sr := io.NewSectionReader(r, int64(sec.Offset), int64(sec.Size))
+	buf := make([]byte, maxPathLen+stringsChunkSize+maxPathLen)
+	for offset := int64(0); ; offset += stringsChunkSize {
+		start := max(offset-maxPathLen, 0)
+		n, err := sr.ReadAt(buf, start)
+		if err != nil && err != io.EOF {
+			return err
+		}
+		window, last := buf[:n], err == io.EOF
+		from := int(offset - start)
+		to := min(from+stringsChunkSize, len(window))
+		if last {
+			to = len(window)
+		}
+		if !fn(window, from, to) || last {
+			return nil
+		}
+	}
+}
+
+// pathAt returns the absolute path whose file name starts with the name of
+// length n at data[i:], or "" if there is none.
+func pathAt(data []byte, i, n int) string {
+	if i == 0 || data[i-1] != '/' {
+		return ""
+	}
+	start := i
+	for start > 0 && i-start < maxPathLen && isPathByte(data[start-1]) {
+		start--
+	}
+	// Only version suffixes, e.g. ".3" or ".1.1", are part of the name.
+	end := i + n
+	for end+1 < len(data) && data[end] == '.' && isDigit(data[end+1]) {
+		end++
+		for end < len(data) && isDigit(data[end]) {
+			end++
+		}
+	}
+	slash := bytes.IndexByte(data[start:end], '/')
+	p := string(data[start+slash : end])
+	if path.Dir(p) == "/" || path.Clean(p) != p {
+		return ""
+	}
+	return p
+}
+
+func isPathByte(b byte) bool {
+	return b >= 'a' && b <= 'z' || b >= 'A' && b <= 'Z' || isDigit(b) || b == '/' || b == '.' || b == '_' || b == '-' || b == '+'
+}
+
+func isDigit(b byte) bool {
+	return b >= '0' && b <= '9'
+}
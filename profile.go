@@ -0,0 +1,61 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"runtime/pprof"
+)
+
+var (
+	cpuProfile string
+	memProfile string
+	cpuFile    *os.File
+)
+
+// startProfiling starts writing a CPU profile if --cpuprofile is set.
+func startProfiling() error {
+	if cpuProfile == "" {
+		return nil
+	}
+	f, err := os.Create(cpuProfile)
+	if err != nil {
+		return fmt.Errorf("failed to create CPU profile: %v", err)
+	}
+	if err := pprof.StartCPUProfile(f); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to start CPU profile: %v", err)
+	}
+	cpuFile = f
+	return nil
+}
+
+// stopProfiling finishes the CPU profile and writes a heap profile if
+// --memprofile is set. Errors are printed, but don't change the exit code.
+func stopProfiling() {
+	if cpuFile != nil {
+		pprof.StopCPUProfile()
+		cpuFile.Close()
+		cpuFile = nil
+	}
+	if memProfile == "" {
+		return
+	}
+	f, err := os.Create(memProfile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: failed to create memory profile: %v\n", err)
+		return
+	}
+	defer f.Close()
+	// Get up-to-date statistics of the memory that is still in use.
+	runtime.GC()
+	if err := pprof.WriteHeapProfile(f); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: failed to write memory profile: %v\n", err)
+	}
+}
+
+// exit writes the profiles, if any, and exits with the given code.
+func exit(code int) {
+	stopProfiling()
+	os.Exit(code)
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/flightctl/fips-validator/internal/report"
+	"github.com/flightctl/fips-validator/internal/validation"
+)
+
+// printRulesComparison lists the binaries whose verdict under the policy's
+// CompareRules differs from their verdict under its rules.
+func printRulesComparison(r *report.Report, policy *validation.Policy) {
+	rules := policy.Rules
+	if rules == nil {
+		rules = validation.DefaultRules()
+	}
+	a, b := rules.Version, policy.CompareRules.Version
+
+	fmt.Fprintf(out, "\nComparing rules %s with rules %s:\n", a, b)
+	n := 0
+	for _, t := range r.Targets {
+		for _, res := range t.Binaries {
+			if res.CompareStatus == "" || res.CompareStatus == res.Status {
+				continue
+			}
+			n++
+			if res.Status == validation.StatusPassed {
+				fmt.Fprintf(out, "• %s passes under rules %s but fails under rules %s\n", res.Path, a, b)
+			} else {
+				fmt.Fprintf(out, "• %s fails under rules %s but passes under rules %s\n", res.Path, a, b)
+			}
+		}
+	}
+	if n == 0 {
+		fmt.Fprintf(out, "• all binaries have the same verdict under both rules\n")
+	}
+	fmt.Fprintln(out)
+}
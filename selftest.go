@@ -0,0 +1,55 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/flightctl/fips-validator/internal/selftest"
+	"github.com/flightctl/fips-validator/internal/validation"
+)
+
+// runSelftest validates the embedded fixture binaries, printing each one's
+// verdict, and returns the exit code: 0 if all verdicts are as expected, 1 if
+// the validator's behavior has drifted.
+func runSelftest(args []string) (int, error) {
+	if len(args) > 0 {
+		return 0, fmt.Errorf("selftest: incorrect number of arguments")
+	}
+	info("Validating the self-test fixtures:\n")
+	drifted := 0
+	for _, r := range selftest.Run(context.TODO(), debug) {
+		fmt.Fprintf(out, "• %s (%s)... ", r.Description, r.Path)
+		if r.OK() {
+			success("%s\n", verdict(r.Status, r.FailedChecks))
+			continue
+		}
+		drifted++
+		failure("%s, expected %s\n", verdict(r.Status, r.FailedChecks), verdict(expectedStatus(r.Failures), r.Failures))
+		for _, f := range r.Binary.Findings {
+			fmt.Fprintf(out, "  %s: %s\n", f.Severity, f.Message)
+		}
+	}
+	fmt.Fprintln(out)
+	if drifted > 0 {
+		failure("Self-test failed: %d of %d fixtures got an unexpected verdict\n", drifted, len(selftest.Fixtures))
+		return 1, nil
+	}
+	success("Self-test passed\n")
+	return 0, nil
+}
+
+// verdict describes a fixture's status and the checks it failed.
+func verdict(status validation.Status, failed []string) string {
+	if len(failed) == 0 {
+		return string(status)
+	}
+	return fmt.Sprintf("%s (%s)", status, strings.Join(failed, ", "))
+}
+
+func expectedStatus(failures []string) validation.Status {
+	if len(failures) > 0 {
+		return validation.StatusFailed
+	}
+	return validation.StatusPassed
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/flightctl/fips-validator/internal/executor"
+	"github.com/flightctl/fips-validator/internal/report"
+)
+
+// squashfsMagic starts every squashfs image, as written by mksquashfs.
+var squashfsMagic = []byte("hsqs")
+
+// isSquashfs returns whether the file at path is a squashfs image.
+func isSquashfs(path string) bool {
+	f, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+	magic := make([]byte, len(squashfsMagic))
+	if _, err := io.ReadFull(f, magic); err != nil {
+		return false
+	}
+	return bytes.Equal(magic, squashfsMagic)
+}
+
+// validateSquashfs mounts the squashfs image at imagePath read-only with
+// squashfuse and validates it like a directory tree.
+func validateSquashfs(imagePath string) (*report.Target, error) {
+	path, err := filepath.Abs(imagePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get absolute path: %v", err)
+	}
+	if !isSquashfs(path) {
+		return nil, fmt.Errorf("%s is not a squashfs image", path)
+	}
+	info("Validating squashfs image %q:\n", path)
+
+	tempDir, err := os.MkdirTemp("", "fips-validator-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+	debug("Using temporary directory %s\n", tempDir)
+
+	rootPath := filepath.Join(tempDir, "rootfs")
+	if err := os.Mkdir(rootPath, 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create mount point: %v", err)
+	}
+	if err := mountSquashfs(path, rootPath); err != nil {
+		return nil, err
+	}
+	defer unmountSquashfs(rootPath)
+
+	openssl, err := validateOpenSSL(out, rootPath)
+	if err != nil {
+		return nil, err
+	}
+	results, stoppedEarly, err := scanDirTree(out, rootPath)
+	if err != nil {
+		return nil, err
+	}
+	t := newTarget("squashfs", path, openssl, results)
+	t.StoppedEarly = stoppedEarly
+	if wantSubjects() {
+		subject, err := fileSubject(path)
+		if err != nil {
+			return nil, err
+		}
+		t.Subjects = []report.Subject{subject}
+	}
+	return t, nil
+}
+
+func mountSquashfs(image, mountPoint string) error {
+	fmt.Fprintf(out, "• mounting squashfs image... ")
+	_, stderr, rc, err := executor.Execute(context.TODO(), "", "squashfuse", "-o", "ro", image, mountPoint)
+	if err != nil {
+		return commandError("failed to mount squashfs image", err)
+	}
+	if rc != 0 {
+		return fmt.Errorf("failed to mount squashfs image, exit code %d: %s", rc, string(stderr))
+	}
+	success("done\n")
+	return nil
+}
+
+// fuseUnmounters are the commands that unmount a FUSE file system, in order
+// of preference: fusermount3 of FUSE 3, which squashfuse usually links
+// against, fusermount of FUSE 2, and umount, which only works as root.
+var fuseUnmounters = [][]string{{"fusermount3", "-u"}, {"fusermount", "-u"}, {"umount"}}
+
+func unmountSquashfs(mountPoint string) error {
+	fmt.Fprintf(out, "• unmounting squashfs image... ")
+	for _, cmd := range fuseUnmounters {
+		if !executor.Available(cmd[0]) {
+			continue
+		}
+		_, stderr, rc, err := executor.Execute(context.TODO(), "", cmd[0], append(cmd[1:], mountPoint)...)
+		if err != nil {
+			return fmt.Errorf("failed to unmount squashfs image: %v", err)
+		}
+		if rc != 0 {
+			return fmt.Errorf("failed to unmount squashfs image, exit code %d: %s", rc, string(stderr))
+		}
+		success("done\n")
+		return nil
+	}
+	return notInstalledError("fusermount3")
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/flightctl/fips-validator/internal/executor"
+	"github.com/flightctl/fips-validator/internal/report"
+	"github.com/flightctl/fips-validator/internal/validation"
+)
+
+// externalTool is a command that validating some targets depends on.
+type externalTool struct {
+	// pkg is the package that provides the command on Fedora and RHEL.
+	pkg string
+	// use describes what the command is needed for.
+	use string
+}
+
+var externalTools = map[string]externalTool{
+	"podman":      {pkg: "podman", use: "validate OCI images"},
+	"rpm2cpio":    {pkg: "rpm", use: "validate RPM packages"},
+	"cpio":        {pkg: "cpio", use: "validate RPM packages"},
+	"ostree":      {pkg: "ostree", use: "validate ostree commits"},
+	"squashfuse":  {pkg: "squashfuse", use: "validate squashfs images"},
+	"fusermount3": {pkg: "fuse3", use: "unmount squashfs images"},
+	"git":         {pkg: "git", use: "find the files changed since a git ref"},
+}
+
+// modeTools lists the external tools required by each mode. nm isn't among
+// them, as libcrypto's symbols are read in-process if it isn't installed.
+var modeTools = map[string][]string{
+	"rpm":      {"rpm2cpio", "cpio"},
+	"image":    {"podman"},
+	"ostree":   {"ostree"},
+	"squashfs": {"squashfuse"},
+}
+
+// requiredTools returns the external tools required to validate a target in
+// mode: the mode's tools, and git with --since-git.
+func requiredTools(mode string) []string {
+	tools := slices.Clone(modeTools[mode])
+	if sinceGit != "" {
+		tools = append(tools, "git")
+	}
+	return tools
+}
+
+// checkTools returns an error if an external tool required by mode isn't
+// installed, so that validation fails before any work is done.
+func checkTools(mode string) error {
+	for _, tool := range requiredTools(mode) {
+		if !executor.Available(tool) {
+			return notInstalledError(tool)
+		}
+	}
+	return nil
+}
+
+// usedTools returns the external tools that validating a target in mode may
+// run: the tools it requires, nm for reading libcrypto's symbols unless
+// that's done in-process, zstd and xz for decompressing initramfs images if
+// the boot is checked, and upx for unpacking packed binaries. Optional
+// tools that aren't installed are left out.
+func usedTools(mode string) []string {
+	tools := requiredTools(mode)
+	validatesOpenSSL := mode == "image" || mode == "dir" || mode == "ostree" || mode == "squashfs" || ((mode == "tar" || mode == "zip") && opensslOnly)
+	if validatesOpenSSL && !noOpenSSL && !policy.OpenSSL.InProcess {
+		tools = append(tools, "nm")
+	}
+	if validatesOpenSSL && !noOpenSSL && policy.Boot.FipsMode != validation.EnforcementAllow {
+		tools = append(tools, "zstd", "xz")
+	}
+	if !opensslOnly {
+		tools = append(tools, "upx")
+	}
+	return tools
+}
+
+// probeTools returns the path and version of each installed tool.
+func probeTools(tools []string) []report.Tool {
+	var probed []report.Tool
+	for _, name := range tools {
+		path, err := exec.LookPath(name)
+		if err != nil {
+			continue
+		}
+		t := report.Tool{Name: name, Path: path, Version: toolVersion(name)}
+		debug("using %s from %s, version %q", name, path, t.Version)
+		probed = append(probed, t)
+	}
+	return probed
+}
+
+// toolVersion returns the version reported by "<tool> --version", or "" if
+// it reports none. Tools print their version in various formats, so the first
+// line of the output that contains a digit is taken, e.g. "GNU nm (GNU
+// Binutils) 2.41" or "podman version 5.2.2".
+func toolVersion(tool string) string {
+	stdout, stderr, rc, err := executor.Execute(context.TODO(), "", tool, "--version")
+	if err != nil || rc != 0 {
+		return ""
+	}
+	for _, line := range strings.Split(string(stdout)+string(stderr), "\n") {
+		line = strings.TrimSpace(line)
+		if strings.ContainsFunc(line, unicode.IsDigit) {
+			return line
+		}
+	}
+	return ""
+}
+
+// executedCommands are the commands traced by traceCommands.
+var (
+	executedCommandsMu sync.Mutex
+	executedCommands   []report.Command
+)
+
+// traceCommands logs every external command with its working directory and
+// exit code as a debug message, and records it for the JSON report, so that
+// failing steps can be reproduced by hand.
+func traceCommands() {
+	executor.Trace = func(c executor.Command) {
+		where := ""
+		if c.Dir != "" {
+			where = " in " + c.Dir
+		}
+		debug("ran %s%s: exit code %d after %s", c, where, c.ExitCode, c.Duration.Round(time.Millisecond))
+		executedCommandsMu.Lock()
+		defer executedCommandsMu.Unlock()
+		executedCommands = append(executedCommands, report.Command{Args: c.Args, Dir: c.Dir, ExitCode: c.ExitCode})
+	}
+}
+
+// notInstalledError returns an error telling which package to install to get
+// tool.
+func notInstalledError(tool string) error {
+	t, ok := externalTools[tool]
+	if !ok {
+		return fmt.Errorf("%s not found, install it and make sure it is in the PATH", tool)
+	}
+	return fmt.Errorf("%s not found, install the %s package to %s", tool, t.pkg, t.use)
+}
+
+// commandError describes the error of running an external tool as msg, unless
+// the tool isn't installed, in which case it tells which package to install.
+func commandError(msg string, err error) error {
+	var nie *executor.NotInstalledError
+	if errors.As(err, &nie) {
+		return notInstalledError(nie.Tool)
+	}
+	return fmt.Errorf("%s: %v", msg, err)
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+
+	"github.com/flightctl/fips-validator/internal/report"
+)
+
+// manifest is the report that "verify" compares the target against, or nil
+// if not verifying.
+var manifest *report.Report
+
+// hashFiles returns whether the SHA-256 digests of all files need to be
+// recorded, for writing a manifest or comparing against one.
+func hashFiles() bool {
+	return outputFormat == "manifest" || manifest != nil
+}
+
+// parseVerifyFlags parses the flags of "verify", reads the manifest, and
+// returns the remaining arguments, which select the target.
+func parseVerifyFlags(args []string) (*report.Report, []string, error) {
+	fs := flag.NewFlagSet("verify", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	manifestPath := fs.String("manifest", "", "Manifest written with --output manifest")
+	if err := fs.Parse(args); err != nil {
+		return nil, nil, fmt.Errorf("verify: %v", err)
+	}
+	if *manifestPath == "" {
+		return nil, nil, fmt.Errorf("verify: --manifest is required")
+	}
+	m, err := report.ReadManifest(*manifestPath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("verify: %v", err)
+	}
+	return m, fs.Args(), nil
+}
+
+// printDrift prints the discrepancies between the manifest and the new report
+// and returns the exit code: 0 if there are none, 1 otherwise. The verdict of
+// the validation itself doesn't matter, only whether it changed.
+func printDrift(ds []report.Discrepancy) int {
+	if len(ds) == 0 {
+		success("Target matches the manifest\n")
+		return 0
+	}
+	fmt.Fprintln(out)
+	report.PrintDiscrepancies(out, ds)
+	failure("Target differs from the manifest in %d places\n", len(ds))
+	return 1
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/flightctl/fips-validator/internal/report"
+	"github.com/flightctl/fips-validator/internal/validation"
+)
+
+// writeExecutables writes executable files with the given contents, by path
+// relative to root.
+func writeExecutables(t *testing.T, root string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestVerifyManifest(t *testing.T) {
+	oldOut, oldPolicy, oldFormat, oldManifest := out, policy, outputFormat, manifest
+	t.Cleanup(func() { out, policy, outputFormat, manifest = oldOut, oldPolicy, oldFormat, oldManifest })
+	out = io.Discard
+	policy = validation.DefaultPolicy()
+
+	root := t.TempDir()
+	writeExecutables(t, root, map[string]string{
+		"usr/bin/kept":    "kept",
+		"usr/bin/changed": "before",
+		"usr/bin/removed": "removed",
+	})
+
+	// Write the manifest of the tree, as with --output manifest.
+	outputFormat = "manifest"
+	target, err := validateDirTree(root)
+	if err != nil {
+		t.Fatalf("validateDirTree: %v", err)
+	}
+	manifestPath := filepath.Join(t.TempDir(), "manifest.json")
+	f, err := os.Create(manifestPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := report.WriteJSON(f, report.New(target), false); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	outputFormat = "text"
+	m, args, err := parseVerifyFlags([]string{"--manifest", manifestPath, "dir", root})
+	if err != nil {
+		t.Fatalf("parseVerifyFlags: %v", err)
+	}
+	if len(args) != 2 || args[0] != "dir" || args[1] != root {
+		t.Errorf("parseVerifyFlags returned arguments %q, want dir %s", args, root)
+	}
+	manifest = m
+	if !hashFiles() {
+		t.Fatal("hashFiles() = false when verifying")
+	}
+
+	verify := func() []report.Discrepancy {
+		t.Helper()
+		target, err := validateDirTree(root)
+		if err != nil {
+			t.Fatalf("validateDirTree: %v", err)
+		}
+		return report.Compare(manifest, report.New(target))
+	}
+	if ds := verify(); len(ds) != 0 || printDrift(ds) != 0 {
+		t.Errorf("unchanged tree differs from its manifest: %+v", ds)
+	}
+
+	writeExecutables(t, root, map[string]string{
+		"usr/bin/changed": "after",
+		"usr/bin/added":   "added",
+	})
+	if err := os.Remove(filepath.Join(root, "usr/bin/removed")); err != nil {
+		t.Fatal(err)
+	}
+	ds := verify()
+	want := map[string]report.DriftKind{
+		"/usr/bin/changed": report.DriftChanged,
+		"/usr/bin/added":   report.DriftAdded,
+		"/usr/bin/removed": report.DriftRemoved,
+	}
+	for _, d := range ds {
+		if kind, ok := want[d.Path]; !ok || kind != d.Kind {
+			t.Errorf("unexpected discrepancy %+v", d)
+			continue
+		}
+		delete(want, d.Path)
+	}
+	for path, kind := range want {
+		t.Errorf("missing discrepancy: %s %s", path, kind)
+	}
+
+	var buf bytes.Buffer
+	out = &buf
+	if rc := printDrift(ds); rc != 1 {
+		t.Errorf("printDrift() = %d, want 1", rc)
+	}
+	if !bytes.Contains(buf.Bytes(), []byte("/usr/bin/removed was removed")) {
+		t.Errorf("printDrift() printed %q, want the removed binary", buf.String())
+	}
+}
+
+func TestParseVerifyFlagsErrors(t *testing.T) {
+	for _, args := range [][]string{
+		{"dir", "/"},
+		{"--manifest", filepath.Join(t.TempDir(), "missing.json"), "dir", "/"},
+		{"--bogus", "dir", "/"},
+	} {
+		if _, _, err := parseVerifyFlags(args); err == nil {
+			t.Errorf("parseVerifyFlags(%q) succeeded, want error", args)
+		}
+	}
+}